@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/parser/epub"
+	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/readium/go-toolkit/pkg/streamer"
+	"github.com/readium/go-toolkit/pkg/webvtt"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/html"
+)
+
+// Format of the exported guided navigation documents.
+var guidedFormatFlag string
+
+// Path to the output file or directory.
+var guidedOutputFlag string
+
+// Audio file to caption, when a resource is narrated by several audio files.
+var guidedAudioFlag string
+
+// Indentation used to pretty-print the JSON documents.
+var guidedIndentFlag string
+
+var guidedNavigationCmd = &cobra.Command{
+	Use:   "guided-navigation <pub-path> [<resource-href>]",
+	Short: "Export the guided navigation documents of a publication",
+	Long: `Export the guided navigation documents of a publication.
+
+Guided navigation documents are built from the media overlays (SMIL) of an EPUB,
+or the frames of a comic book described by an ACBF document. They can be
+exported as JSON, as EPUB 3 Media Overlay documents (SMIL), or as WebVTT
+captions of the narration audio.
+
+Without a resource, the resources having a guided navigation document are listed,
+or all exported into the directory given with --output.
+
+Examples:
+  List the resources having a guided navigation document.
+  $ rwp guided-navigation publication.epub
+
+  Print out the captions of the narration of a chapter.
+  $ rwp guided-navigation --format vtt publication.epub OEBPS/chapter1.xhtml
+
+  Export the media overlays of all the resources into a directory.
+  $ rwp guided-navigation --format smil --output overlays/ publication.epub
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("expects a path to the publication")
+		} else if len(args) > 2 {
+			return errors.New("accepts a path to a publication and an optional resource")
+		}
+		switch guidedFormatFlag {
+		case "json", "smil", "vtt":
+		default:
+			return errors.New(`format must be one of "json", "smil", or "vtt"`)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// By the time we reach this point, we know that the arguments were
+		// properly parsed, and we don't want to show the usage if an API error
+		// occurs.
+		cmd.SilenceUsage = true
+
+		path := filepath.Clean(args[0])
+		publication, err := streamer.New(streamer.Config{}).Open(asset.File(path), "")
+		if err != nil {
+			return fmt.Errorf("failed opening %s: %w", path, err)
+		}
+		defer publication.Close()
+
+		service, ok := publication.FindService(pub.GuidedNavigationService_Name).(pub.GuidedNavigationService)
+		if !ok {
+			return fmt.Errorf("%s has no guided navigation", path)
+		}
+		exporter := guidedNavigationExporter{
+			publication: publication,
+			service:     service,
+		}
+
+		if len(args) == 2 {
+			href := strings.TrimPrefix(args[1], "/")
+			if !service.HasGuideForResource(href) {
+				return fmt.Errorf("%s has no guided navigation document", href)
+			}
+			if guidedOutputFlag == "" {
+				return exporter.export(cmd.OutOrStdout(), href, guidedAudioFlag)
+			}
+			return exporter.exportFile(guidedOutputFlag, href, guidedAudioFlag)
+		}
+
+		for _, link := range publication.Manifest.ReadingOrder {
+			href := strings.TrimPrefix(link.Href, "/")
+			if !service.HasGuideForResource(href) {
+				continue
+			}
+			if guidedOutputFlag == "" {
+				fmt.Fprintln(cmd.OutOrStdout(), href)
+				continue
+			}
+			if err := exporter.exportAll(guidedOutputFlag, href); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(guidedNavigationCmd)
+	guidedNavigationCmd.Flags().StringVarP(&guidedFormatFlag, "format", "f", "json", "Output format: json, smil, or vtt")
+	guidedNavigationCmd.Flags().StringVarP(&guidedOutputFlag, "output", "o", "", "Output file, or directory when exporting all the resources")
+	guidedNavigationCmd.Flags().StringVarP(&guidedIndentFlag, "indent", "i", "", "Indentation used to pretty-print the json format")
+	guidedNavigationCmd.Flags().StringVar(&guidedAudioFlag, "audio", "", "Audio file to caption with the vtt format, defaults to the first one of the resource")
+}
+
+type guidedNavigationExporter struct {
+	publication *pub.Publication
+	service     pub.GuidedNavigationService
+	texts       map[string]map[string]string // Text of the elements of the publication's resources, by ID.
+}
+
+// Exports the guided navigation of every resource into the [dir] directory, with the same layout as the publication.
+func (e *guidedNavigationExporter) exportAll(dir string, href string) error {
+	if guidedFormatFlag != "vtt" {
+		return e.exportFile(filepath.Join(dir, filepath.FromSlash(outputHref(href, guidedFormatFlag))), href, "")
+	}
+
+	doc, err := e.service.GuideForResource(href)
+	if err != nil {
+		return fmt.Errorf("failed building guided navigation of %s: %w", href, err)
+	}
+	// One caption file is needed for each audio file
+	files := webvtt.AudioFiles(*doc)
+	for _, audio := range files {
+		name := outputHref(href, "vtt")
+		if len(files) > 1 {
+			name = strings.TrimSuffix(name, ".vtt") + "." + strings.TrimSuffix(path.Base(audio), path.Ext(audio)) + ".vtt"
+		}
+		if err := e.exportFile(filepath.Join(dir, filepath.FromSlash(name)), href, audio); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *guidedNavigationExporter) exportFile(name string, href string, audio string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := e.export(f, href, audio); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (e *guidedNavigationExporter) export(w io.Writer, href string, audio string) error {
+	doc, err := e.service.GuideForResource(href)
+	if err != nil {
+		return fmt.Errorf("failed building guided navigation of %s: %w", href, err)
+	}
+
+	switch guidedFormatFlag {
+	case "smil":
+		err = epub.WriteSMILDocument(w, *doc, outputHref(href, "smil"))
+	case "vtt":
+		if audio == "" {
+			files := webvtt.AudioFiles(*doc)
+			if len(files) == 0 {
+				return fmt.Errorf("%s has no narration audio", href)
+			}
+			if len(files) > 1 {
+				fmt.Fprintf(os.Stderr, "%s is narrated by %d audio files, captioning %s\n", href, len(files), files[0])
+			}
+			audio = files[0]
+		}
+		e.fillTexts(doc.Guided)
+		err = webvtt.Write(w, webvtt.Cues(*doc, strings.TrimPrefix(audio, "/")))
+	default:
+		var bin []byte
+		if guidedIndentFlag == "" {
+			bin, err = json.Marshal(doc)
+		} else {
+			bin, err = json.MarshalIndent(doc, "", guidedIndentFlag)
+		}
+		if err == nil {
+			_, err = w.Write(append(bin, '\n'))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed exporting guided navigation of %s: %w", href, err)
+	}
+	return nil
+}
+
+// Path of the exported document of the resource at [href], with the extension of the [format].
+func outputHref(href string, format string) string {
+	return strings.TrimSuffix(href, path.Ext(href)) + "." + format
+}
+
+// Fills the missing text of the objects with the text content of the element they reference.
+func (e *guidedNavigationExporter) fillTexts(objects []manifest.GuidedNavigationObject) {
+	for i := range objects {
+		o := &objects[i]
+		if o.Text == "" && o.TextRef != "" {
+			file, id, _ := strings.Cut(o.TextRef, "#")
+			if id != "" {
+				o.Text = e.elementTexts(file)[id]
+			}
+		}
+		e.fillTexts(o.Children)
+	}
+}
+
+func (e *guidedNavigationExporter) elementTexts(href string) map[string]string {
+	if texts, ok := e.texts[href]; ok {
+		return texts
+	}
+	if e.texts == nil {
+		e.texts = make(map[string]map[string]string)
+	}
+	texts := make(map[string]string)
+	e.texts[href] = texts
+
+	link := e.publication.Find("/" + strings.TrimPrefix(href, "/"))
+	if link == nil {
+		return texts
+	}
+	res := e.publication.Get(*link)
+	defer res.Close()
+	data, rerr := res.Read(0, 0)
+	if rerr != nil {
+		return texts
+	}
+	root, err := html.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		return texts
+	}
+
+	var collect func(n *html.Node, sb *strings.Builder)
+	collect = func(n *html.Node, sb *strings.Builder) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c, sb)
+		}
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, attr := range n.Attr {
+				if attr.Key == "id" && attr.Namespace == "" {
+					var sb strings.Builder
+					collect(n, &sb)
+					texts[attr.Val] = strings.Join(strings.Fields(sb.String()), " ")
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return texts
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes an EPUB whose first page is narrated by a single audio file, and whose second page by two audio files.
+func writeMediaOverlayEPUB(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "overlays.epub")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, entry := range []struct{ name, content string }{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">overlays</dc:identifier>
+    <dc:title>Overlays</dc:title>
+  </metadata>
+  <manifest>
+    <item id="page1" href="page1.xhtml" media-type="application/xhtml+xml" media-overlay="page1-mo"/>
+    <item id="page1-mo" href="page1.smil" media-type="application/smil+xml"/>
+    <item id="page2" href="page2.xhtml" media-type="application/xhtml+xml" media-overlay="page2-mo"/>
+    <item id="page2-mo" href="page2.smil" media-type="application/smil+xml"/>
+    <item id="page3" href="page3.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="page1"/><itemref idref="page2"/><itemref idref="page3"/></spine>
+</package>`},
+		{"OEBPS/page1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+  <p><span id="w0">Alice was</span> <span id="w1">beginning   to get</span></p>
+</body></html>`},
+		{"OEBPS/page1.smil", `<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"><body>
+  <par><text src="page1.xhtml#w0"/><audio src="audio/page1.m4a" clipBegin="0s" clipEnd="0.84s"/></par>
+  <par><text src="page1.xhtml#w1"/><audio src="audio/page1.m4a" clipBegin="0.84s" clipEnd="3.69s"/></par>
+</body></smil>`},
+		{"OEBPS/page2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+  <p id="p1">very tired</p><p id="p2">of sitting</p>
+</body></html>`},
+		{"OEBPS/page2.smil", `<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"><body>
+  <par><text src="page2.xhtml#p1"/><audio src="audio/a.mp3" clipBegin="1s" clipEnd="2s"/></par>
+  <par><text src="page2.xhtml#p2"/><audio src="audio/b.mp3" clipEnd="1.5s"/></par>
+</body></smil>`},
+		{"OEBPS/page3.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>by her sister</p></body></html>`},
+	} {
+		w, err := zw.Create(entry.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return path
+}
+
+// Runs the guided-navigation command with the given arguments, and returns its output.
+func runGuidedNavigation(t *testing.T, args ...string) (string, error) {
+	// The flags keep their values between the executions of the command
+	guidedFormatFlag, guidedOutputFlag, guidedAudioFlag, guidedIndentFlag = "json", "", "", ""
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"guided-navigation"}, args...))
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	})
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestGuidedNavigationList(t *testing.T) {
+	out, err := runGuidedNavigation(t, writeMediaOverlayEPUB(t))
+	require.NoError(t, err)
+	assert.Equal(t, "OEBPS/page1.xhtml\nOEBPS/page2.xhtml\n", out)
+}
+
+func TestGuidedNavigationJSON(t *testing.T) {
+	out, err := runGuidedNavigation(t, writeMediaOverlayEPUB(t), "OEBPS/page1.xhtml")
+	require.NoError(t, err)
+	var doc manifest.GuidedNavigationDocument
+	require.NoError(t, json.Unmarshal([]byte(out), &doc))
+	require.Len(t, doc.Guided, 2)
+	assert.Equal(t, "OEBPS/page1.xhtml#w0", doc.Guided[0].TextRef)
+	assert.Equal(t, "OEBPS/audio/page1.m4a#t=0,0.84", doc.Guided[0].AudioRef)
+	assert.Equal(t, "OEBPS/audio/page1.m4a#t=0.84,3.69", doc.Guided[1].AudioRef)
+
+	// The leading slash of the resource is optional
+	indented, err := runGuidedNavigation(t, "--indent", "  ", writeMediaOverlayEPUB(t), "/OEBPS/page1.xhtml")
+	require.NoError(t, err)
+	assert.Contains(t, indented, "\n  ")
+	var indentedDoc manifest.GuidedNavigationDocument
+	require.NoError(t, json.Unmarshal([]byte(indented), &indentedDoc))
+	assert.Equal(t, doc, indentedDoc)
+}
+
+func TestGuidedNavigationSMIL(t *testing.T) {
+	out, err := runGuidedNavigation(t, "--format", "smil", writeMediaOverlayEPUB(t), "OEBPS/page2.xhtml")
+	require.NoError(t, err)
+	assert.Contains(t, out, `<text src="page2.xhtml#p1"/>`)
+	assert.Contains(t, out, `<audio src="audio/a.mp3" clipBegin="1s" clipEnd="2s"/>`)
+	assert.Contains(t, out, `<audio src="audio/b.mp3" clipEnd="1.5s"/>`)
+}
+
+func TestGuidedNavigationVTT(t *testing.T) {
+	epub := writeMediaOverlayEPUB(t)
+
+	out, err := runGuidedNavigation(t, "--format", "vtt", epub, "OEBPS/page1.xhtml")
+	require.NoError(t, err)
+	// The text of the cues is the text content of the referenced elements
+	assert.Equal(t, "WEBVTT\n\n"+
+		"00:00:00.000 --> 00:00:00.840\nAlice was\n\n"+
+		"00:00:00.840 --> 00:00:03.690\nbeginning to get\n", out)
+
+	out, err = runGuidedNavigation(t, "--format", "vtt", "--audio", "OEBPS/audio/b.mp3", epub, "OEBPS/page2.xhtml")
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nof sitting\n", out)
+}
+
+func TestGuidedNavigationExportAll(t *testing.T) {
+	epub := writeMediaOverlayEPUB(t)
+	dir := t.TempDir()
+
+	_, err := runGuidedNavigation(t, "--format", "vtt", "--output", dir, epub)
+	require.NoError(t, err)
+	// One caption file is written for each audio file
+	for _, name := range []string{"page1.vtt", "page2.a.vtt", "page2.b.vtt"} {
+		assert.FileExists(t, filepath.Join(dir, "OEBPS", name))
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "OEBPS", "page3.vtt"))
+
+	_, err = runGuidedNavigation(t, "--format", "smil", "--output", dir, epub)
+	require.NoError(t, err)
+	smil, err := os.ReadFile(filepath.Join(dir, "OEBPS", "page2.smil"))
+	require.NoError(t, err)
+	assert.Contains(t, string(smil), `<audio src="audio/a.mp3" clipBegin="1s" clipEnd="2s"/>`)
+
+	file := filepath.Join(dir, "page1.json")
+	_, err = runGuidedNavigation(t, "--output", file, epub, "OEBPS/page1.xhtml")
+	require.NoError(t, err)
+	assert.FileExists(t, file)
+}
+
+func TestGuidedNavigationErrors(t *testing.T) {
+	epub := writeMediaOverlayEPUB(t)
+
+	_, err := runGuidedNavigation(t, epub, "OEBPS/page3.xhtml")
+	assert.ErrorContains(t, err, "OEBPS/page3.xhtml has no guided navigation document")
+
+	_, err = runGuidedNavigation(t, "--format", "txt", epub)
+	assert.ErrorContains(t, err, "format must be one of")
+
+	_, err = runGuidedNavigation(t)
+	assert.ErrorContains(t, err, "expects a path to the publication")
+}
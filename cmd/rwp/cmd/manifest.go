@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 
 	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/parser/epub"
 	"github.com/readium/go-toolkit/pkg/streamer"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,30 @@ var inferA11yFlag InferA11yMetadata
 // Infer the number of pages from the generated position list.
 var inferPageCountFlag bool
 
+// Scan the EPUB resources for undeclared content features.
+var scanContentFeaturesFlag bool
+
+// Read the dimensions of the fixed-layout EPUB resources from their viewport.
+var readViewportDimensionsFlag bool
+
+// Read the durations of the EPUB media overlays from their SMIL documents.
+var readMediaOverlayDurationsFlag bool
+
+// Keep the non-linear EPUB spine items in the reading order.
+var nonLinearFlag bool
+
+// Detect the language of EPUBs without dc:language from their text.
+var inferLanguageFlag bool
+
+// Preferred languages of the EPUB rendition.
+var renditionLanguagesFlag []string
+
+// Preferred access modes of the EPUB rendition.
+var renditionAccessModesFlag []string
+
+// Preferred layout of the EPUB rendition.
+var renditionLayoutFlag string
+
 var manifestCmd = &cobra.Command{
 	Use:   "manifest <pub-path>",
 	Short: "Generate a Readium Web Publication Manifest for a publication",
@@ -54,9 +80,24 @@ Examples:
 		cmd.SilenceUsage = true
 
 		path := filepath.Clean(args[0])
+		epubConfig := epub.ParserConfig{
+			ScanContentFeatures:       scanContentFeaturesFlag,
+			ReadViewportDimensions:    readViewportDimensionsFlag,
+			ReadMediaOverlayDurations: readMediaOverlayDurationsFlag,
+			NonLinearInReadingOrder:   nonLinearFlag,
+			InferLanguage:             inferLanguageFlag,
+		}
+		if len(renditionLanguagesFlag) > 0 || len(renditionAccessModesFlag) > 0 || renditionLayoutFlag != "" {
+			epubConfig.RenditionSelection = &epub.RenditionSelection{
+				Layout:      manifest.EPUBLayout(renditionLayoutFlag),
+				Languages:   renditionLanguagesFlag,
+				AccessModes: renditionAccessModesFlag,
+			}
+		}
 		pub, err := streamer.New(streamer.Config{
 			InferA11yMetadata: streamer.InferA11yMetadata(inferA11yFlag),
 			InferPageCount:    inferPageCountFlag,
+			EPUBParserConfig:  epubConfig,
 		}).Open(
 			asset.File(path), "",
 		)
@@ -84,6 +125,14 @@ func init() {
 	manifestCmd.Flags().StringVarP(&indentFlag, "indent", "i", "", "Indentation used to pretty-print")
 	manifestCmd.Flags().Var(&inferA11yFlag, "infer-a11y", "Infer accessibility metadata: no, merged, split")
 	manifestCmd.Flags().BoolVar(&inferPageCountFlag, "infer-page-count", false, "Infer the number of pages from the generated position list.")
+	manifestCmd.Flags().BoolVar(&scanContentFeaturesFlag, "scan-content-features", false, "Scan the EPUB resources for the MathML, SVG, scripts and remote resources missing from the package document, e.g. to infer accessibility metadata.")
+	manifestCmd.Flags().BoolVar(&readViewportDimensionsFlag, "read-viewport-dimensions", false, "Read the dimensions of the fixed-layout EPUB resources from their viewport, when the package document doesn't declare them.")
+	manifestCmd.Flags().BoolVar(&readMediaOverlayDurationsFlag, "read-media-overlay-durations", false, "Read the durations of the EPUB media overlays from their SMIL documents, when the package document doesn't declare them.")
+	manifestCmd.Flags().BoolVar(&nonLinearFlag, "non-linear", false, "Keep the non-linear EPUB spine items in the reading order.")
+	manifestCmd.Flags().BoolVar(&inferLanguageFlag, "infer-language", false, "Detect the language of EPUBs without dc:language from their text.")
+	manifestCmd.Flags().StringSliceVar(&renditionLanguagesFlag, "rendition-language", nil, "Preferred languages of the rendition of EPUBs with multiple renditions, by decreasing order of preference.")
+	manifestCmd.Flags().StringSliceVar(&renditionAccessModesFlag, "rendition-access-mode", nil, "Preferred access modes of the rendition of EPUBs with multiple renditions, e.g. textual.")
+	manifestCmd.Flags().StringVar(&renditionLayoutFlag, "rendition-layout", "", "Preferred layout of the rendition of EPUBs with multiple renditions: reflowable or fixed.")
 }
 
 type InferA11yMetadata streamer.InferA11yMetadata
@@ -117,6 +166,11 @@ func (e *InferA11yMetadata) Set(v string) error {
 	return nil
 }
 
+// UnmarshalText decodes the value from a configuration file.
+func (e *InferA11yMetadata) UnmarshalText(text []byte) error {
+	return e.Set(string(text))
+}
+
 // Type is only used in help text.
 func (e *InferA11yMetadata) Type() string {
 	return "string"
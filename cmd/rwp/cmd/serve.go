@@ -1,28 +1,29 @@
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
 	"log/slog"
 
 	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve"
-	"github.com/readium/go-toolkit/pkg/streamer"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
-var debugFlag bool
+// Configuration of the server, bound to the flags.
+var serveConf = defaultServeConfig()
 
-var bindAddressFlag string
-
-var bindPortFlag uint16
+// Path to a TOML or YAML configuration file.
+var serveConfigFlag string
 
 var serveCmd = &cobra.Command{
-	Use:   "serve <directory>",
+	Use:   "serve [<directory>]",
 	Short: "Start a local HTTP server, serving a specified directory of publications",
 	Long: `Start a local HTTP server, serving a specified directory of publications.
 
@@ -33,16 +34,25 @@ as Readium Web Publications. To get started, the manifest can be accessed from
 This file serves as the entry point and contains metadata and links to the rest
 of the files that can be accessed for the publication.
 
-For debugging purposes, the server also exposes a '/list.json' endpoint that
-returns a list of all the publications found in the directory along with their
-encoded paths. This will be replaced by an OPDS 2 feed in a future release.
+The publications found in the directory and its subdirectories are listed in
+an OPDS 2 feed at 'http://localhost:15080/opds.json', which can be browsed by
+directory and filtered by format or language. The directory is scanned
+periodically, so that publications added, modified or removed are picked up
+without restarting the server.
+
+The server can be configured with the flags below, or with a TOML or YAML file
+given with --config, whose keys are documented in the README. The flags take
+precedence over the configuration file.
+
+The publications are public by default. Access can be restricted with static
+bearer tokens, sent in the Authorization header, and with expiring signed URLs
+generated with the 'sign' command, both configured in the [auth] section of the
+configuration file.
 
 Note: This server is not meant for production usage, and should not be exposed
-to the internet except for testing/debugging purposes.`,
+to the internet without authentication, except for testing/debugging purposes.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return errors.New("expects a directory path to serve publications from")
-		} else if len(args) > 1 {
+		if len(args) > 1 {
 			return errors.New("accepts a directory path")
 		}
 		return nil
@@ -55,42 +65,60 @@ to the internet except for testing/debugging purposes.`,
 		// occurs.
 		cmd.SilenceUsage = true
 
-		path := filepath.Clean(args[0])
-		fi, err := os.Stat(path)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("given directory %s does not exist", path)
+		if serveConfigFlag != "" {
+			if err := serveConf.load(serveConfigFlag, cmd); err != nil {
+				return err
 			}
-			return fmt.Errorf("failed to stat %s: %w", path, err)
 		}
-		if !fi.IsDir() {
-			return fmt.Errorf("given path %s is not a directory", path)
+		if len(args) > 0 {
+			serveConf.Directory = args[0]
+		}
+		if err := serveConf.validate(); err != nil {
+			return err
 		}
 
 		// Log level
-		if debugFlag {
+		if serveConf.Debug {
 			slog.SetLogLoggerLevel(slog.LevelDebug)
 		} else {
 			slog.SetLogLoggerLevel(slog.LevelInfo)
 		}
 
-		pubServer := serve.NewServer(serve.ServerConfig{
-			Debug:             debugFlag,
-			BaseDirectory:     path,
-			JSONIndent:        indentFlag,
-			InferA11yMetadata: streamer.InferA11yMetadata(inferA11yFlag),
-		})
+		pubServer := serve.NewServer(serveConf.serverConfig())
+		go pubServer.WatchLibrary(context.Background())
+
+		var handler http.Handler = pubServer.Routes()
+		useTLS := serveConf.TLS.Cert != ""
+		if serveConf.HTTP2 && !useTLS {
+			handler = h2c.NewHandler(handler, &http2.Server{})
+		}
 
-		bind := fmt.Sprintf("%s:%d", bindAddressFlag, bindPortFlag)
+		bind := fmt.Sprintf("%s:%d", serveConf.Address, serveConf.Port)
 		httpServer := &http.Server{
-			ReadTimeout:    10 * time.Second,
-			WriteTimeout:   10 * time.Second,
+			ReadTimeout:    time.Duration(serveConf.Timeouts.Read),
+			WriteTimeout:   time.Duration(serveConf.Timeouts.Write),
+			IdleTimeout:    time.Duration(serveConf.Timeouts.Idle),
 			MaxHeaderBytes: 1 << 20,
 			Addr:           bind,
-			Handler:        pubServer.Routes(),
+			Handler:        handler,
 		}
-		slog.Info("Starting HTTP server", "address", "http://"+httpServer.Addr)
-		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		if useTLS && !serveConf.HTTP2 {
+			// A non-nil empty map disables HTTP/2 over TLS
+			httpServer.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+
+		scheme := "http://"
+		if useTLS {
+			scheme = "https://"
+		}
+		slog.Info("Starting HTTP server", "address", scheme+httpServer.Addr+serveConf.BasePath)
+		var err error
+		if useTLS {
+			err = httpServer.ListenAndServeTLS(serveConf.TLS.Cert, serveConf.TLS.Key)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
 			slog.Error("Server stopped", "error", err)
 		} else {
 			slog.Info("Goodbye!")
@@ -103,10 +131,6 @@ to the internet except for testing/debugging purposes.`,
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
-	serveCmd.Flags().StringVarP(&bindAddressFlag, "address", "a", "localhost", "Address to bind the HTTP server to")
-	serveCmd.Flags().Uint16VarP(&bindPortFlag, "port", "p", 15080, "Port to bind the HTTP server to")
-	serveCmd.Flags().StringVarP(&indentFlag, "indent", "i", "", "Indentation used to pretty-print JSON files")
-	serveCmd.Flags().Var(&inferA11yFlag, "infer-a11y", "Infer accessibility metadata: no, merged, split")
-	serveCmd.Flags().BoolVarP(&debugFlag, "debug", "d", false, "Enable debug mode")
-
+	serveCmd.Flags().StringVarP(&serveConfigFlag, "config", "c", "", "Path to a TOML or YAML configuration file")
+	serveConf.bindFlags(serveCmd.Flags())
 }
@@ -2,9 +2,11 @@ package serve
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
@@ -15,7 +17,6 @@ import (
 	"syscall"
 
 	"github.com/gorilla/mux"
-	httprange "github.com/gotd/contrib/http_range"
 	"github.com/pkg/errors"
 	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve/cache"
 	"github.com/readium/go-toolkit/pkg/archive"
@@ -27,79 +28,93 @@ import (
 	"github.com/zeebo/xxh3"
 )
 
-type demoListItem struct {
-	Filename string `json:"filename"`
-	Path     string `json:"path"`
+// Opens the publication at the given [path], relative to the base directory, recording the failures in the
+// metrics.
+func (s *Server) openPublication(path string) (*pub.Publication, error) {
+	publication, err := s.parsePublication(path)
+	if err != nil {
+		s.metrics.observeOpenFailure(asset.File(filepath.Join(s.config.BaseDirectory, path)).MediaType().String())
+		return nil, err
+	}
+	return publication, nil
 }
 
-func (s *Server) demoList(w http.ResponseWriter, req *http.Request) {
-	fi, err := os.ReadDir(s.config.BaseDirectory)
+// Opens the publication at the given [path], relative to the base directory, without recording the failures in
+// the metrics, e.g. when scanning files which might not be publications.
+func (s *Server) parsePublication(path string) (*pub.Publication, error) {
+	a := asset.File(filepath.Join(s.config.BaseDirectory, path))
+	publication, err := streamer.New(streamer.Config{
+		InferA11yMetadata: s.config.InferA11yMetadata,
+	}).Open(a, "")
 	if err != nil {
-		slog.Error("failed reading publications directory", "error", err)
-		w.WriteHeader(500)
-		return
-	}
-	files := make([]demoListItem, len(fi))
-	for i, f := range fi {
-		files[i] = demoListItem{
-			Filename: f.Name(),
-			Path:     base64.RawURLEncoding.EncodeToString([]byte(f.Name())),
-		}
+		return nil, errors.Wrap(err, "failed opening "+path)
 	}
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", s.config.JSONIndent)
-	enc.Encode(files)
+	return publication, nil
 }
 
-func (s *Server) getPublication(filename string) (*pub.Publication, error) {
-	fpath, err := base64.RawURLEncoding.DecodeString(filename)
+// Returns the publication whose path is encoded in [filename], from the cache or freshly opened.
+// The publication is acquired for the caller, which must release it once done reading it.
+func (s *Server) getPublication(filename string) (*cache.CachedPublication, error) {
+	fpath, err := decodePublicationPath(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	cp := filepath.Clean(string(fpath))
-	dat, ok := s.lfu.Get(cp)
-	if !ok {
-		pub, err := streamer.New(streamer.Config{
-			InferA11yMetadata: s.config.InferA11yMetadata,
-		}).Open(asset.File(filepath.Join(s.config.BaseDirectory, cp)), "")
-		if err != nil {
-			return nil, errors.Wrap(err, "failed opening "+cp)
-		}
-
-		// TODO: Remove this after we make links relative in the go-toolkit
-		for i, link := range pub.Manifest.Links {
-			pub.Manifest.Links[i] = makeRelative(link)
-		}
-		for i, link := range pub.Manifest.Resources {
-			pub.Manifest.Resources[i] = makeRelative(link)
-		}
-		for i, link := range pub.Manifest.ReadingOrder {
-			pub.Manifest.ReadingOrder[i] = makeRelative(link)
+	cp := filepath.Clean(fpath)
+	fi, err := os.Stat(filepath.Join(s.config.BaseDirectory, cp))
+	if err != nil {
+		s.lfu.Del(cp)
+		return nil, err
+	}
+	dat, ok := s.lfu.GetIf(cp, func(e cache.Evictable) bool {
+		if e.(*cache.CachedPublication).IsStale(fi.ModTime(), fi.Size()) {
+			slog.Debug("publication changed on disk", "path", cp)
+			return false
 		}
-		for i, link := range pub.Manifest.TableOfContents {
-			pub.Manifest.TableOfContents[i] = makeRelative(link)
+		return true
+	})
+	if ok {
+		if cached := dat.(*cache.CachedPublication); cached.Acquire() {
+			return cached, nil
 		}
-		var makeCollectionRelative func(mp manifest.PublicationCollectionMap)
-		makeCollectionRelative = func(mp manifest.PublicationCollectionMap) {
-			for i := range mp {
-				for j := range mp[i] {
-					for k := range mp[i][j].Links {
-						mp[i][j].Links[k] = makeRelative(mp[i][j].Links[k])
-					}
-					makeCollectionRelative(mp[i][j].Subcollections)
+	}
+	pub, err := s.openPublication(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: Remove this after we make links relative in the go-toolkit
+	for i, link := range pub.Manifest.Links {
+		pub.Manifest.Links[i] = makeRelative(link)
+	}
+	for i, link := range pub.Manifest.Resources {
+		pub.Manifest.Resources[i] = makeRelative(link)
+	}
+	for i, link := range pub.Manifest.ReadingOrder {
+		pub.Manifest.ReadingOrder[i] = makeRelative(link)
+	}
+	for i, link := range pub.Manifest.TableOfContents {
+		pub.Manifest.TableOfContents[i] = makeRelative(link)
+	}
+	var makeCollectionRelative func(mp manifest.PublicationCollectionMap)
+	makeCollectionRelative = func(mp manifest.PublicationCollectionMap) {
+		for i := range mp {
+			for j := range mp[i] {
+				for k := range mp[i][j].Links {
+					mp[i][j].Links[k] = makeRelative(mp[i][j].Links[k])
 				}
+				makeCollectionRelative(mp[i][j].Subcollections)
 			}
 		}
-		makeCollectionRelative(pub.Manifest.Subcollections)
+	}
+	makeCollectionRelative(pub.Manifest.Subcollections)
 
-		// Cache the publication
-		encPub := &cache.CachedPublication{Publication: pub}
-		s.lfu.Set(cp, encPub)
+	// Cache the publication
+	encPub := &cache.CachedPublication{Publication: pub, ModTime: fi.ModTime(), Size: fi.Size()}
+	encPub.Acquire()
+	s.lfu.Set(cp, encPub)
 
-		return encPub.Publication, nil
-	}
-	return dat.(*cache.CachedPublication).Publication, nil
+	return encPub, nil
 }
 
 func (s *Server) getManifest(w http.ResponseWriter, req *http.Request) {
@@ -113,19 +128,14 @@ func (s *Server) getManifest(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(500)
 		return
 	}
+	defer publication.Release()
 
 	// Create "self" link in manifest
-	scheme := "http://"
-	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
-		// Note: this is never going to be 100% accurate behind proxies,
-		// but it's better than nothing for a dev server.
-		scheme = "https://"
-	}
 	rPath, _ := s.router.Get("manifest").URLPath("path", vars["path"])
 	selfLink := &manifest.Link{
 		Rels: manifest.Strings{"self"},
 		Type: conformsToAsMimetype(publication.Manifest.Metadata.ConformsTo),
-		Href: scheme + req.Host + rPath.String(),
+		Href: baseURL(req) + rPath.String(),
 	}
 
 	// Marshal the manifest
@@ -157,7 +167,6 @@ func (s *Server) getManifest(w http.ResponseWriter, req *http.Request) {
 	// Add headers
 	w.Header().Set("content-type", conformsToAsMimetype(publication.Manifest.Metadata.ConformsTo)+"; charset=utf-8")
 	w.Header().Set("cache-control", "private, must-revalidate")
-	w.Header().Set("access-control-allow-origin", "*") // TODO: provide options?
 
 	// Etag based on hash of the manifest bytes
 	etag := `"` + strconv.FormatUint(xxh3.Hash(identJSON.Bytes()), 36) + `"`
@@ -178,6 +187,52 @@ func (s *Server) getManifest(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func (s *Server) getContent(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	filename := vars["path"]
+
+	// Load the publication
+	publication, err := s.getPublication(filename)
+	if err != nil {
+		slog.Error("failed opening publication", "error", err)
+		w.WriteHeader(500)
+		return
+	}
+	defer publication.Release()
+
+	service, ok := publication.FindService(pub.ContentService_Name).(pub.ContentService)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// Parse the start location and limit of the page
+	query, err := pub.ContentPageQueryFromParameters(publication.Manifest, req.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// Add headers
+	w.Header().Set("content-type", pub.ContentLink.Type+"; charset=utf-8")
+	w.Header().Set("cache-control", "private, must-revalidate")
+
+	// Stream the page, the status can only be changed until the first byte is written
+	rw := &trackingWriter{w: w}
+	err = pub.WriteContentPage(rw, service.Content(query.Start), query, s.config.JSONIndent)
+	if err != nil {
+		if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+			// Ignore client errors
+			return
+		}
+		slog.Error("failed writing content page", "error", err)
+		if !rw.written {
+			w.WriteHeader(500)
+		}
+	}
+}
+
 func (s *Server) getAsset(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["path"]
@@ -189,6 +244,7 @@ func (s *Server) getAsset(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(500)
 		return
 	}
+	defer publication.Release()
 
 	// Make sure the asset exists in the publication
 	href := path.Clean(vars["asset"])
@@ -204,6 +260,11 @@ func (s *Server) getAsset(w http.ResponseWriter, r *http.Request) {
 		finalLink = finalLink.ExpandTemplate(convertURLValuesToMap(r.URL.Query()))
 	}
 
+	// Downscale the pages of comics for small screens
+	if publicationPath, err := decodePublicationPath(filename); err == nil && s.serveResizedPage(w, r, publicationPath, publication.Publication, finalLink) {
+		return
+	}
+
 	// Get the asset from the publication
 	res := publication.Get(finalLink)
 	defer res.Close()
@@ -227,52 +288,68 @@ func (s *Server) getAsset(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", contentType)
 	w.Header().Set("cache-control", "private, max-age=86400, immutable")
 	w.Header().Set("content-length", strconv.FormatInt(l, 10))
-	w.Header().Set("access-control-allow-origin", "*") // TODO: provide options?
+	w.Header().Set("accept-ranges", "bytes")
+
+	// Stream the asset in compressed format if supported by the user agent
+	var encoding string
+	cres, ok := res.(fetcher.CompressedResource)
+	if ok && cres.CompressedAs(archive.CompressionMethodDeflate) {
+		if supportsEncoding(r, "deflate") {
+			encoding = "deflate"
+		} else if supportsEncoding(r, "gzip") && l <= archive.GzipMaxLength {
+			encoding = "gzip"
+		}
+	}
+
+	// Conditional requests
+	v := validatorsOf(res)
+	if v.notModified(r) {
+		v.setHeaders(w, encoding)
+		writeNotModified(w)
+		return
+	}
 
-	var start, end int64
 	// Range reading assets
 	rangeHeader := r.Header.Get("range")
-	if rangeHeader != "" {
-		rng, err := httprange.ParseRange(rangeHeader, l)
-		if err != nil {
-			slog.Error("failed parsing range header", "error", err)
-			w.WriteHeader(http.StatusLengthRequired)
-			return
-		}
-		if len(rng) > 1 {
-			slog.Error("no support for multiple read ranges")
-			w.WriteHeader(http.StatusNotImplemented)
-			return
-		}
-		if len(rng) > 0 {
-			w.Header().Set("content-range", rng[0].ContentRange(l))
-			start = rng[0].Start
-			end = start + rng[0].Length - 1
-			w.Header().Set("content-length", strconv.FormatInt(rng[0].Length, 10))
-		}
+	if !v.rangeApplies(r) {
+		rangeHeader = ""
 	}
-	if w.Header().Get("content-range") != "" {
-		w.WriteHeader(http.StatusPartialContent)
+	ranges, err := parseRanges(rangeHeader, l)
+	if err != nil {
+		slog.Debug("unsatisfiable range header", "error", err)
+		w.Header().Set("content-range", fmt.Sprintf("bytes */%d", l))
+		w.Header().Del("content-length")
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if len(ranges) > 0 {
+		encoding = ""
 	}
+	v.setHeaders(w, encoding)
+	s.metrics.observeStream(encoding)
 
-	cres, ok := res.(fetcher.CompressedResource)
-	if ok && cres.CompressedAs(archive.CompressionMethodDeflate) && start == 0 && end == 0 {
-		// Stream the asset in compressed format if supported by the user agent
-		if supportsEncoding(r, "deflate") {
-			w.Header().Set("content-encoding", "deflate")
-			w.Header().Set("content-length", strconv.FormatInt(cres.CompressedLength(), 10))
-			_, err = cres.StreamCompressed(w)
-		} else if supportsEncoding(r, "gzip") && l <= archive.GzipMaxLength {
-			w.Header().Set("content-encoding", "gzip")
-			w.Header().Set("content-length", strconv.FormatInt(cres.CompressedLength()+archive.GzipWrapperLength, 10))
-			_, err = cres.StreamCompressedGzip(w)
-		} else {
-			// Fall back to normal streaming
-			_, rerr = res.Stream(w, start, end)
-		}
+	if len(ranges) == 1 {
+		w.Header().Set("content-range", ranges[0].ContentRange(l))
+		w.Header().Set("content-length", strconv.FormatInt(ranges[0].Length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		_, rerr = streamRange(w, res, ranges[0])
+	} else if len(ranges) > 1 {
+		boundary := multipart.NewWriter(io.Discard).Boundary()
+		w.Header().Set("content-type", multipartRangesContentType(boundary))
+		w.Header().Set("content-length", strconv.FormatInt(multipartRangesLength(ranges, boundary, contentType, l), 10))
+		w.WriteHeader(http.StatusPartialContent)
+		rerr = streamMultipartRanges(w, res, ranges, boundary, contentType, l)
+	} else if encoding == "deflate" {
+		w.Header().Set("content-encoding", "deflate")
+		w.Header().Set("content-length", strconv.FormatInt(cres.CompressedLength(), 10))
+		_, err = cres.StreamCompressed(w)
+	} else if encoding == "gzip" {
+		w.Header().Set("content-encoding", "gzip")
+		w.Header().Set("content-length", strconv.FormatInt(cres.CompressedLength()+archive.GzipWrapperLength, 10))
+		_, err = cres.StreamCompressedGzip(w)
 	} else {
 		// Stream the asset
-		_, rerr = res.Stream(w, start, end)
+		_, rerr = res.Stream(w, 0, 0)
 	}
 
 	if rerr != nil {
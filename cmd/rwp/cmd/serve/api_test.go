@@ -0,0 +1,93 @@
+package serve
+
+import (
+	"archive/zip"
+	"bytes"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Modification time of the page of the test comics.
+var testPageModified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Writes a comic book archive with a single page of [page] bytes, stored uncompressed.
+func writeTestComic(t *testing.T, path string, page []byte) {
+	writeTestArchive(t, path, map[string][]byte{"page.jpg": page})
+}
+
+// Writes a ZIP archive of the given [entries], stored uncompressed.
+func writeTestArchive(t *testing.T, path string, entries map[string][]byte) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, data := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: testPageModified})
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+// Response writer blocking its first write until [unblock] is closed.
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	writing chan struct{}
+	unblock chan struct{}
+	blocked bool
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if !w.blocked {
+		w.blocked = true
+		close(w.writing)
+		<-w.unblock
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestGetAssetWhilePublicationChanges(t *testing.T) {
+	dir := t.TempDir()
+	page := make([]byte, 300_000)
+	rand.New(rand.NewSource(1)).Read(page)
+	writeTestComic(t, filepath.Join(dir, "comic.cbz"), page)
+
+	s := NewServer(ServerConfig{BaseDirectory: dir})
+	router := s.Routes()
+	assetURL := "/" + encodePath("comic.cbz") + "/page.jpg"
+
+	w := &blockingWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		writing:          make(chan struct{}),
+		unblock:          make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(w, httptest.NewRequest("GET", assetURL, nil))
+	}()
+	<-w.writing
+
+	// The publication is modified while the page is streaming, and evicted from the cache by the next request
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "comic.cbz"), later, later))
+	other := httptest.NewRecorder()
+	router.ServeHTTP(other, httptest.NewRequest("GET", assetURL, nil))
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.True(t, bytes.Equal(page, other.Body.Bytes()))
+
+	close(w.unblock)
+	<-done
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, bytes.Equal(page, w.Body.Bytes()), "the streamed page is complete")
+}
@@ -0,0 +1,203 @@
+package serve
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnauthorized = errors.New("authentication required")
+	ErrForbidden    = errors.New("access to the publication is forbidden")
+)
+
+// Decides whether a request can access a publication.
+type Authorizer interface {
+	// Returns nil if the request can access the publication at [path], relative to the base directory.
+	// Otherwise returns [ErrUnauthorized] if the request lacks valid credentials, or [ErrForbidden] if they don't
+	// grant access to this publication.
+	Authorize(r *http.Request, path string) error
+}
+
+// Static bearer tokens, sent in the Authorization header of the requests.
+// Each token is mapped to the patterns of the publications it grants access to, matched with [path.Match] against
+// their path relative to the base directory. A pattern ending with a slash matches the whole directory. A token
+// without patterns grants access to all the publications.
+type BearerTokens map[string][]string
+
+func (t BearerTokens) Authorize(r *http.Request, p string) error {
+	scheme, token, _ := strings.Cut(r.Header.Get("authorization"), " ")
+	if !strings.EqualFold(scheme, "bearer") || token == "" {
+		return ErrUnauthorized
+	}
+
+	for candidate, patterns := range t {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) != 1 {
+			continue
+		}
+		if len(patterns) == 0 {
+			return nil
+		}
+		for _, pattern := range patterns {
+			if strings.HasSuffix(pattern, "/") && strings.HasPrefix(p, pattern) {
+				return nil
+			}
+			if ok, _ := path.Match(pattern, p); ok {
+				return nil
+			}
+		}
+		return ErrForbidden
+	}
+	return ErrUnauthorized
+}
+
+// Signs and verifies expiring URLs granting access to a single publication, with an HMAC-SHA256 key.
+//
+// The signature is embedded in the first segment of the URLs, after the encoded path of the publication, so that the
+// relative links of the resources (e.g. a stylesheet in an HTML document loaded in an iframe) keep it.
+type URLSigner struct {
+	Key []byte
+}
+
+// Returns the first segment of the URLs granting access to the publication at [path] until [expires], in the form
+// <encoded path>.<expiration>.<signature>.
+func (s URLSigner) Sign(path string, expires time.Time) string {
+	signed := base64.RawURLEncoding.EncodeToString([]byte(path)) + "." + strconv.FormatInt(expires.Unix(), 36)
+	return signed + "." + s.signature(signed)
+}
+
+// Returns the URL of the manifest of the publication at [path], relative to the base directory, signed until
+// [expires]. The [baseURL] of the server includes its base path, if any.
+func (s URLSigner) ManifestURL(baseURL string, path string, expires time.Time) (string, error) {
+	p, err := cleanPublicationPath(filepath.ToSlash(path))
+	if err != nil {
+		return "", errors.Errorf("%s must be relative to the served directory", path)
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + s.Sign(p, expires) + "/manifest.json", nil
+}
+
+func (s URLSigner) signature(signed string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verifies the signature of the first segment of a URL and returns the path of the publication it grants access to.
+func (s URLSigner) Verify(segment string, now time.Time) (string, error) {
+	encoded, rest, ok := strings.Cut(segment, ".")
+	if !ok {
+		return "", ErrUnauthorized
+	}
+	expiration, signature, ok := strings.Cut(rest, ".")
+	if !ok {
+		return "", ErrForbidden
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(encoded+"."+expiration))) {
+		return "", errors.Wrap(ErrForbidden, "invalid signature")
+	}
+	expires, err := strconv.ParseInt(expiration, 36, 64)
+	if err != nil || now.Unix() > expires {
+		return "", errors.Wrap(ErrForbidden, "expired URL")
+	}
+	p, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(ErrForbidden, "invalid path")
+	}
+	cleaned, err := cleanPublicationPath(string(p))
+	if err != nil {
+		return "", errors.Wrap(ErrForbidden, "invalid path")
+	}
+	return cleaned, nil
+}
+
+func (s URLSigner) Authorize(r *http.Request, p string) error {
+	signed, err := s.Verify(mux.Vars(r)["path"], time.Now())
+	if err != nil {
+		return err
+	}
+	if signed != p {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Authorizes the request with the configured authorizers, granting access if any of them does.
+// Returns nil if no authorizer is configured.
+func (s *Server) authorize(r *http.Request, path string) error {
+	if len(s.config.Authorizers) == 0 {
+		return nil
+	}
+	err := ErrUnauthorized
+	for _, a := range s.config.Authorizers {
+		aerr := a.Authorize(r, path)
+		if aerr == nil {
+			return nil
+		}
+		if errors.Is(aerr, ErrForbidden) {
+			err = aerr
+		}
+	}
+	return err
+}
+
+// Middleware rejecting the requests for publications outside of the base directory, and the requests which are not
+// authorized to access the publication of the route.
+func (s *Server) requireAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := decodePublicationPath(mux.Vars(r)["path"])
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(s.config.Authorizers) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := s.authorize(r, p); err != nil {
+			slog.Debug("unauthorized request", "path", p, "error", err)
+			if errors.Is(err, ErrForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+			} else {
+				w.Header().Set("www-authenticate", `Bearer realm="rwp"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var errInvalidPath = errors.New("invalid publication path")
+
+// Decodes the path of a publication, relative to the base directory, from the first segment of its URLs.
+// The signature of a signed URL is ignored.
+func decodePublicationPath(segment string) (string, error) {
+	encoded, _, _ := strings.Cut(segment, ".")
+	p, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(errInvalidPath, err.Error())
+	}
+	return cleanPublicationPath(string(p))
+}
+
+// Cleans a slash-separated path relative to the base directory, rejecting the absolute paths and the ones escaping
+// the base directory.
+func cleanPublicationPath(p string) (string, error) {
+	p = path.Clean(p)
+	if p == "." || !filepath.IsLocal(filepath.FromSlash(p)) {
+		return "", errInvalidPath
+	}
+	return p, nil
+}
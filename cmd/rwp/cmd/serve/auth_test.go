@@ -0,0 +1,198 @@
+package serve
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigningKey = []byte("0123456789abcdef0123456789abcdef")
+
+func encodePath(p string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(p))
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestBearerTokensScopesPublications(t *testing.T) {
+	tokens := BearerTokens{
+		"full":    nil,
+		"novels":  {"novels/"},
+		"pattern": {"comics/*.cbz", "single.epub"},
+	}
+
+	tests := []struct {
+		token string
+		path  string
+		err   error
+	}{
+		{"", "single.epub", ErrUnauthorized},
+		{"unknown", "single.epub", ErrUnauthorized},
+		{"full", "anything/at/all.epub", nil},
+		{"novels", "novels/moby-dick.epub", nil},
+		{"novels", "novels/old/moby-dick.epub", nil},
+		{"novels", "novels-other/moby-dick.epub", ErrForbidden},
+		{"novels", "single.epub", ErrForbidden},
+		{"pattern", "comics/page-blanche.cbz", nil},
+		{"pattern", "comics/old/page-blanche.cbz", ErrForbidden},
+		{"pattern", "comics/page-blanche.epub", ErrForbidden},
+		{"pattern", "single.epub", nil},
+	}
+	for _, tt := range tests {
+		err := tokens.Authorize(bearerRequest(tt.token), tt.path)
+		if tt.err == nil {
+			assert.NoError(t, err, "%s on %s", tt.token, tt.path)
+		} else {
+			assert.ErrorIs(t, err, tt.err, "%s on %s", tt.token, tt.path)
+		}
+	}
+
+	// Other schemes are ignored
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Basic full")
+	assert.ErrorIs(t, tokens.Authorize(r, "single.epub"), ErrUnauthorized)
+}
+
+func TestURLSignerVerify(t *testing.T) {
+	signer := URLSigner{Key: testSigningKey}
+	now := time.Unix(1_700_000_000, 0)
+	segment := signer.Sign("novels/moby-dick.epub", now.Add(time.Hour))
+
+	p, err := signer.Verify(segment, now)
+	require.NoError(t, err)
+	assert.Equal(t, "novels/moby-dick.epub", p)
+
+	// Expired
+	_, err = signer.Verify(segment, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Tampered signature
+	tampered := segment[:len(segment)-1] + "A"
+	if tampered == segment {
+		tampered = segment[:len(segment)-1] + "B"
+	}
+	_, err = signer.Verify(tampered, now)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Tampered expiration
+	parts := strings.Split(segment, ".")
+	_, err = signer.Verify(parts[0]+".zzzzzzz."+parts[2], now)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Signature of another publication
+	other := strings.Split(signer.Sign("novels/other.epub", now.Add(time.Hour)), ".")
+	_, err = signer.Verify(parts[0]+"."+other[1]+"."+other[2], now)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Another key
+	_, err = URLSigner{Key: []byte("another key of at least 32 bytes!")}.Verify(segment, now)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// Unsigned
+	_, err = signer.Verify(encodePath("novels/moby-dick.epub"), now)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// Paths escaping the base directory are never granted
+	_, err = signer.Verify(signer.Sign("../secret.epub", now.Add(time.Hour)), now)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestURLSignerManifestURL(t *testing.T) {
+	signer := URLSigner{Key: testSigningKey}
+	expires := time.Now().Add(time.Hour)
+
+	u, err := signer.ManifestURL("https://example.com/books/", "novels/moby-dick.epub", expires)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/books/"+signer.Sign("novels/moby-dick.epub", expires)+"/manifest.json", u)
+
+	for _, p := range []string{"../moby-dick.epub", "/etc/passwd", "novels/../../moby-dick.epub", "."} {
+		_, err := signer.ManifestURL("https://example.com", p, expires)
+		assert.Error(t, err, p)
+	}
+}
+
+func TestDecodePublicationPathRejectsPathsOutsideBaseDirectory(t *testing.T) {
+	p, err := decodePublicationPath(encodePath("novels//moby-dick.epub") + ".signature")
+	require.NoError(t, err)
+	assert.Equal(t, "novels/moby-dick.epub", p)
+
+	for _, p := range []string{"../secret", "/etc/passwd", "novels/../../secret", "..", ""} {
+		_, err := decodePublicationPath(encodePath(p))
+		assert.ErrorIs(t, err, errInvalidPath, p)
+	}
+	_, err = decodePublicationPath("not base64!")
+	assert.ErrorIs(t, err, errInvalidPath)
+}
+
+// Router serving the publication routes through the authorization middleware.
+func authTestRouter(authorizers ...Authorizer) http.Handler {
+	s := &Server{config: ServerConfig{Authorizers: authorizers}}
+	r := mux.NewRouter()
+	pub := r.PathPrefix("/{path}").Subrouter()
+	pub.Use(s.requireAuthorization)
+	pub.HandleFunc("/{asset:.*}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	return r
+}
+
+func TestRequireAuthorization(t *testing.T) {
+	signer := URLSigner{Key: testSigningKey}
+	router := authTestRouter(BearerTokens{"novels": {"novels/"}}, signer)
+	novel, single := encodePath("novels/moby-dick.epub"), encodePath("single.epub")
+
+	tests := []struct {
+		name    string
+		segment string
+		token   string
+		code    int
+	}{
+		{"no credentials", novel, "", http.StatusUnauthorized},
+		{"unknown token", novel, "unknown", http.StatusUnauthorized},
+		{"token in scope", novel, "novels", http.StatusOK},
+		{"token out of scope", single, "novels", http.StatusForbidden},
+		{"signed URL", signer.Sign("single.epub", time.Now().Add(time.Hour)), "", http.StatusOK},
+		{"expired signed URL", signer.Sign("single.epub", time.Now().Add(-time.Hour)), "", http.StatusForbidden},
+		{"path outside base directory", encodePath("../secret.epub"), "novels", http.StatusNotFound},
+		{"absolute path", encodePath("/etc/passwd"), "novels", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/"+tt.segment+"/manifest.json", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="rwp"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRequireAuthorizationWithoutAuthorizersRejectsPathsOutsideBaseDirectory(t *testing.T) {
+	router := authTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+encodePath("single.epub")+"/manifest.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+encodePath("../../etc/passwd")+"/manifest.json", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -5,6 +5,7 @@ package cache
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/vmihailenco/go-tinylfu"
@@ -27,6 +28,17 @@ type TinyLFU struct {
 	lfu    *tinylfu.T
 	ttl    time.Duration
 	offset time.Duration
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// Statistics of a cache since its creation.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // Items removed from the cache, because of its capacity, their TTL or an explicit deletion.
 }
 
 var _ LocalCache = (*TinyLFU)(nil)
@@ -65,6 +77,7 @@ func (c *TinyLFU) Set(key string, b Evictable) {
 		Value:    b,
 		ExpireAt: time.Now().Add(ttl),
 		OnEvict: func() {
+			c.evictions.Add(1)
 			b.OnEvict()
 		},
 	})
@@ -76,8 +89,30 @@ func (c *TinyLFU) Get(key string) (Evictable, bool) {
 
 	val, ok := c.lfu.Get(key)
 	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+
+	return val.(Evictable), true
+}
+
+// GetIf returns the item of the key when [valid] reports that it's still valid. Otherwise the item is deleted, and
+// the lookup counts as a miss.
+func (c *TinyLFU) GetIf(key string, valid func(Evictable) bool) (Evictable, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, ok := c.lfu.Get(key)
+	if ok && !valid(val.(Evictable)) {
+		c.lfu.Del(key)
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
 		return nil, false
 	}
+	c.hits.Add(1)
 
 	return val.(Evictable), true
 }
@@ -88,3 +123,11 @@ func (c *TinyLFU) Del(key string) {
 
 	c.lfu.Del(key)
 }
+
+func (c *TinyLFU) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
@@ -1,22 +1,73 @@
 package cache
 
 import (
+	"sync"
+	"time"
+
 	"github.com/readium/go-toolkit/pkg/pub"
 )
 
 // CachedPublication implements Evictable
+//
+// The publication is reference-counted: it is closed once evicted from the cache and released by all the requests
+// reading it.
 type CachedPublication struct {
 	*pub.Publication
+
+	// Modification time and size of the publication file when it was opened, to detect changes.
+	ModTime time.Time
+	Size    int64
+
+	mu      sync.Mutex
+	refs    int
+	evicted bool
+	closed  bool
 }
 
 func EncapsulatePublication(pub *pub.Publication) *CachedPublication {
-	cp := &CachedPublication{pub}
+	cp := &CachedPublication{Publication: pub}
 	return cp
 }
 
+// Acquire keeps the publication open until the matching call to [CachedPublication.Release].
+// Returns false if the publication was already closed, in which case it must be opened again.
+func (cp *CachedPublication) Acquire() bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.closed {
+		return false
+	}
+	cp.refs++
+	return true
+}
+
+// Release closes the publication if it was evicted and this was the last reference to it.
+func (cp *CachedPublication) Release() {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.refs--
+	cp.closeIfUnused()
+}
+
 func (cp *CachedPublication) OnEvict() {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.evicted = true
+	cp.closeIfUnused()
+}
+
+func (cp *CachedPublication) closeIfUnused() {
+	if !cp.evicted || cp.refs > 0 || cp.closed {
+		return
+	}
+	cp.closed = true
 	// Cleanup
 	if cp.Publication != nil {
 		cp.Publication.Close()
 	}
 }
+
+// Reports whether the publication file was modified since the publication was opened.
+func (cp *CachedPublication) IsStale(modTime time.Time, size int64) bool {
+	return !cp.ModTime.Equal(modTime) || cp.Size != size
+}
@@ -0,0 +1,236 @@
+package serve
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/pub"
+)
+
+// Publication found in the base directory, with the information needed to list it in the OPDS feed.
+type catalogEntry struct {
+	Path         string            // Path of the publication file, relative to the base directory and slash-separated.
+	Format       string            // Lowercase extension of the publication file, e.g. "epub".
+	Metadata     manifest.Metadata // Metadata of the publication.
+	ManifestType string            // Media type of the manifest of the publication.
+	Cover        *manifest.Link    // Cover of the publication, relative to its manifest. See [findCover].
+
+	modTime time.Time
+	size    int64
+}
+
+// Directory of the publication, relative to the base directory, or an empty string for the base directory itself.
+func (e catalogEntry) Dir() string {
+	if dir := path.Dir(e.Path); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+func (e catalogEntry) sortKey() string {
+	key := e.Metadata.SortAs()
+	if key == "" {
+		key = e.Metadata.Title()
+	}
+	return strings.ToLower(key)
+}
+
+// Reports whether both entries were read from the same version of a file.
+func (e catalogEntry) isSameFile(other catalogEntry) bool {
+	return e.modTime.Equal(other.modTime) && e.size == other.size
+}
+
+// Catalog of the publications found in a directory and its subdirectories.
+// The directory is scanned when the entries are first requested, and then again on each call to [catalog.Refresh],
+// but only the new or modified files are parsed again.
+type catalog struct {
+	baseDirectory string
+	open          func(path string) (*pub.Publication, error) // Opens the publication at the given path, relative to the base directory.
+	onChange      func(path string)                           // Called with the path of each file modified or removed since the previous scan.
+
+	scanMu sync.Mutex // Serializes the scans.
+
+	mu      sync.RWMutex
+	entries []catalogEntry
+	ignored map[string]catalogEntry // Files which are not publications, only their modification time and size are set.
+	scanned bool
+}
+
+func newCatalog(baseDirectory string, open func(path string) (*pub.Publication, error), onChange func(path string)) *catalog {
+	return &catalog{
+		baseDirectory: baseDirectory,
+		open:          open,
+		onChange:      onChange,
+		ignored:       make(map[string]catalogEntry),
+	}
+}
+
+// Returns the publications of the catalog, sorted by title.
+func (c *catalog) Entries() ([]catalogEntry, error) {
+	c.mu.RLock()
+	entries, scanned := c.entries, c.scanned
+	c.mu.RUnlock()
+	if scanned {
+		return entries, nil
+	}
+
+	if err := c.Refresh(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries, nil
+}
+
+// Scans the directory every [interval] to detect the added, modified and removed publications, until [ctx] is done.
+func (c *catalog) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(); err != nil {
+				slog.Error("failed scanning publications directory", "error", err)
+			}
+		}
+	}
+}
+
+// Scans the directory again, and reports the files which were modified or removed since the previous scan.
+func (c *catalog) Refresh() error {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+
+	// Only the scans modify the catalog, so it can be read without holding the lock.
+	previous := make(map[string]catalogEntry, len(c.entries))
+	for _, e := range c.entries {
+		previous[e.Path] = e
+	}
+	previousIgnored := c.ignored
+
+	entries, ignored, err := c.scan(previous, previousIgnored)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries = entries
+	c.ignored = ignored
+	c.scanned = true
+	c.mu.Unlock()
+
+	if c.onChange == nil {
+		return nil
+	}
+	current := make(map[string]catalogEntry, len(entries)+len(ignored))
+	for _, e := range entries {
+		current[e.Path] = e
+	}
+	for p, e := range ignored {
+		current[p] = e
+	}
+	for _, before := range []map[string]catalogEntry{previous, previousIgnored} {
+		for p, e := range before {
+			if after, ok := current[p]; !ok || !after.isSameFile(e) {
+				slog.Debug("publication file changed", "path", p)
+				c.onChange(p)
+			}
+		}
+	}
+	return nil
+}
+
+// Walks the directory, parsing only the files which are not in the [previous] entries or [previousIgnored] files,
+// or which were modified.
+func (c *catalog) scan(previous map[string]catalogEntry, previousIgnored map[string]catalogEntry) ([]catalogEntry, map[string]catalogEntry, error) {
+	entries := []catalogEntry{}
+	ignored := make(map[string]catalogEntry)
+	err := filepath.WalkDir(c.baseDirectory, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			slog.Warn("failed scanning publications directory", "path", p, "error", err)
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != c.baseDirectory {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(c.baseDirectory, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		entry := catalogEntry{
+			Path:    rel,
+			Format:  strings.ToLower(strings.TrimPrefix(path.Ext(rel), ".")),
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+
+		// Unchanged files are not parsed again
+		if e, ok := previous[rel]; ok && e.isSameFile(entry) {
+			entries = append(entries, e)
+			return nil
+		}
+		if e, ok := previousIgnored[rel]; ok && e.isSameFile(entry) {
+			ignored[rel] = e
+			return nil
+		}
+
+		// Sniffing the media type is much cheaper than parsing files which are not publications
+		if mt := asset.File(p).MediaType(); !mt.IsPublication() {
+			slog.Debug("ignoring file which is not a publication", "path", rel, "mediatype", mt.String())
+			ignored[rel] = entry
+			return nil
+		}
+
+		publication, err := c.open(rel)
+		if err != nil {
+			slog.Warn("failed opening publication", "path", rel, "error", err)
+			ignored[rel] = entry
+			return nil
+		}
+		entry.Metadata = publication.Manifest.Metadata
+		entry.ManifestType = conformsToAsMimetype(publication.Manifest.Metadata.ConformsTo)
+		if cover := findCover(publication); cover != nil {
+			link := makeRelative(*cover)
+			entry.Cover = &link
+		}
+		publication.Close()
+
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].sortKey(), entries[j].sortKey()
+		if a != b {
+			return a < b
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, ignored, nil
+}
@@ -0,0 +1,161 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/readium/go-toolkit/pkg/streamer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Catalog of [dir] recording the paths of the parsed files and of the changes it reports.
+type catalogRecorder struct {
+	*catalog
+	opened  []string
+	changed []string
+}
+
+func newCatalogRecorder(dir string) *catalogRecorder {
+	r := &catalogRecorder{}
+	r.catalog = newCatalog(dir, func(path string) (*pub.Publication, error) {
+		r.opened = append(r.opened, path)
+		return streamer.New(streamer.Config{}).Open(asset.File(filepath.Join(dir, path)), "")
+	}, func(path string) {
+		r.changed = append(r.changed, path)
+	})
+	return r
+}
+
+// Refreshes the catalog, and returns the paths of its entries and the files parsed and changed since the previous
+// refresh.
+func (r *catalogRecorder) refresh(t *testing.T) (paths, opened, changed []string) {
+	r.opened, r.changed = nil, nil
+	require.NoError(t, r.Refresh())
+	entries, err := r.Entries()
+	require.NoError(t, err)
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	sort.Strings(paths)
+	sort.Strings(r.opened)
+	sort.Strings(r.changed)
+	return paths, r.opened, r.changed
+}
+
+// Sets the modification time of the file at [path] to [d] from now.
+func touch(t *testing.T, path string, d time.Duration) {
+	at := time.Now().Add(d)
+	require.NoError(t, os.Chtimes(path, at, at))
+}
+
+func TestCatalogScansRecursively(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"sub/deeper", ".hidden"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, d), 0o755))
+	}
+	for _, p := range []string{"a.cbz", "sub/b.cbz", "sub/deeper/c.cbz", ".hidden/d.cbz", ".e.cbz"} {
+		writeTestComic(t, filepath.Join(dir, p), []byte("page"))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "notes.txt"), []byte("notes"), 0o644))
+
+	c := newCatalogRecorder(dir)
+	paths, opened, changed := c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "sub/b.cbz", "sub/deeper/c.cbz"}, paths)
+	// The files which are not publications are not parsed, and the hidden files are skipped
+	assert.Equal(t, []string{"a.cbz", "sub/b.cbz", "sub/deeper/c.cbz"}, opened)
+	assert.Empty(t, changed)
+	assert.Contains(t, c.ignored, "sub/notes.txt")
+}
+
+func TestCatalogRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeTestComic(t, filepath.Join(dir, "a.cbz"), []byte("page"))
+	writeTestComic(t, filepath.Join(dir, "b.cbz"), []byte("page"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+	// Spread the modification times, as their resolution might be coarse
+	touch(t, filepath.Join(dir, "a.cbz"), -time.Hour)
+	touch(t, filepath.Join(dir, "b.cbz"), -time.Hour)
+	touch(t, filepath.Join(dir, "notes.txt"), -time.Hour)
+
+	c := newCatalogRecorder(dir)
+	paths, opened, _ := c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, paths)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, opened)
+
+	// Unchanged files are not parsed again
+	paths, opened, changed := c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, paths)
+	assert.Empty(t, opened)
+	assert.Empty(t, changed)
+
+	// Added
+	writeTestComic(t, filepath.Join(dir, "c.cbz"), []byte("page"))
+	paths, opened, changed = c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "b.cbz", "c.cbz"}, paths)
+	assert.Equal(t, []string{"c.cbz"}, opened)
+	assert.Empty(t, changed)
+
+	// Modified, by their modification time or size
+	touch(t, filepath.Join(dir, "a.cbz"), 0)
+	writeTestComic(t, filepath.Join(dir, "b.cbz"), []byte("larger page"))
+	touch(t, filepath.Join(dir, "b.cbz"), -time.Hour)
+	paths, opened, changed = c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "b.cbz", "c.cbz"}, paths)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, opened)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, changed)
+
+	// Removed, including the files which are not publications
+	require.NoError(t, os.Remove(filepath.Join(dir, "c.cbz")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "notes.txt")))
+	paths, opened, changed = c.refresh(t)
+	assert.Equal(t, []string{"a.cbz", "b.cbz"}, paths)
+	assert.Empty(t, opened)
+	assert.Equal(t, []string{"c.cbz", "notes.txt"}, changed)
+}
+
+func TestCatalogChangesEvictCachedPublications(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "comic.cbz")
+	writeTestComic(t, path, []byte("page"))
+	touch(t, path, -time.Hour)
+	s := NewServer(ServerConfig{BaseDirectory: dir})
+	router := s.Routes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+encodePath("comic.cbz")+"/manifest.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, s.catalog.Refresh())
+	_, ok := s.lfu.Get("comic.cbz")
+	assert.True(t, ok, "unchanged publications stay cached")
+
+	touch(t, path, 0)
+	require.NoError(t, s.catalog.Refresh())
+	_, ok = s.lfu.Get("comic.cbz")
+	assert.False(t, ok, "modified publications are evicted")
+}
+
+func TestCatalogScanDoesNotRecordOpenFailures(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.cbz"), []byte("not a ZIP archive"), 0o644))
+	s := NewServer(ServerConfig{BaseDirectory: dir})
+	router := s.Routes()
+
+	entries, err := s.catalog.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Empty(t, s.metrics.openFailures)
+
+	// Requesting the publication records the failure
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+encodePath("broken.cbz")+"/manifest.json", nil))
+	assert.NotEqual(t, http.StatusOK, w.Code)
+	assert.Len(t, s.metrics.openFailures, 1)
+}
@@ -0,0 +1,90 @@
+package serve
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+)
+
+// Validators of the current representation of an asset, used to evaluate conditional requests.
+type validators struct {
+	ETag     string    // Strong entity tag, without quotes. Empty if unknown.
+	Modified time.Time // Last modification time, truncated to seconds. Zero if unknown.
+}
+
+func validatorsOf(res fetcher.Resource) validators {
+	return validators{
+		ETag:     fetcher.ResourceETag(res),
+		Modified: fetcher.ResourceModTime(res).Truncate(time.Second),
+	}
+}
+
+// Sets the ETag and Last-Modified headers of the response. The [encoding] of the content, if any, is appended to the
+// entity tag, as the compressed representation differs from the original bytes.
+func (v validators) setHeaders(w http.ResponseWriter, encoding string) {
+	if v.ETag != "" {
+		etag := v.ETag
+		if encoding != "" {
+			etag += "-" + encoding
+		}
+		w.Header().Set("etag", `"`+etag+`"`)
+	}
+	if !v.Modified.IsZero() {
+		w.Header().Set("last-modified", v.Modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// Reports whether the client already has the current representation of the asset, according to the If-None-Match
+// or If-Modified-Since headers of the request.
+func (v validators) notModified(r *http.Request) bool {
+	if inm := r.Header.Get("if-none-match"); inm != "" {
+		// If-Modified-Since is ignored when If-None-Match is present.
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				return true
+			}
+			// Weak comparison, which also matches the compressed representations.
+			tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+			tag = strings.TrimSuffix(strings.TrimSuffix(tag, "-deflate"), "-gzip")
+			if v.ETag != "" && tag == v.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("if-modified-since"); ims != "" && !v.Modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err == nil && !v.Modified.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the Range header of the request can be honored, according to its If-Range header.
+// When the validator sent by the client doesn't match the current representation, the full content must be sent.
+func (v validators) rangeApplies(r *http.Request) bool {
+	ir := r.Header.Get("if-range")
+	if ir == "" {
+		return true
+	}
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, "W/") {
+		// Strong comparison, weak entity tags never match.
+		return v.ETag != "" && ir == `"`+v.ETag+`"`
+	}
+	t, err := http.ParseTime(ir)
+	return err == nil && !v.Modified.IsZero() && v.Modified.Equal(t)
+}
+
+// Writes a 304 Not Modified response, without the headers describing the content.
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("content-type")
+	h.Del("content-length")
+	h.Del("content-encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
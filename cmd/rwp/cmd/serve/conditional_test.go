@@ -0,0 +1,120 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAssetConditional(t *testing.T) {
+	router, assetURL, page := rangeTestServer(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", assetURL, nil))
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+-3e8"$`, etag)
+	lastModified := testPageModified.Format(http.TimeFormat)
+	assert.Equal(t, lastModified, w.Header().Get("Last-Modified"))
+
+	before := testPageModified.Add(-time.Hour).Format(http.TimeFormat)
+	after := testPageModified.Add(time.Hour).Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+	}{
+		{"strong If-None-Match", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak If-None-Match", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"If-None-Match of a compressed representation", map[string]string{"If-None-Match": etag[:len(etag)-1] + `-gzip"`}, http.StatusNotModified},
+		{"If-None-Match list", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"If-None-Match wildcard", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"mismatched If-None-Match", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"If-Modified-Since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"If-Modified-Since later", map[string]string{"If-Modified-Since": after}, http.StatusNotModified},
+		{"If-Modified-Since earlier", map[string]string{"If-Modified-Since": before}, http.StatusOK},
+		{"invalid If-Modified-Since", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"If-Modified-Since with a matching ETag", map[string]string{"If-Modified-Since": before, "If-None-Match": etag}, http.StatusNotModified},
+		{"If-Modified-Since with a mismatched ETag", map[string]string{"If-Modified-Since": after, "If-None-Match": `"other"`}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", assetURL, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			assert.Equal(t, lastModified, w.Header().Get("Last-Modified"))
+			if tt.code == http.StatusNotModified {
+				assert.Empty(t, w.Body.Bytes())
+				assert.Empty(t, w.Header().Get("Content-Type"))
+				assert.Empty(t, w.Header().Get("Content-Length"))
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+			} else {
+				assert.Equal(t, page, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestGetAssetIfRange(t *testing.T) {
+	router, assetURL, page := rangeTestServer(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", assetURL, nil))
+	etag := w.Header().Get("ETag")
+
+	tests := []struct {
+		name    string
+		ifRange string
+		code    int
+	}{
+		{"matching ETag", etag, http.StatusPartialContent},
+		{"mismatched ETag", `"other"`, http.StatusOK},
+		{"weak ETag", "W/" + etag, http.StatusOK},
+		{"matching date", testPageModified.Format(http.TimeFormat), http.StatusPartialContent},
+		{"mismatched date", testPageModified.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", assetURL, nil)
+			r.Header.Set("Range", "bytes=0-9")
+			r.Header.Set("If-Range", tt.ifRange)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusPartialContent {
+				assert.Equal(t, page[:10], w.Body.Bytes())
+			} else {
+				assert.Equal(t, page, w.Body.Bytes())
+				assert.Empty(t, w.Header().Get("Content-Range"))
+			}
+		})
+	}
+}
+
+func TestValidatorsWithoutETag(t *testing.T) {
+	v := validators{Modified: testPageModified}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-Modified-Since", testPageModified.Format(http.TimeFormat))
+	assert.True(t, v.notModified(r))
+
+	// If-Modified-Since is ignored when If-None-Match is present
+	r.Header.Set("If-None-Match", `"other"`)
+	assert.False(t, v.notModified(r))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-Range", `"other"`)
+	assert.False(t, v.rangeApplies(r))
+
+	w := httptest.NewRecorder()
+	v.setHeaders(w, "gzip")
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Equal(t, testPageModified.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+}
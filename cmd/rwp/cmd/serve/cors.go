@@ -0,0 +1,48 @@
+package serve
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Response headers readable by cross-origin clients, in addition to the safelisted ones.
+var corsExposedHeaders = []string{"Accept-Ranges", "Content-Encoding", "Content-Range", "ETag", "Last-Modified"}
+
+// Middleware adding the CORS headers to the responses for the allowed origins, and answering the preflight requests.
+func (s *Server) cors(next http.Handler) http.Handler {
+	origins := s.config.CORSAllowedOrigins
+	allowAny := slices.Contains(origins, "*")
+	exposed := strings.Join(corsExposedHeaders, ", ")
+	allowedHeaders := strings.Join(s.config.CORSAllowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("origin")
+		h := w.Header()
+		if allowAny {
+			h.Set("access-control-allow-origin", "*")
+		} else if origin != "" && slices.Contains(origins, origin) {
+			h.Set("access-control-allow-origin", origin)
+			h.Add("vary", "Origin")
+		} else {
+			if origin != "" {
+				h.Add("vary", "Origin")
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("access-control-expose-headers", exposed)
+
+		if r.Method == http.MethodOptions && r.Header.Get("access-control-request-method") != "" {
+			// Preflight request
+			h.Set("access-control-allow-methods", "GET, HEAD, OPTIONS")
+			if allowedHeaders != "" {
+				h.Set("access-control-allow-headers", allowedHeaders)
+			}
+			h.Set("access-control-max-age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
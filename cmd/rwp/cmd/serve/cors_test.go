@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func corsTestHandler(origins ...string) http.Handler {
+	s := &Server{config: ServerConfig{
+		CORSAllowedOrigins: origins,
+		CORSAllowedHeaders: []string{"Authorization", "Range"},
+	}}
+	return s.cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+}
+
+func TestCORSAllowsAnyOrigin(t *testing.T) {
+	r := httptest.NewRequest("GET", "/opds.json", nil)
+	r.Header.Set("Origin", "https://reader.example.com")
+	w := httptest.NewRecorder()
+	corsTestHandler("*").ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Content-Range")
+	assert.Empty(t, w.Header().Values("Vary"))
+}
+
+func TestCORSAllowsListedOrigins(t *testing.T) {
+	handler := corsTestHandler("https://reader.example.com")
+
+	r := httptest.NewRequest("GET", "/opds.json", nil)
+	r.Header.Set("Origin", "https://reader.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "https://reader.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+
+	r = httptest.NewRequest("GET", "/opds.json", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+}
+
+func TestCORSWithoutOrigins(t *testing.T) {
+	r := httptest.NewRequest("GET", "/opds.json", nil)
+	r.Header.Set("Origin", "https://reader.example.com")
+	w := httptest.NewRecorder()
+	corsTestHandler().ServeHTTP(w, r)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := httptest.NewRequest("OPTIONS", "/abc/manifest.json", nil)
+	r.Header.Set("Origin", "https://reader.example.com")
+	r.Header.Set("Access-Control-Request-Method", "GET")
+	r.Header.Set("Access-Control-Request-Headers", "authorization")
+	w := httptest.NewRecorder()
+	corsTestHandler("https://reader.example.com").ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization, Range", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "86400", w.Header().Get("Access-Control-Max-Age"))
+
+	// Preflight requests from other origins reach the handler, without CORS headers
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	corsTestHandler("https://reader.example.com").ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
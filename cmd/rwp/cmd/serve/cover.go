@@ -0,0 +1,91 @@
+package serve
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/pub"
+)
+
+// Height of the cover thumbnails listed in the OPDS feed, in pixels.
+const OPDSThumbnailHeight = 400
+
+// Returns the cover of the publication: the link with the cover relation, or the first bitmap of the reading order,
+// e.g. for comics.
+func findCover(publication *pub.Publication) *manifest.Link {
+	if cover := publication.LinkWithRel("cover"); cover != nil {
+		return cover
+	}
+	for _, link := range publication.Manifest.ReadingOrder {
+		if link.MediaType().IsBitmap() {
+			return &link
+		}
+	}
+	return nil
+}
+
+func (s *Server) getCover(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	query, err := imageQueryFromRequest(r)
+	if err != nil {
+		if errors.Is(err, errNotAcceptable) {
+			w.WriteHeader(http.StatusNotAcceptable)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if r.URL.Query().Get("format") == "" {
+		w.Header().Add("vary", "Accept")
+	}
+
+	// The cover changes only with the publication file
+	publicationPath, err := decodePublicationPath(vars["path"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	file := fetcher.NewFileResource(manifest.Link{}, filepath.Join(s.config.BaseDirectory, publicationPath))
+	v := validatorsOf(file)
+	if v.ETag == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("cache-control", "private, max-age=86400, must-revalidate")
+	s.writeImage(w, r, v, imageCacheName(query, "cover", publicationPath, v.ETag), func() ([]byte, int, error) {
+		return s.renderCover(vars["path"], query)
+	})
+}
+
+// Decodes, resizes and encodes the cover of the publication, returning the HTTP status of the failure if any.
+func (s *Server) renderCover(filename string, query imageQuery) ([]byte, int, error) {
+	publication, err := s.getPublication(filename)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer publication.Release()
+	link := findCover(publication.Publication)
+	if link == nil {
+		return nil, http.StatusNotFound, errors.New("publication has no cover")
+	}
+
+	res := publication.Get(*link)
+	defer res.Close()
+	b, rerr := res.Read(0, 0)
+	if rerr != nil {
+		return nil, rerr.HTTPStatus(), rerr
+	}
+	data, err := transcodeImage(b, query)
+	if err != nil {
+		// Covers in other formats, such as SVG, can't be resized
+		return nil, http.StatusNotFound, errors.Wrap(err, "failed transcoding cover "+link.Href)
+	}
+	return data, http.StatusOK, nil
+}
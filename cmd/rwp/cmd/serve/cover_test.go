@@ -0,0 +1,140 @@
+package serve
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCover(t *testing.T) {
+	publication := &pub.Publication{Manifest: manifest.Manifest{
+		ReadingOrder: manifest.LinkList{
+			{Href: "chapter.xhtml", Type: "application/xhtml+xml"},
+			{Href: "page1.jpg", Type: "image/jpeg"},
+			{Href: "page2.jpg", Type: "image/jpeg"},
+		},
+	}}
+	cover := findCover(publication)
+	require.NotNil(t, cover)
+	assert.Equal(t, "page1.jpg", cover.Href)
+
+	publication.Manifest.Resources = manifest.LinkList{{Href: "cover.png", Type: "image/png", Rels: manifest.Strings{"cover"}}}
+	cover = findCover(publication)
+	require.NotNil(t, cover)
+	assert.Equal(t, "cover.png", cover.Href)
+
+	assert.Nil(t, findCover(&pub.Publication{Manifest: manifest.Manifest{
+		ReadingOrder: manifest.LinkList{{Href: "chapter.xhtml", Type: "application/xhtml+xml"}},
+	}}))
+}
+
+// Server of a comic whose first page is a 600x800 PNG, caching the resized images in [cacheDir].
+func coverTestServer(t *testing.T, cacheDir string) (http.Handler, string) {
+	dir := t.TempDir()
+	var page bytes.Buffer
+	require.NoError(t, png.Encode(&page, image.NewGray(image.Rect(0, 0, 600, 800))))
+	writeTestArchive(t, filepath.Join(dir, "comic.cbz"), map[string][]byte{"page1.png": page.Bytes()})
+
+	s := NewServer(ServerConfig{BaseDirectory: dir, ImageCacheDirectory: cacheDir})
+	return s.Routes(), "/" + encodePath("comic.cbz")
+}
+
+func TestGetCover(t *testing.T) {
+	cacheDir := t.TempDir()
+	router, base := coverTestServer(t, cacheDir)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", base+"/~readium/cover?height=400&format=jpeg", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+-jpeg"$`, etag)
+	img, err := jpeg.Decode(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 400), img.Bounds())
+
+	// The resized cover is cached
+	files, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, strings.Trim(etag, `"`), strings.Replace(files[0].Name(), ".", "-", 1))
+
+	r := httptest.NewRequest("GET", base+"/~readium/cover?height=400&format=jpeg", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+}
+
+func TestGetCoverNegotiatesFormat(t *testing.T) {
+	router, base := coverTestServer(t, "")
+
+	r := httptest.NewRequest("GET", base+"/~readium/cover?width=60", nil)
+	r.Header.Set("Accept", "image/webp,image/png")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept")
+	img, err := png.Decode(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 60, 80), img.Bounds())
+
+	r = httptest.NewRequest("GET", base+"/~readium/cover?width=60", nil)
+	r.Header.Set("Accept", "image/webp")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", base+"/~readium/cover?width=0", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetCoverErrors(t *testing.T) {
+	router, _ := coverTestServer(t, "")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+encodePath("missing.cbz")+"/~readium/cover", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCoverRouteDoesNotShadowAssets(t *testing.T) {
+	router, base := coverTestServer(t, "")
+
+	// An asset named "cover" is looked up in the publication
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", base+"/cover?height=400", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+func TestOPDSPublicationThumbnail(t *testing.T) {
+	s := NewServer(ServerConfig{BaseDirectory: t.TempDir()})
+	s.Routes()
+	p := s.opdsPublication("https://example.com", catalogEntry{
+		Path:         "comic.cbz",
+		ManifestType: "application/divina+json",
+		Cover:        &manifest.Link{Href: "page1.png", Type: "image/png"},
+	})
+	require.Len(t, p.Images, 2)
+	assert.Equal(t, "https://example.com/"+encodePath("comic.cbz")+"/page1.png", p.Images[0].Href)
+	assert.Equal(t, "https://example.com/"+encodePath("comic.cbz")+"/~readium/cover?height=400&format=jpeg", p.Images[1].Href)
+	assert.Equal(t, "image/jpeg", p.Images[1].Type)
+
+	assert.Empty(t, s.opdsPublication("https://example.com", catalogEntry{Path: "book.epub"}).Images)
+}
@@ -1,6 +1,7 @@
 package serve
 
 import (
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -74,6 +75,27 @@ func conformsToAsMimetype(conformsTo manifest.Profiles) string {
 	return mime
 }
 
+// Scheme and host of the server, as requested by the client.
+func baseURL(req *http.Request) string {
+	scheme := "http://"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		// Note: this is never going to be 100% accurate behind proxies,
+		// but it's better than nothing for a dev server.
+		scheme = "https://"
+	}
+	return scheme + req.Host
+}
+
+// Path of the named route, with the given variables of its template.
+func (s *Server) routePath(name string, pairs ...string) string {
+	u, err := s.router.Get(name).URLPath(pairs...)
+	if err != nil {
+		slog.Error("failed building route path", "route", name, "error", err)
+		return ""
+	}
+	return u.String()
+}
+
 func supportsEncoding(r *http.Request, encoding string) bool {
 	vv := r.Header.Values("Accept-Encoding")
 	for _, v := range vv {
@@ -108,3 +130,14 @@ func convertURLValuesToMap(values url.Values) map[string]string {
 	}
 	return result
 }
+
+// Writer keeping track of whether anything was written to the underlying [http.ResponseWriter].
+type trackingWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
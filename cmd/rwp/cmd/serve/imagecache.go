@@ -0,0 +1,134 @@
+package serve
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default maximum size of the cache of the resized images, in bytes.
+const DefaultImageCacheSize = 1 << 30
+
+// Cache of the resized images in a directory, bounded in size by evicting the least recently used images.
+// The modification time of the files is their last access time, so that the cache survives restarts.
+type imageCache struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64 // Total size of the cached images, -1 until the directory is scanned.
+}
+
+// Names of the images written by the cache, as returned by [imageCacheName]. Any other file of the directory is
+// left untouched.
+var cachedImageNamePattern = regexp.MustCompile(`^[0-9a-f]{32}\.(jpeg|png)$`)
+
+func newImageCache(dir string, maxSize int64) *imageCache {
+	return &imageCache{dir: dir, maxSize: maxSize, size: -1}
+}
+
+// Returns the cached image with the given [name], or nil if it isn't cached.
+func (c *imageCache) get(name string) []byte {
+	path := filepath.Join(c.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data
+}
+
+// Writes the image to the cache directory, through a temporary file so that partial files are never read, then
+// evicts the least recently used images if the cache is full.
+func (c *imageCache) put(name string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, ".image-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path := filepath.Join(c.dir, name)
+	// An overwritten image no longer counts in the size of the cache
+	var replaced int64
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		replaced = info.Size()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if c.size >= 0 {
+		c.size += int64(len(data)) - replaced
+	}
+	if c.size < 0 || c.size > c.maxSize {
+		c.evict()
+	}
+	return nil
+}
+
+type cachedImage struct {
+	path     string
+	size     int64
+	accessed time.Time
+}
+
+// Scans the cache directory and removes the least recently used images, until the cache is 10% below its maximum
+// size to avoid scanning it on every write. Only the images at the top of the directory named by [imageCacheName]
+// are counted and removed, in case the directory is shared with other files. Must be called with the lock held.
+func (c *imageCache) evict() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		slog.Warn("failed scanning image cache", "error", err)
+		return
+	}
+	var images []cachedImage
+	var size int64
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !cachedImageNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		images = append(images, cachedImage{
+			path:     filepath.Join(c.dir, entry.Name()),
+			size:     info.Size(),
+			accessed: info.ModTime(),
+		})
+		size += info.Size()
+	}
+
+	if size > c.maxSize {
+		sort.Slice(images, func(i, j int) bool {
+			return images[i].accessed.Before(images[j].accessed)
+		})
+		target := c.maxSize - c.maxSize/10
+		for _, img := range images {
+			if size <= target {
+				break
+			}
+			if err := os.Remove(img.path); err == nil || os.IsNotExist(err) {
+				size -= img.size
+			}
+		}
+	}
+	c.size = size
+}
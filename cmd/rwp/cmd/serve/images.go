@@ -0,0 +1,309 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/util/imagesize"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// Maximum width and height of the resized images, in pixels.
+const MaxImageDimension = 4096
+
+// Maximum number of pixels of the images decoded for resizing, bounding the memory used by each of them.
+const MaxImagePixels = 50_000_000
+
+// Quality of the images encoded as JPEG, unless requested otherwise.
+const DefaultJPEGQuality = 85
+
+// Image formats the resized images can be encoded to, by their media type.
+var imageFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+}
+
+var (
+	errNotAcceptable = errors.New("the image can only be served as image/jpeg or image/png")
+	errImageTooLarge = errors.Errorf("the image has more than %d pixels", MaxImagePixels)
+)
+
+// Parameters of a resized image.
+type imageQuery struct {
+	Width   int    // Maximum width, 0 if unconstrained.
+	Height  int    // Maximum height, 0 if unconstrained.
+	Format  string // jpeg or png
+	Quality int    // Quality of the JPEG encoding, from 1 to 100.
+}
+
+// Parses the width, height, format and quality query parameters of a request for a resized image. When no format is
+// given, it is negotiated with the Accept header.
+func imageQueryFromRequest(r *http.Request) (imageQuery, error) {
+	q := imageQuery{Quality: DefaultJPEGQuality}
+	params := r.URL.Query()
+	for _, p := range []struct {
+		name  string
+		value *int
+		max   int
+	}{{"width", &q.Width, MaxImageDimension}, {"height", &q.Height, MaxImageDimension}, {"quality", &q.Quality, 100}} {
+		raw := params.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 || v > p.max {
+			return q, fmt.Errorf("%s must be an integer between 1 and %d", p.name, p.max)
+		}
+		*p.value = v
+	}
+
+	switch format := strings.ToLower(params.Get("format")); format {
+	case "jpeg", "jpg":
+		q.Format = "jpeg"
+	case "png":
+		q.Format = "png"
+	case "":
+		q.Format = negotiateImageFormat(r.Header.Get("accept"))
+		if q.Format == "" {
+			return q, errNotAcceptable
+		}
+	default:
+		return q, errors.New("format must be jpeg or png")
+	}
+	return q, nil
+}
+
+// Returns the image format preferred by the Accept header, JPEG if both are equally acceptable, or an empty string
+// if none is acceptable.
+func negotiateImageFormat(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return "jpeg"
+	}
+	quality := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		switch mt {
+		case "*/*", "image/*":
+			for ct := range imageFormats {
+				if _, ok := quality[ct]; !ok {
+					quality[ct] = q
+				}
+			}
+		case "image/jpeg", "image/png":
+			quality[mt] = q
+		}
+	}
+
+	best, bestQuality := "", 0.0
+	for _, ct := range []string{"image/jpeg", "image/png"} {
+		if q := quality[ct]; q > bestQuality {
+			best, bestQuality = imageFormats[ct], q
+		}
+	}
+	return best
+}
+
+// Returns the dimensions of an image of [width] x [height] pixels fitting within the dimensions of the [query],
+// keeping its aspect ratio, and whether it must be downscaled. Images are never upscaled.
+func (q imageQuery) fit(width, height int) (int, int, bool) {
+	if width <= 0 || height <= 0 {
+		return width, height, false
+	}
+	scale := 1.0
+	if q.Width > 0 && q.Width < width {
+		scale = float64(q.Width) / float64(width)
+	}
+	if q.Height > 0 && q.Height < height {
+		scale = min(scale, float64(q.Height)/float64(height))
+	}
+	if scale >= 1 {
+		return width, height, false
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5)), true
+}
+
+// Applies the EXIF [orientation] (1 to 8) to the image, which the decoders of the [image] package ignore, so that
+// it is displayed upright.
+func orientImage(src image.Image, orientation uint) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// Pixel of the source image displayed at (x, y)
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Rotated 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Rotated 90° counterclockwise
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// Scales the image to fit within the dimensions of the [query], with a Catmull-Rom resampler.
+func resizeImage(src image.Image, query imageQuery) image.Image {
+	b := src.Bounds()
+	w, h, ok := query.fit(b.Dx(), b.Dy())
+	if !ok {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+func encodeImage(img image.Image, query imageQuery) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch query.Format {
+	case "png":
+		err = (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&buf, img)
+	default:
+		// JPEG has no alpha channel, transparent areas are flattened on a white background
+		b := img.Bounds()
+		flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+		err = jpeg.Encode(&buf, flat, &jpeg.Options{Quality: query.Quality})
+	}
+	return buf.Bytes(), err
+}
+
+// Decodes the image, orients it, resizes it and encodes it according to the [query].
+// Images of more than [MaxImagePixels] are rejected before being decoded.
+func transcodeImage(data []byte, query imageQuery) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding image")
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, errImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed decoding image")
+	}
+	// The dimensions of the links and the resized images are the ones of the image as displayed
+	if orientation, err := imagesize.ProbeOrientation(bytes.NewReader(data)); err == nil {
+		img = orientImage(img, orientation)
+	}
+	data, err = encodeImage(resizeImage(img, query), query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed encoding image")
+	}
+	return data, nil
+}
+
+// Name of the resized image identified by the [key], e.g. the path and entity tag of its source, in the cache
+// directory.
+func imageCacheName(query imageQuery, key ...string) string {
+	h := sha256.New()
+	for _, k := range key {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+	}
+	fmt.Fprintf(h, "%dx%d@%d", query.Width, query.Height, query.Quality)
+	return hex.EncodeToString(h.Sum(nil)[:16]) + "." + query.Format
+}
+
+// Waits for one of the [ServerConfig.ImageConcurrency] slots for resizing an image, and returns the function
+// releasing it.
+func (s *Server) acquireImageSlot(ctx context.Context) (func(), error) {
+	select {
+	case s.imageSlots <- struct{}{}:
+		return func() { <-s.imageSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Writes a resized image, from the cache directory or rendered by [render], which returns the HTTP status of its
+// failure if any. The [cacheName] identifies the image and is used for its entity tag.
+func (s *Server) writeImage(w http.ResponseWriter, r *http.Request, v validators, cacheName string, render func() ([]byte, int, error)) {
+	format := strings.TrimPrefix(filepath.Ext(cacheName), ".")
+	v.ETag = strings.TrimSuffix(cacheName, filepath.Ext(cacheName)) + "-" + format
+	v.setHeaders(w, "")
+	if v.notModified(r) {
+		writeNotModified(w)
+		return
+	}
+
+	var data []byte
+	if s.images != nil {
+		data = s.images.get(cacheName)
+	}
+	if data == nil {
+		release, err := s.acquireImageSlot(r.Context())
+		if err != nil {
+			// The client is gone
+			return
+		}
+		var status int
+		data, status, err = render()
+		release()
+		if err != nil {
+			slog.Debug("failed rendering image", "url", r.URL.String(), "error", err)
+			w.Header().Del("etag")
+			w.Header().Del("last-modified")
+			w.WriteHeader(status)
+			return
+		}
+		if s.images != nil {
+			if err := s.images.put(cacheName, data); err != nil {
+				slog.Warn("failed caching image", "error", err)
+			}
+		}
+	}
+
+	w.Header().Set("content-type", "image/"+format)
+	w.Header().Set("content-length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
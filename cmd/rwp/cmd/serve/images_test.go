@@ -0,0 +1,222 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageQueryFit(t *testing.T) {
+	tests := []struct {
+		query         imageQuery
+		width, height int
+		fitW, fitH    int
+		mustBeResized bool
+		name          string
+	}{
+		{imageQuery{Width: 300}, 600, 800, 300, 400, true, "width"},
+		{imageQuery{Height: 200}, 600, 800, 150, 200, true, "height"},
+		{imageQuery{Width: 300, Height: 100}, 600, 800, 75, 100, true, "height is the constraint"},
+		{imageQuery{Width: 100, Height: 800}, 600, 800, 100, 133, true, "width is the constraint"},
+		{imageQuery{Width: 1200}, 600, 800, 600, 800, false, "never upscaled"},
+		{imageQuery{Width: 600, Height: 800}, 600, 800, 600, 800, false, "same size"},
+		{imageQuery{}, 600, 800, 600, 800, false, "unconstrained"},
+		{imageQuery{Width: 1}, 1000, 10, 1, 1, true, "at least one pixel"},
+		{imageQuery{Width: 100}, 0, 800, 0, 800, false, "unknown size"},
+	}
+	for _, tt := range tests {
+		w, h, ok := tt.query.fit(tt.width, tt.height)
+		assert.Equal(t, tt.mustBeResized, ok, tt.name)
+		assert.Equal(t, tt.fitW, w, tt.name)
+		assert.Equal(t, tt.fitH, h, tt.name)
+	}
+}
+
+func TestNegotiateImageFormat(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "jpeg",
+		"*/*":                                  "jpeg",
+		"image/*":                              "jpeg",
+		"image/png":                            "png",
+		"image/webp,image/png;q=0.9,*/*;q=0.8": "png",
+		"image/png;q=0.5, image/jpeg;q=0.6":    "jpeg",
+		"image/jpeg;q=0, image/*":              "png",
+		"image/webp, image/avif":               "",
+		"text/html":                            "",
+		"image/png;q=invalid, image/jpeg":      "jpeg",
+	}
+	for accept, format := range tests {
+		assert.Equal(t, format, negotiateImageFormat(accept), accept)
+	}
+}
+
+func TestImageQueryFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/cover?width=300&height=200&format=PNG&quality=50", nil)
+	q, err := imageQueryFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, imageQuery{Width: 300, Height: 200, Format: "png", Quality: 50}, q)
+
+	r = httptest.NewRequest("GET", "/cover", nil)
+	r.Header.Set("Accept", "image/webp")
+	_, err = imageQueryFromRequest(r)
+	assert.ErrorIs(t, err, errNotAcceptable)
+
+	for _, query := range []string{"width=0", "height=5000", "width=abc", "quality=101", "format=gif"} {
+		_, err := imageQueryFromRequest(httptest.NewRequest("GET", "/cover?"+query, nil))
+		assert.Error(t, err, query)
+		assert.NotErrorIs(t, err, errNotAcceptable, query)
+	}
+}
+
+func TestImageCacheName(t *testing.T) {
+	query := imageQuery{Width: 300, Format: "jpeg", Quality: 85}
+	name := imageCacheName(query, "cover", "book.epub", "etag")
+	assert.Regexp(t, `^[0-9a-f]{32}\.jpeg$`, name)
+	assert.Equal(t, name, imageCacheName(query, "cover", "book.epub", "etag"))
+
+	// Every parameter of the image is part of its name
+	for _, other := range []string{
+		imageCacheName(imageQuery{Width: 301, Format: "jpeg", Quality: 85}, "cover", "book.epub", "etag"),
+		imageCacheName(imageQuery{Width: 300, Height: 1, Format: "jpeg", Quality: 85}, "cover", "book.epub", "etag"),
+		imageCacheName(imageQuery{Width: 300, Format: "jpeg", Quality: 84}, "cover", "book.epub", "etag"),
+		imageCacheName(imageQuery{Width: 300, Format: "png", Quality: 85}, "cover", "book.epub", "etag"),
+		imageCacheName(query, "cover", "book.epub", "other etag"),
+		imageCacheName(query, "page", "book.epub", "etag"),
+		// The keys are delimited
+		imageCacheName(query, "cover", "book.epube", "tag"),
+	} {
+		assert.NotEqual(t, name, other)
+	}
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestTranscodeImage(t *testing.T) {
+	data, err := transcodeImage(encodeTestPNG(t, 600, 800), imageQuery{Width: 300, Format: "jpeg", Quality: 85})
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 400), img.Bounds())
+
+	_, err = transcodeImage([]byte("not an image"), imageQuery{Width: 300, Format: "jpeg"})
+	assert.Error(t, err)
+}
+
+func TestTranscodeImageAppliesEXIFOrientation(t *testing.T) {
+	// 60x20 image, white on its left half and black on its right half
+	src := image.NewGray(image.Rect(0, 0, 60, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 30; x++ {
+			src.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, src, &jpeg.Options{Quality: 100}))
+
+	// APP1 segment with an orientation of 6: rotated 90° clockwise for display
+	exif := []byte("Exif\x00\x00MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	app := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(exif)+2))
+	data := bytes.Join([][]byte{buf.Bytes()[:2], app, exif, buf.Bytes()[2:]}, nil)
+
+	data, err := transcodeImage(data, imageQuery{Width: 10, Format: "png"})
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 30), img.Bounds())
+	// The left half of the image is displayed at the top
+	top, _, _, _ := img.At(5, 2).RGBA()
+	bottom, _, _, _ := img.At(5, 27).RGBA()
+	assert.Greater(t, top, uint32(0xF000))
+	assert.Less(t, bottom, uint32(0x1000))
+}
+
+func TestTranscodeImageRejectsImagesOverPixelBudget(t *testing.T) {
+	// Only the header is read, the pixels are never decoded
+	data := encodeTestPNG(t, 1, 1)
+	header := append([]byte{}, data[:33]...)       // Signature and IHDR chunk
+	binary.BigEndian.PutUint32(header[16:], 10000) // Width
+	binary.BigEndian.PutUint32(header[20:], 10000) // Height
+	binary.BigEndian.PutUint32(header[29:], crc32.ChecksumIEEE(header[12:29]))
+	_, err := transcodeImage(header, imageQuery{Width: 300, Format: "jpeg"})
+	assert.ErrorIs(t, err, errImageTooLarge)
+}
+
+// Name of a cached image, as written by [imageCacheName].
+func cachedTestImage(id string) string {
+	return strings.Repeat(id, 32) + ".jpeg"
+}
+
+func TestImageCacheEvictsLeastRecentlyUsedImages(t *testing.T) {
+	dir := t.TempDir()
+	c := newImageCache(dir, 1000)
+	data := make([]byte, 300)
+
+	require.NoError(t, c.put(cachedTestImage("a"), data))
+	require.NoError(t, c.put(cachedTestImage("b"), data))
+	require.NoError(t, c.put(cachedTestImage("c"), data))
+	// The access times are spread, as the resolution of the file times might be coarse
+	for i, name := range []string{cachedTestImage("b"), cachedTestImage("a"), cachedTestImage("c")} {
+		at := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), at, at))
+	}
+	assert.NotNil(t, c.get(cachedTestImage("b")))
+
+	// Over the maximum size, the least recently used images are evicted down to 90% of it
+	require.NoError(t, c.put(cachedTestImage("d"), data))
+	assert.Nil(t, c.get(cachedTestImage("a")))
+	assert.NotNil(t, c.get(cachedTestImage("b")))
+	assert.NotNil(t, c.get(cachedTestImage("c")))
+	assert.NotNil(t, c.get(cachedTestImage("d")))
+	assert.Equal(t, int64(900), c.size)
+
+	require.NoError(t, c.put(cachedTestImage("e"), data[:50]))
+	assert.Equal(t, int64(950), c.size)
+	assert.Nil(t, c.get(cachedTestImage("f")))
+}
+
+func TestImageCacheOnlyEvictsItsOwnImages(t *testing.T) {
+	dir := t.TempDir()
+	foreign := []string{"notes.txt", "a.jpeg", strings.Repeat("a", 32) + ".gif", filepath.Join("sub", cachedTestImage("b"))}
+	for _, name := range foreign {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), make([]byte, 1000), 0o644))
+	}
+
+	c := newImageCache(dir, 1000)
+	data := make([]byte, 600)
+	require.NoError(t, c.put(cachedTestImage("c"), data))
+	assert.Equal(t, int64(600), c.size)
+	require.NoError(t, c.put(cachedTestImage("d"), data))
+
+	// Only the cached images are evicted, the other files don't count in the size of the cache
+	for _, name := range foreign {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+	assert.Equal(t, int64(600), c.size)
+}
+
+func TestImageCacheSizeOnOverwrite(t *testing.T) {
+	c := newImageCache(t.TempDir(), 1000)
+	require.NoError(t, c.put(cachedTestImage("a"), make([]byte, 300)))
+	require.NoError(t, c.put(cachedTestImage("a"), make([]byte, 400)))
+	assert.Equal(t, int64(400), c.size)
+	require.NoError(t, c.put(cachedTestImage("a"), make([]byte, 100)))
+	assert.Equal(t, int64(100), c.size)
+}
@@ -0,0 +1,235 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve/cache"
+)
+
+// Upper bounds of the buckets of the request duration histograms, in seconds.
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Operational metrics of the server, exposed in the Prometheus text format.
+type metrics struct {
+	mu           sync.Mutex
+	requests     map[requestLabels]uint64
+	durations    map[string]*histogram // By route
+	bytes        map[string]uint64     // By route
+	streams      map[string]uint64     // Assets streamed, by content encoding
+	openFailures map[string]uint64     // Publications which failed to open, by media type
+}
+
+type requestLabels struct {
+	Route string
+	Code  int
+}
+
+type histogram struct {
+	Counts []uint64 // Number of observations in each bucket, not cumulative.
+	Sum    float64
+	Count  uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:     map[requestLabels]uint64{},
+		durations:    map[string]*histogram{},
+		bytes:        map[string]uint64{},
+		streams:      map[string]uint64{},
+		openFailures: map[string]uint64{},
+	}
+}
+
+func (m *metrics) observeRequest(route string, code int, written int64, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{Route: route, Code: code}]++
+	m.bytes[route] += uint64(written)
+
+	h, ok := m.durations[route]
+	if !ok {
+		h = &histogram{Counts: make([]uint64, len(requestDurationBuckets))}
+		m.durations[route] = h
+	}
+	seconds := d.Seconds()
+	for i, bound := range requestDurationBuckets {
+		if seconds <= bound {
+			h.Counts[i]++
+			break
+		}
+	}
+	h.Sum += seconds
+	h.Count++
+}
+
+// Counts an asset streamed with the given content [encoding], empty when the asset is streamed uncompressed.
+func (m *metrics) observeStream(encoding string) {
+	if encoding == "" {
+		encoding = "identity"
+	}
+	m.mu.Lock()
+	m.streams[encoding]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) observeOpenFailure(mediaType string) {
+	m.mu.Lock()
+	m.openFailures[mediaType]++
+	m.mu.Unlock()
+}
+
+// Response writer recording the status code and the number of bytes written.
+type instrumentedWriter struct {
+	http.ResponseWriter
+	code    int
+	written int64
+}
+
+func (w *instrumentedWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *instrumentedWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *instrumentedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Middleware recording the count, duration and size of the responses, by route name.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "other"
+		if cr := mux.CurrentRoute(r); cr != nil && cr.GetName() != "" {
+			route = cr.GetName()
+		}
+
+		start := time.Now()
+		iw := &instrumentedWriter{ResponseWriter: w}
+		next.ServeHTTP(iw, r)
+		if iw.code == 0 {
+			iw.code = http.StatusOK
+		}
+		s.metrics.observeRequest(route, iw.code, iw.written, time.Since(start))
+	})
+}
+
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("cache-control", "no-store")
+	if err := s.metrics.write(w, s.lfu.Stats()); err != nil {
+		slog.Error("failed writing metrics", "error", err)
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Writes the metrics in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer, cacheStats cache.Stats) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	family := func(name, typ, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	sample := func(name string, labels []string, value string) {
+		b.WriteString(name)
+		if len(labels) > 0 {
+			b.WriteByte('{')
+			for i := 0; i < len(labels); i += 2 {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, `%s="%s"`, labels[i], labelValueEscaper.Replace(labels[i+1]))
+			}
+			b.WriteByte('}')
+		}
+		b.WriteByte(' ')
+		b.WriteString(value)
+		b.WriteByte('\n')
+	}
+	count := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	number := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	family("rwp_http_requests_total", "counter", "Number of HTTP requests, by route and status code.")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].Route != requests[j].Route {
+			return requests[i].Route < requests[j].Route
+		}
+		return requests[i].Code < requests[j].Code
+	})
+	for _, l := range requests {
+		sample("rwp_http_requests_total", []string{"route", l.Route, "code", strconv.Itoa(l.Code)}, count(m.requests[l]))
+	}
+
+	family("rwp_http_request_duration_seconds", "histogram", "Duration of the HTTP requests, by route.")
+	for _, route := range sortedKeys(m.durations) {
+		h := m.durations[route]
+		var cumulative uint64
+		for i, bound := range requestDurationBuckets {
+			cumulative += h.Counts[i]
+			sample("rwp_http_request_duration_seconds_bucket", []string{"route", route, "le", number(bound)}, count(cumulative))
+		}
+		sample("rwp_http_request_duration_seconds_bucket", []string{"route", route, "le", "+Inf"}, count(h.Count))
+		sample("rwp_http_request_duration_seconds_sum", []string{"route", route}, number(h.Sum))
+		sample("rwp_http_request_duration_seconds_count", []string{"route", route}, count(h.Count))
+	}
+
+	family("rwp_http_response_bytes_total", "counter", "Number of bytes sent in the bodies of the HTTP responses, by route.")
+	for _, route := range sortedKeys(m.bytes) {
+		sample("rwp_http_response_bytes_total", []string{"route", route}, count(m.bytes[route]))
+	}
+
+	family("rwp_asset_streams_total", "counter", "Number of assets streamed, by content encoding. Deflate and gzip are streamed from the compressed bytes of the archive.")
+	for _, encoding := range sortedKeys(m.streams) {
+		sample("rwp_asset_streams_total", []string{"encoding", encoding}, count(m.streams[encoding]))
+	}
+
+	family("rwp_publication_cache_hits_total", "counter", "Number of requests for a publication already open.")
+	sample("rwp_publication_cache_hits_total", nil, count(cacheStats.Hits))
+	family("rwp_publication_cache_misses_total", "counter", "Number of requests for a publication which had to be opened.")
+	sample("rwp_publication_cache_misses_total", nil, count(cacheStats.Misses))
+	family("rwp_publication_cache_evictions_total", "counter", "Number of publications closed because of the cache capacity, their TTL or a change on disk.")
+	sample("rwp_publication_cache_evictions_total", nil, count(cacheStats.Evictions))
+
+	family("rwp_publication_open_failures_total", "counter", "Number of publications which failed to open, by media type.")
+	for _, mt := range sortedKeys(m.openFailures) {
+		sample("rwp_publication_open_failures_total", []string{"mediatype", mt}, count(m.openFailures[mt]))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
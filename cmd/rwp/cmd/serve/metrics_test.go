@@ -0,0 +1,62 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	m := newMetrics()
+	// Durations which are exact in binary, so that their sum is too
+	m.observeRequest("manifest", 200, 1000, 250*time.Millisecond)
+	m.observeRequest("manifest", 200, 500, 62500*time.Microsecond)
+	m.observeRequest("manifest", 404, 0, 500*time.Millisecond)
+	m.observeRequest("asset", 206, 4096, 20*time.Second)
+	m.observeRequest("asset", 200, 8192, 125*time.Millisecond)
+	m.observeStream("")
+	m.observeStream("gzip")
+	m.observeStream("deflate")
+	m.observeStream("gzip")
+	m.observeOpenFailure("application/epub+zip")
+	m.observeOpenFailure("text/plain; name=\"a\\b\"\nc")
+
+	var b strings.Builder
+	require.NoError(t, m.write(&b, cache.Stats{Hits: 7, Misses: 3, Evictions: 1}))
+
+	golden, err := os.ReadFile(filepath.Join("testdata", "metrics.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), b.String())
+}
+
+func TestGetPublicationCountsStaleEntriesAsMisses(t *testing.T) {
+	dir := t.TempDir()
+	writeTestComic(t, filepath.Join(dir, "comic.cbz"), []byte("page"))
+	s := NewServer(ServerConfig{BaseDirectory: dir})
+	router := s.Routes()
+	manifestURL := "/" + encodePath("comic.cbz") + "/manifest.json"
+
+	get := func() {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", manifestURL, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	get()
+	get()
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1}, s.lfu.Stats())
+
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "comic.cbz"), later, later))
+	get()
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 2, Evictions: 1}, s.lfu.Stats())
+	get()
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 2, Evictions: 1}, s.lfu.Stats())
+}
@@ -0,0 +1,333 @@
+package serve
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/go-toolkit/pkg/opds"
+)
+
+// Maximum number of publications in a page of the OPDS feed.
+const OPDSItemsPerPage = 50
+
+// Parameters of a page of the OPDS feed.
+type opdsQuery struct {
+	Dir      string // Directory of the listed publications, relative to the base directory.
+	Format   string // Only lists the publications with this file extension.
+	Language string // Only lists the publications in this language.
+	Page     int    // Starts at 1.
+}
+
+func opdsQueryFromRequest(req *http.Request) (opdsQuery, error) {
+	params := req.URL.Query()
+	q := opdsQuery{
+		Format:   params.Get("format"),
+		Language: params.Get("language"),
+		Page:     1,
+	}
+	if dir := params.Get("dir"); dir != "" {
+		dir = path.Clean(strings.Trim(dir, "/"))
+		if dir == ".." || strings.HasPrefix(dir, "../") {
+			return q, errors.New("invalid directory " + dir)
+		}
+		if dir != "." {
+			q.Dir = dir
+		}
+	}
+	if page := params.Get("page"); page != "" {
+		p, err := strconv.Atoi(page)
+		if err != nil || p < 1 {
+			return q, errors.New("invalid page " + page)
+		}
+		q.Page = p
+	}
+	return q, nil
+}
+
+// URL of the page of the feed described by the query, omitting the default parameters.
+func (q opdsQuery) URL(feedURL string) string {
+	params := url.Values{}
+	if q.Dir != "" {
+		params.Set("dir", q.Dir)
+	}
+	if q.Format != "" {
+		params.Set("format", q.Format)
+	}
+	if q.Language != "" {
+		params.Set("language", q.Language)
+	}
+	if q.Page > 1 {
+		params.Set("page", strconv.Itoa(q.Page))
+	}
+	u := feedURL
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+func (q opdsQuery) matches(e catalogEntry) bool {
+	if q.Format != "" && e.Format != q.Format {
+		return false
+	}
+	if q.Language != "" {
+		for _, l := range e.Metadata.Languages {
+			if l == q.Language {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (s *Server) getOPDSFeed(w http.ResponseWriter, req *http.Request) {
+	query, err := opdsQueryFromRequest(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	entries, err := s.catalog.Entries()
+	if err != nil {
+		slog.Error("failed reading publications directory", "error", err)
+		w.WriteHeader(500)
+		return
+	}
+	entries, err = s.authorizedEntries(req, entries)
+	if err != nil {
+		w.Header().Set("www-authenticate", `Bearer realm="rwp"`)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	feed, ok := s.buildOPDSFeed(baseURL(req), query, entries)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("content-type", mediatype.OPDS2.String()+"; charset=utf-8")
+	w.Header().Set("cache-control", "private, must-revalidate")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", s.config.JSONIndent)
+	if err := enc.Encode(feed); err != nil {
+		slog.Error("failed writing OPDS feed", "error", err)
+	}
+}
+
+// Filters the entries the request is authorized to access. Returns [ErrUnauthorized] if the request lacks valid
+// credentials for all of them.
+func (s *Server) authorizedEntries(req *http.Request, entries []catalogEntry) ([]catalogEntry, error) {
+	if len(s.config.Authorizers) == 0 {
+		return entries, nil
+	}
+	authorized := make([]catalogEntry, 0, len(entries))
+	authenticated := false
+	for _, e := range entries {
+		err := s.authorize(req, e.Path)
+		if err == nil {
+			authorized = append(authorized, e)
+		}
+		if !errors.Is(err, ErrUnauthorized) {
+			authenticated = true
+		}
+	}
+	if !authenticated && len(entries) > 0 {
+		return nil, ErrUnauthorized
+	}
+	return authorized, nil
+}
+
+// Builds the page of the feed described by the [query], or returns false if its directory doesn't contain any
+// publication.
+func (s *Server) buildOPDSFeed(base string, query opdsQuery, entries []catalogEntry) (opds.Feed, bool) {
+	itemsPerPage, currentPage := OPDSItemsPerPage, query.Page
+	feed := opds.Feed{
+		Metadata: opds.FeedMetadata{
+			Title:        "Publications",
+			ItemsPerPage: &itemsPerPage,
+			CurrentPage:  &currentPage,
+		},
+	}
+	if query.Dir != "" {
+		feed.Metadata.Title = path.Base(query.Dir)
+	}
+	feedURL := base + s.routePath("opds")
+
+	// Publications of the directory, and number of publications in its subdirectories
+	var inDir []catalogEntry
+	subdirs := map[string]int{}
+	for _, e := range entries {
+		dir := e.Dir()
+		if dir == query.Dir {
+			inDir = append(inDir, e)
+			continue
+		}
+		if query.Dir != "" {
+			if !strings.HasPrefix(dir, query.Dir+"/") {
+				continue
+			}
+			dir = strings.TrimPrefix(dir, query.Dir+"/")
+		} else if dir == "" {
+			continue
+		}
+		sub, _, _ := strings.Cut(dir, "/")
+		subdirs[path.Join(query.Dir, sub)]++
+	}
+	if query.Dir != "" && len(inDir) == 0 && len(subdirs) == 0 {
+		return feed, false
+	}
+
+	feed.Links = []manifest.Link{
+		{Rels: manifest.Strings{"self"}, Href: query.URL(feedURL), Type: mediatype.OPDS2.String()},
+		{Rels: manifest.Strings{"start"}, Href: opdsQuery{}.URL(feedURL), Type: mediatype.OPDS2.String()},
+	}
+	if query.Dir != "" {
+		up := opdsQuery{Dir: path.Dir(query.Dir)}
+		if up.Dir == "." {
+			up.Dir = ""
+		}
+		feed.Links = append(feed.Links, manifest.Link{Rels: manifest.Strings{"up"}, Href: up.URL(feedURL), Type: mediatype.OPDS2.String()})
+	}
+
+	// Navigation by directory
+	dirs := make([]string, 0, len(subdirs))
+	for dir := range subdirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		feed.Navigation = append(feed.Navigation, manifest.Link{
+			Rels:       manifest.Strings{"subsection"},
+			Href:       opdsQuery{Dir: dir}.URL(feedURL),
+			Type:       mediatype.OPDS2.String(),
+			Title:      path.Base(dir),
+			Properties: manifest.Properties{"numberOfItems": subdirs[dir]},
+		})
+	}
+
+	// Facets, counting the publications matching the other active facet
+	formats := map[string]int{}
+	languages := map[string]int{}
+	var formatsTotal, languagesTotal int
+	var filtered []catalogEntry
+	for _, e := range inDir {
+		if (opdsQuery{Language: query.Language}).matches(e) {
+			formats[e.Format]++
+			formatsTotal++
+		}
+		if (opdsQuery{Format: query.Format}).matches(e) {
+			for _, l := range e.Metadata.Languages {
+				languages[l]++
+			}
+			languagesTotal++
+		}
+		if query.matches(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	if len(inDir) > 0 {
+		feed.Facets = []opds.Facet{
+			opdsFacetOf("Format", feedURL, query, formats, formatsTotal,
+				func(q *opdsQuery) *string { return &q.Format }, strings.ToUpper),
+			opdsFacetOf("Language", feedURL, query, languages, languagesTotal,
+				func(q *opdsQuery) *string { return &q.Language }, func(l string) string { return l }),
+		}
+	}
+
+	// Pagination
+	numberOfItems := len(filtered)
+	feed.Metadata.NumberOfItems = &numberOfItems
+	lastPage := max(1, (len(filtered)+OPDSItemsPerPage-1)/OPDSItemsPerPage)
+	if lastPage > 1 {
+		page := func(rel string, number int) manifest.Link {
+			q := query
+			q.Page = number
+			return manifest.Link{Rels: manifest.Strings{rel}, Href: q.URL(feedURL), Type: mediatype.OPDS2.String()}
+		}
+		feed.Links = append(feed.Links, page("first", 1))
+		if query.Page > 1 && query.Page <= lastPage {
+			feed.Links = append(feed.Links, page("previous", query.Page-1))
+		}
+		if query.Page < lastPage {
+			feed.Links = append(feed.Links, page("next", query.Page+1))
+		}
+		feed.Links = append(feed.Links, page("last", lastPage))
+	}
+
+	start := min(len(filtered), (query.Page-1)*OPDSItemsPerPage)
+	end := min(len(filtered), start+OPDSItemsPerPage)
+	for _, e := range filtered[start:end] {
+		feed.Publications = append(feed.Publications, s.opdsPublication(base, e))
+	}
+	return feed, true
+}
+
+// Builds a facet group from the number of publications by [value], out of a [total] of publications.
+// The [param] function returns the query parameter set by the facet, and [label] the title of a value.
+func opdsFacetOf(title string, feedURL string, query opdsQuery, counts map[string]int, total int, param func(q *opdsQuery) *string, label func(string) string) opds.Facet {
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	link := func(value string, title string, count int) manifest.Link {
+		q := query
+		q.Page = 1
+		*param(&q) = value
+		l := manifest.Link{
+			Href:       q.URL(feedURL),
+			Type:       mediatype.OPDS2.String(),
+			Title:      title,
+			Properties: manifest.Properties{"numberOfItems": count},
+		}
+		if *param(&query) == value {
+			l.Rels = manifest.Strings{"self"}
+		}
+		return l
+	}
+
+	facet := opds.Facet{Metadata: opds.FeedMetadata{Title: title}}
+	facet.Links = append(facet.Links, link("", "All", total))
+	for _, v := range values {
+		facet.Links = append(facet.Links, link(v, label(v), counts[v]))
+	}
+	return facet
+}
+
+func (s *Server) opdsPublication(base string, e catalogEntry) opds.Publication {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(e.Path))
+
+	p := opds.Publication{
+		Metadata: e.Metadata,
+		Links: manifest.LinkList{{
+			Rels: manifest.Strings{opds.RelAcquisitionOpenAccess},
+			Href: base + s.routePath("manifest", "path", encoded),
+			Type: e.ManifestType,
+		}},
+	}
+	if e.Cover != nil {
+		cover := *e.Cover
+		cover.Href = base + s.routePath("asset", "path", encoded, "asset", cover.Href)
+		thumbnail := manifest.Link{
+			Href: base + s.routePath("cover", "path", encoded) + "?height=" + strconv.Itoa(OPDSThumbnailHeight) + "&format=jpeg",
+			Type: "image/jpeg",
+		}
+		p.Images = manifest.LinkList{cover, thumbnail}
+	}
+	return p
+}
@@ -0,0 +1,220 @@
+package serve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/opds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes a minimal EPUB with the given [title] and [language].
+func writeTestEPUB(t *testing.T, path string, title string, language string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	writeTestArchive(t, path, map[string][]byte{
+		"mimetype": []byte("application/epub+zip"),
+		"META-INF/container.xml": []byte(`<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`),
+		"content.opf": []byte(`<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">` + title + `</dc:identifier>
+    <dc:title>` + title + `</dc:title>
+    <dc:language>` + language + `</dc:language>
+  </metadata>
+  <manifest><item id="chapter" href="chapter.xhtml" media-type="application/xhtml+xml"/></manifest>
+  <spine><itemref idref="chapter"/></spine>
+</package>`),
+		"chapter.xhtml": []byte(`<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Text</p></body></html>`),
+	})
+}
+
+// Server of a directory of publications:
+//   - alpha.epub (en), beta.epub (fr) and comic.cbz at the top
+//   - OPDSItemsPerPage+1 comics in comics/, and another one in comics/manga/
+//   - deep/nested/gamma.epub (en)
+func opdsTestServer(t *testing.T) (*Server, []catalogEntry) {
+	dir := t.TempDir()
+	page := encodeTestPNG(t, 10, 10)
+	writeTestEPUB(t, filepath.Join(dir, "alpha.epub"), "Alpha", "en")
+	writeTestEPUB(t, filepath.Join(dir, "beta.epub"), "Beta", "fr")
+	writeTestComic(t, filepath.Join(dir, "comic.cbz"), page)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "comics", "manga"), 0o755))
+	for i := 0; i <= OPDSItemsPerPage; i++ {
+		writeTestComic(t, filepath.Join(dir, "comics", fmt.Sprintf("comic-%02d.cbz", i)), page)
+	}
+	writeTestComic(t, filepath.Join(dir, "comics", "manga", "manga.cbz"), page)
+	writeTestEPUB(t, filepath.Join(dir, "deep", "nested", "gamma.epub"), "Gamma", "en")
+
+	s := NewServer(ServerConfig{BaseDirectory: dir})
+	s.Routes()
+	entries, err := s.catalog.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, OPDSItemsPerPage+6)
+	return s, entries
+}
+
+// Returns the hrefs of the [links] by relation.
+func linksByRel(links []manifest.Link) map[string]string {
+	hrefs := map[string]string{}
+	for _, l := range links {
+		for _, rel := range l.Rels {
+			hrefs[rel] = l.Href
+		}
+	}
+	return hrefs
+}
+
+// Returns the number of items of the links of the facet, by title.
+func facetCounts(facet opds.Facet) map[string]int {
+	counts := map[string]int{}
+	for _, l := range facet.Links {
+		counts[l.Title] = l.Properties["numberOfItems"].(int)
+	}
+	return counts
+}
+
+// Returns the title of the selected link of the facet.
+func facetSelection(facet opds.Facet) string {
+	for _, l := range facet.Links {
+		if len(l.Rels) > 0 && l.Rels[0] == "self" {
+			return l.Title
+		}
+	}
+	return ""
+}
+
+func publicationTitles(feed opds.Feed) []string {
+	var titles []string
+	for _, p := range feed.Publications {
+		titles = append(titles, p.Metadata.Title())
+	}
+	return titles
+}
+
+const opdsTestFeedURL = "https://example.com/opds.json"
+
+func TestOPDSFeedNavigation(t *testing.T) {
+	s, entries := opdsTestServer(t)
+
+	feed, ok := s.buildOPDSFeed("https://example.com", opdsQuery{Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, "Publications", feed.Metadata.Title)
+	assert.Equal(t, map[string]string{
+		"self":  opdsTestFeedURL,
+		"start": opdsTestFeedURL,
+	}, linksByRel(feed.Links))
+	require.Len(t, feed.Navigation, 2)
+	assert.Equal(t, "comics", feed.Navigation[0].Title)
+	assert.Equal(t, opdsTestFeedURL+"?dir=comics", feed.Navigation[0].Href)
+	assert.Equal(t, OPDSItemsPerPage+2, feed.Navigation[0].Properties["numberOfItems"])
+	assert.Equal(t, "deep", feed.Navigation[1].Title)
+	assert.Equal(t, 1, feed.Navigation[1].Properties["numberOfItems"])
+	assert.Len(t, feed.Publications, 3)
+
+	// Subdirectory with only a subdirectory
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "deep", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, "deep", feed.Metadata.Title)
+	assert.Equal(t, opdsTestFeedURL, linksByRel(feed.Links)["up"])
+	require.Len(t, feed.Navigation, 1)
+	assert.Equal(t, opdsTestFeedURL+"?dir=deep%2Fnested", feed.Navigation[0].Href)
+	assert.Empty(t, feed.Publications)
+	assert.Empty(t, feed.Facets)
+
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "deep/nested", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, opdsTestFeedURL+"?dir=deep", linksByRel(feed.Links)["up"])
+	assert.Empty(t, feed.Navigation)
+	assert.Equal(t, []string{"Gamma"}, publicationTitles(feed))
+
+	_, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "missing", Page: 1}, entries)
+	assert.False(t, ok)
+}
+
+func TestOPDSFeedFacets(t *testing.T) {
+	s, entries := opdsTestServer(t)
+
+	feed, ok := s.buildOPDSFeed("https://example.com", opdsQuery{Page: 1}, entries)
+	require.True(t, ok)
+	require.Len(t, feed.Facets, 2)
+	assert.Equal(t, map[string]int{"All": 3, "CBZ": 1, "EPUB": 2}, facetCounts(feed.Facets[0]))
+	assert.Equal(t, "All", facetSelection(feed.Facets[0]))
+	assert.Equal(t, map[string]int{"All": 3, "en": 1, "fr": 1}, facetCounts(feed.Facets[1]))
+	assert.Equal(t, "All", facetSelection(feed.Facets[1]))
+
+	// Each facet counts the publications matching the other one
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Format: "epub", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Alpha", "Beta"}, publicationTitles(feed))
+	assert.Equal(t, "EPUB", facetSelection(feed.Facets[0]))
+	assert.Equal(t, map[string]int{"All": 3, "CBZ": 1, "EPUB": 2}, facetCounts(feed.Facets[0]))
+	assert.Equal(t, map[string]int{"All": 2, "en": 1, "fr": 1}, facetCounts(feed.Facets[1]))
+	assert.Equal(t, opdsTestFeedURL+"?format=epub&language=fr", feed.Facets[1].Links[2].Href)
+
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Language: "fr", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Beta"}, publicationTitles(feed))
+	assert.Equal(t, map[string]int{"All": 1, "EPUB": 1}, facetCounts(feed.Facets[0]))
+	assert.Equal(t, "fr", facetSelection(feed.Facets[1]))
+	assert.Equal(t, 1, *feed.Metadata.NumberOfItems)
+
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Format: "cbz", Language: "fr", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Empty(t, feed.Publications)
+	assert.Equal(t, 0, *feed.Metadata.NumberOfItems)
+}
+
+func TestOPDSFeedPagination(t *testing.T) {
+	s, entries := opdsTestServer(t)
+
+	feed, ok := s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "comics", Page: 1}, entries)
+	require.True(t, ok)
+	assert.Equal(t, OPDSItemsPerPage+1, *feed.Metadata.NumberOfItems)
+	assert.Equal(t, OPDSItemsPerPage, *feed.Metadata.ItemsPerPage)
+	assert.Equal(t, 1, *feed.Metadata.CurrentPage)
+	assert.Len(t, feed.Publications, OPDSItemsPerPage)
+	assert.Equal(t, map[string]string{
+		"self":  opdsTestFeedURL + "?dir=comics",
+		"start": opdsTestFeedURL,
+		"up":    opdsTestFeedURL,
+		"first": opdsTestFeedURL + "?dir=comics",
+		"next":  opdsTestFeedURL + "?dir=comics&page=2",
+		"last":  opdsTestFeedURL + "?dir=comics&page=2",
+	}, linksByRel(feed.Links))
+
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "comics", Page: 2}, entries)
+	require.True(t, ok)
+	assert.Equal(t, 2, *feed.Metadata.CurrentPage)
+	assert.Len(t, feed.Publications, 1)
+	assert.Equal(t, map[string]string{
+		"self":     opdsTestFeedURL + "?dir=comics&page=2",
+		"start":    opdsTestFeedURL,
+		"up":       opdsTestFeedURL,
+		"first":    opdsTestFeedURL + "?dir=comics",
+		"previous": opdsTestFeedURL + "?dir=comics",
+		"last":     opdsTestFeedURL + "?dir=comics&page=2",
+	}, linksByRel(feed.Links))
+
+	// A page out of range is empty, and only links to the first and last pages
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Dir: "comics", Page: 5}, entries)
+	require.True(t, ok)
+	assert.Empty(t, feed.Publications)
+	links := linksByRel(feed.Links)
+	assert.Equal(t, opdsTestFeedURL+"?dir=comics", links["first"])
+	assert.Equal(t, opdsTestFeedURL+"?dir=comics&page=2", links["last"])
+	assert.NotContains(t, links, "previous")
+	assert.NotContains(t, links, "next")
+
+	// A single page has no pagination links
+	feed, ok = s.buildOPDSFeed("https://example.com", opdsQuery{Page: 1}, entries)
+	require.True(t, ok)
+	assert.NotContains(t, linksByRel(feed.Links), "first")
+	assert.NotContains(t, linksByRel(feed.Links), "last")
+}
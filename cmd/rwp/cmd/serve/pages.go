@@ -0,0 +1,111 @@
+package serve
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/readium/go-toolkit/pkg/util/imagesize"
+)
+
+// Client hints used to fit the pages of visual publications to the screen, when no dimensions are requested.
+var pageClientHints = []string{"Sec-CH-Viewport-Width", "Sec-CH-DPR"}
+
+// Reports whether the request asks for a downscaled page, with the query parameters of [imageQueryFromRequest] or
+// the viewport client hints.
+func wantsResizedPage(r *http.Request) bool {
+	params := r.URL.Query()
+	for _, p := range []string{"width", "height", "format", "quality"} {
+		if params.Has(p) {
+			return true
+		}
+	}
+	return r.Header.Get("sec-ch-viewport-width") != ""
+}
+
+// Parses the parameters of a downscaled page. Without dimensions in the query, the page fits the width of the
+// viewport given in the client hints, in physical pixels.
+func pageQueryFromRequest(r *http.Request) (imageQuery, error) {
+	query, err := imageQueryFromRequest(r)
+	if err != nil || query.Width > 0 || query.Height > 0 {
+		return query, err
+	}
+	if vw, err := strconv.ParseFloat(r.Header.Get("sec-ch-viewport-width"), 64); err == nil && vw > 0 {
+		dpr := 1.0
+		if v, err := strconv.ParseFloat(r.Header.Get("sec-ch-dpr"), 64); err == nil && v > 0 {
+			dpr = v
+		}
+		query.Width = min(MaxImageDimension, int(math.Ceil(vw*dpr)))
+	}
+	return query, nil
+}
+
+// Serves a downscaled version of a bitmap page of a Divina publication (e.g. a CBZ), when requested.
+// Returns false if the asset must be served as-is, e.g. when it's already smaller than the requested dimensions.
+func (s *Server) serveResizedPage(w http.ResponseWriter, r *http.Request, publicationPath string, publication *pub.Publication, link manifest.Link) bool {
+	if !publication.ConformsTo(manifest.ProfileDivina) || !link.MediaType().IsBitmap() {
+		return false
+	}
+	hints := strings.Join(pageClientHints, ", ")
+	w.Header().Set("accept-ch", hints)
+	w.Header().Add("vary", hints)
+	if !wantsResizedPage(r) {
+		return false
+	}
+
+	query, err := pageQueryFromRequest(r)
+	if err != nil {
+		if errors.Is(err, errNotAcceptable) {
+			// The original page is served, as its format might be acceptable
+			return false
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return true
+	}
+
+	res := publication.Get(link)
+	defer res.Close()
+
+	// Pages which don't need to be downscaled are served as-is, recompressing them would likely make them larger
+	width, height := int(link.Width), int(link.Height)
+	if width == 0 || height == 0 {
+		size, err := imagesize.ProbeResource(res)
+		if err != nil {
+			return false
+		}
+		width, height = int(size.Width), int(size.Height)
+	}
+	if _, _, ok := query.fit(width, height); !ok {
+		return false
+	}
+	if int64(width)*int64(height) > MaxImagePixels {
+		// Too large to be decoded safely
+		return false
+	}
+
+	v := validatorsOf(res)
+	if v.ETag == "" {
+		return false
+	}
+	if r.URL.Query().Get("format") == "" {
+		w.Header().Add("vary", "Accept")
+	}
+	w.Header().Set("cache-control", "private, max-age=86400, immutable")
+	s.writeImage(w, r, v, imageCacheName(query, "page", publicationPath, link.Href, v.ETag), func() ([]byte, int, error) {
+		b, rerr := res.Read(0, 0)
+		if rerr != nil {
+			return nil, rerr.HTTPStatus(), rerr
+		}
+		data, err := transcodeImage(b, query)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return data, http.StatusOK, nil
+	})
+	return true
+}
@@ -0,0 +1,56 @@
+package serve
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageQueryFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		headers map[string]string
+		width   int
+		height  int
+	}{
+		{"no parameters", "", nil, 0, 0},
+		{"query dimensions", "?width=800&height=600", map[string]string{"Sec-CH-Viewport-Width": "400"}, 800, 600},
+		{"viewport width", "", map[string]string{"Sec-CH-Viewport-Width": "400"}, 400, 0},
+		{"viewport width and pixel ratio", "", map[string]string{"Sec-CH-Viewport-Width": "412", "Sec-CH-DPR": "2.625"}, 1082, 0},
+		{"viewport width limited", "", map[string]string{"Sec-CH-Viewport-Width": "2000", "Sec-CH-DPR": "3"}, MaxImageDimension, 0},
+		{"invalid pixel ratio", "", map[string]string{"Sec-CH-Viewport-Width": "400", "Sec-CH-DPR": "-1"}, 400, 0},
+		{"invalid viewport width", "", map[string]string{"Sec-CH-Viewport-Width": "wide", "Sec-CH-DPR": "2"}, 0, 0},
+		{"height only", "?height=600", map[string]string{"Sec-CH-Viewport-Width": "400"}, 0, 600},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/page.jpg"+tt.query, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			q, err := pageQueryFromRequest(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.width, q.Width)
+			assert.Equal(t, tt.height, q.Height)
+			assert.Equal(t, "jpeg", q.Format)
+			assert.Equal(t, DefaultJPEGQuality, q.Quality)
+		})
+	}
+
+	_, err := pageQueryFromRequest(httptest.NewRequest("GET", "/page.jpg?width=-1", nil))
+	assert.Error(t, err)
+}
+
+func TestWantsResizedPage(t *testing.T) {
+	assert.False(t, wantsResizedPage(httptest.NewRequest("GET", "/page.jpg", nil)))
+	assert.False(t, wantsResizedPage(httptest.NewRequest("GET", "/page.jpg?page=2", nil)))
+	for _, query := range []string{"width=1", "height=1", "format=png", "quality=50"} {
+		assert.True(t, wantsResizedPage(httptest.NewRequest("GET", "/page.jpg?"+query, nil)), query)
+	}
+	r := httptest.NewRequest("GET", "/page.jpg", nil)
+	r.Header.Set("Sec-CH-Viewport-Width", "400")
+	assert.True(t, wantsResizedPage(r))
+}
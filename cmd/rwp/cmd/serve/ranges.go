@@ -0,0 +1,127 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+
+	httprange "github.com/gotd/contrib/http_range"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+)
+
+// Maximum number of ranges served in a single response, after coalescing the overlapping ones. Requests with more
+// ranges are answered with the whole resource.
+const MaxRanges = 50
+
+// Parses the ranges of a Range header for a resource of the given size, merging the ranges that overlap or are
+// adjacent. Returns nil if the header is empty, if the ranges cover the whole resource or if there are more than
+// [MaxRanges] of them, as a server may ignore the Range header (RFC 9110 §14.2). Returns an error if the ranges
+// are invalid or unsatisfiable.
+func parseRanges(header string, size int64) ([]httprange.Range, error) {
+	ranges, err := httprange.ParseRange(header, size)
+	if err != nil {
+		return nil, err
+	}
+	ranges = coalesceRanges(ranges)
+	if len(ranges) > MaxRanges {
+		return nil, nil
+	}
+	if len(ranges) == 1 && ranges[0].Start == 0 && ranges[0].Length >= size {
+		return nil, nil
+	}
+	return ranges, nil
+}
+
+// Sorts the ranges and merges the ones that overlap or are adjacent.
+func coalesceRanges(ranges []httprange.Range) []httprange.Range {
+	if len(ranges) < 2 {
+		return ranges
+	}
+	sorted := make([]httprange.Range, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.Start+last.Length {
+			last.Length = max(last.Length, r.Start+r.Length-last.Start)
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// Streams the bytes of a single range of the resource.
+func streamRange(w io.Writer, res fetcher.Resource, r httprange.Range) (int64, *fetcher.ResourceError) {
+	if r.Start == 0 && r.Length == 1 {
+		// Stream(0, 0) would return the whole resource.
+		b, err := res.Read(0, 1)
+		if err != nil {
+			return -1, err
+		}
+		n, werr := w.Write(b[:min(1, len(b))])
+		if werr != nil {
+			return int64(n), fetcher.Other(werr)
+		}
+		return int64(n), nil
+	}
+	return res.Stream(w, r.Start, r.Start+r.Length-1)
+}
+
+func rangePartHeader(r httprange.Range, contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.ContentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// Length of the multipart/byteranges body for the ranges, used for the Content-Length header.
+func multipartRangesLength(ranges []httprange.Range, boundary string, contentType string, size int64) int64 {
+	var w countingWriter
+	mw := multipart.NewWriter(&w)
+	mw.SetBoundary(boundary)
+	for _, r := range ranges {
+		mw.CreatePart(rangePartHeader(r, contentType, size))
+		w += countingWriter(r.Length)
+	}
+	mw.Close()
+	return int64(w)
+}
+
+// Streams the ranges of the resource as a multipart/byteranges body delimited by [boundary].
+func streamMultipartRanges(w io.Writer, res fetcher.Resource, ranges []httprange.Range, boundary string, contentType string, size int64) *fetcher.ResourceError {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return fetcher.Other(err)
+	}
+	for _, r := range ranges {
+		part, err := mw.CreatePart(rangePartHeader(r, contentType, size))
+		if err != nil {
+			return fetcher.Other(err)
+		}
+		if _, rerr := streamRange(part, res, r); rerr != nil {
+			return rerr
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return fetcher.Other(err)
+	}
+	return nil
+}
+
+func multipartRangesContentType(boundary string) string {
+	return fmt.Sprintf("multipart/byteranges; boundary=%s", boundary)
+}
@@ -0,0 +1,172 @@
+package serve
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	httprange "github.com/gotd/contrib/http_range"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		ranges []httprange.Range
+		err    bool
+	}{
+		{"no header", "", nil, false},
+		{"single range", "bytes=0-99", []httprange.Range{{Start: 0, Length: 100}}, false},
+		{"suffix range", "bytes=-100", []httprange.Range{{Start: 900, Length: 100}}, false},
+		{"open-ended range", "bytes=100-", []httprange.Range{{Start: 100, Length: 900}}, false},
+		{"end past the size", "bytes=900-2000", []httprange.Range{{Start: 900, Length: 100}}, false},
+		{"whole resource", "bytes=0-", nil, false},
+		{"whole resource in several ranges", "bytes=0-499,500-", nil, false},
+		{"disjoint ranges", "bytes=500-599,0-99", []httprange.Range{{Start: 0, Length: 100}, {Start: 500, Length: 100}}, false},
+		{"overlapping ranges", "bytes=0-99,50-149,140-199", []httprange.Range{{Start: 0, Length: 200}}, false},
+		{"adjacent ranges", "bytes=0-99,100-199", []httprange.Range{{Start: 0, Length: 200}}, false},
+		{"contained range", "bytes=0-199,50-99", []httprange.Range{{Start: 0, Length: 200}}, false},
+		{"unsatisfiable range", "bytes=2000-3000", nil, true},
+		{"invalid header", "lines=0-1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := parseRanges(tt.header, 1000)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.ranges, ranges)
+			}
+		})
+	}
+}
+
+// Range header of [n] disjoint ranges.
+func disjointRanges(n int) string {
+	specs := make([]string, n)
+	for i := range specs {
+		specs[i] = fmt.Sprintf("%d-%d", i*10, i*10+1)
+	}
+	return "bytes=" + strings.Join(specs, ",")
+}
+
+func TestParseRangesLimitsTheNumberOfRanges(t *testing.T) {
+	ranges, err := parseRanges(disjointRanges(MaxRanges), 1000)
+	assert.NoError(t, err)
+	assert.Len(t, ranges, MaxRanges)
+
+	// The Range header is ignored
+	ranges, err = parseRanges(disjointRanges(MaxRanges+1), 1000)
+	assert.NoError(t, err)
+	assert.Nil(t, ranges)
+
+	// Coalesced ranges count once
+	ranges, err = parseRanges("bytes="+strings.Repeat("0-1,", MaxRanges)+"0-1", 1000)
+	assert.NoError(t, err)
+	assert.Len(t, ranges, 1)
+}
+
+func rangeTestServer(t *testing.T) (http.Handler, string, []byte) {
+	dir := t.TempDir()
+	page := make([]byte, 1000)
+	for i := range page {
+		page[i] = byte(i)
+	}
+	writeTestComic(t, filepath.Join(dir, "comic.cbz"), page)
+	return NewServer(ServerConfig{BaseDirectory: dir}).Routes(), "/" + encodePath("comic.cbz") + "/page.jpg", page
+}
+
+func TestGetAssetRange(t *testing.T) {
+	router, assetURL, page := rangeTestServer(t)
+
+	r := httptest.NewRequest("GET", assetURL, nil)
+	r.Header.Set("Range", "bytes=-10")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 990-999/1000", w.Header().Get("Content-Range"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, page[990:], w.Body.Bytes())
+
+	r = httptest.NewRequest("GET", assetURL, nil)
+	r.Header.Set("Range", "bytes=0-0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, page[:1], w.Body.Bytes())
+}
+
+func TestGetAssetUnsatisfiableRange(t *testing.T) {
+	router, assetURL, _ := rangeTestServer(t)
+
+	for _, header := range []string{"bytes=1000-", "bytes=1000-1010,2000-"} {
+		r := httptest.NewRequest("GET", assetURL, nil)
+		r.Header.Set("Range", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code, header)
+		assert.Equal(t, "bytes */1000", w.Header().Get("Content-Range"))
+		assert.Empty(t, w.Header().Get("Content-Length"))
+	}
+}
+
+func TestGetAssetTooManyRanges(t *testing.T) {
+	router, assetURL, page := rangeTestServer(t)
+
+	r := httptest.NewRequest("GET", assetURL, nil)
+	r.Header.Set("Range", disjointRanges(MaxRanges+1))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Range"))
+	assert.Equal(t, page, w.Body.Bytes())
+}
+
+func TestGetAssetMultipartRanges(t *testing.T) {
+	router, assetURL, page := rangeTestServer(t)
+
+	r := httptest.NewRequest("GET", assetURL, nil)
+	r.Header.Set("Range", "bytes=500-509,0-9,5-14,-5")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusPartialContent, w.Code)
+
+	// The declared length matches the body
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+
+	expected := []struct {
+		contentRange string
+		data         []byte
+	}{
+		{"bytes 0-14/1000", page[0:15]},
+		{"bytes 500-509/1000", page[500:510]},
+		{"bytes 995-999/1000", page[995:]},
+	}
+	mr := multipart.NewReader(bytes.NewReader(w.Body.Bytes()), params["boundary"])
+	for _, e := range expected {
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, e.contentRange, part.Header.Get("Content-Range"))
+		assert.Equal(t, "image/jpeg", part.Header.Get("Content-Type"))
+		data, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, e.data, data)
+	}
+	_, err = mr.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
@@ -9,12 +9,21 @@ import (
 )
 
 func (s *Server) Routes() *mux.Router {
-	r := mux.NewRouter()
+	root := mux.NewRouter()
+	root.Use(s.instrument, s.cors)
+	r := root
+	if s.config.BasePath != "" {
+		r = root.PathPrefix(s.config.BasePath).Subrouter()
+	}
 
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
-	})
+	}).Name("health")
+	// Like /health, the metrics are not behind the authorization middleware, which only protects the publications.
+	// They don't reveal the paths of the publications, but access to them should be restricted by a reverse proxy
+	// when the server is exposed.
+	r.HandleFunc("/metrics", s.getMetrics).Name("metrics")
 
 	if s.config.Debug {
 		r.HandleFunc("/debug/pprof/", pprof.Index)
@@ -31,17 +40,28 @@ func (s *Server) Routes() *mux.Router {
 		r.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 	}
 
-	r.HandleFunc("/list.json", s.demoList).Name("demo_list")
+	r.HandleFunc("/opds.json", s.getOPDSFeed).Name("opds")
 
 	pub := r.PathPrefix("/{path}").Subrouter()
 	// TODO: publication loading middleware with pub.Use()
+	pub.Use(s.requireAuthorization)
 	pub.Use(func(h http.Handler) http.Handler {
 		adapter, _ := httpcompression.DefaultAdapter(httpcompression.ContentTypes(compressableMimes, false))
-		return adapter(h)
+		compressed := adapter(h)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The compression middleware drops the Range header, so range requests are served uncompressed.
+			if r.Header.Get("range") != "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			compressed.ServeHTTP(w, r)
+		})
 	})
 	pub.HandleFunc("/manifest.json", s.getManifest).Name("manifest")
+	pub.HandleFunc("/~readium/content.json", s.getContent).Name("content")
+	pub.HandleFunc("/~readium/cover", s.getCover).Name("cover")
 	pub.HandleFunc("/{asset:.*}", s.getAsset).Name("asset")
 
-	s.router = r
-	return r
+	s.router = root
+	return root
 }
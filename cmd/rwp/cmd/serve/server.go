@@ -1,6 +1,10 @@
 package serve
 
 import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"runtime"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -11,22 +15,85 @@ import (
 type ServerConfig struct {
 	Debug             bool
 	BaseDirectory     string
+	BasePath          string // Path prefix of all the routes, e.g. "/books", or empty to serve them from the root.
 	JSONIndent        string
 	InferA11yMetadata streamer.InferA11yMetadata
+	ScanInterval      time.Duration // Interval between the scans of the base directory for changes, or 0 to disable them.
+
+	CORSAllowedOrigins []string // Origins allowed to make cross-origin requests, "*" allows any origin.
+	CORSAllowedHeaders []string // Request headers allowed in cross-origin requests, in addition to the safelisted ones.
+
+	CacheSize int           // Maximum number of publications kept open. Defaults to [MaxCachedPublicationAmount].
+	CacheTTL  time.Duration // Duration after which an open publication is closed. Defaults to [MaxCachedPublicationTTL].
+
+	ImageCacheDirectory string // Directory where the resized covers and pages are cached, or empty to disable the cache.
+	ImageCacheSize      int64  // Maximum size of the cached images, in bytes. Defaults to [DefaultImageCacheSize].
+	ImageConcurrency    int    // Maximum number of images resized concurrently. Defaults to the number of CPUs.
+
+	// Authorizers of the requests to the publications, which are all public if none is given.
+	// A request is allowed if any of them grants access.
+	Authorizers []Authorizer
 }
 
 type Server struct {
 	config ServerConfig
 	router *mux.Router
 	lfu    *cache.TinyLFU
+
+	metrics *metrics
+
+	images     *imageCache   // Cache of the resized images, nil if disabled.
+	imageSlots chan struct{} // Semaphore bounding the number of images resized concurrently.
+
+	catalog *catalog
 }
 
 const MaxCachedPublicationAmount = 10
 const MaxCachedPublicationTTL = time.Second * time.Duration(600)
 
+// Default interval between the scans of the base directory for added, modified or removed publications.
+const DefaultScanInterval = 30 * time.Second
+
 func NewServer(config ServerConfig) *Server {
-	return &Server{
+	if config.CacheSize <= 0 {
+		config.CacheSize = MaxCachedPublicationAmount
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = MaxCachedPublicationTTL
+	}
+	if config.ImageCacheSize <= 0 {
+		config.ImageCacheSize = DefaultImageCacheSize
+	}
+	if config.ImageConcurrency <= 0 {
+		config.ImageConcurrency = runtime.NumCPU()
+	}
+	s := &Server{
 		config: config,
-		lfu:    cache.NewTinyLFU(MaxCachedPublicationAmount, MaxCachedPublicationTTL),
+		lfu:    cache.NewTinyLFU(config.CacheSize, config.CacheTTL),
+
+		metrics: newMetrics(),
+
+		imageSlots: make(chan struct{}, config.ImageConcurrency),
+	}
+	if config.ImageCacheDirectory != "" {
+		s.images = newImageCache(config.ImageCacheDirectory, config.ImageCacheSize)
+	}
+	// The scans don't record open failures, as the files found in the directory might not be publications.
+	s.catalog = newCatalog(config.BaseDirectory, s.parsePublication, func(path string) {
+		// Evicts the cached publication, so that the new version is opened on the next request. The old one is closed
+		// once the requests reading it are done.
+		s.lfu.Del(filepath.Clean(path))
+	})
+	return s
+}
+
+// Scans the base directory for changes every [ServerConfig.ScanInterval], until [ctx] is done.
+// The publications are listed right away in the OPDS feed, instead of on its first request.
+func (s *Server) WatchLibrary(ctx context.Context) {
+	if err := s.catalog.Refresh(); err != nil {
+		slog.Error("failed scanning publications directory", "error", err)
+	}
+	if s.config.ScanInterval > 0 {
+		s.catalog.Poll(ctx, s.config.ScanInterval)
 	}
 }
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve"
+	"github.com/readium/go-toolkit/pkg/streamer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration of the serve command, which can be loaded from a TOML or YAML file with the --config flag.
+// The flags given on the command line take precedence over the configuration file.
+type serveConfig struct {
+	Directory    string            `toml:"directory" yaml:"directory"`         // Directory of the publications.
+	Address      string            `toml:"address" yaml:"address"`             // Address to bind the HTTP server to.
+	Port         uint16            `toml:"port" yaml:"port"`                   // Port to bind the HTTP server to.
+	BasePath     string            `toml:"base_path" yaml:"base_path"`         // Path prefix of all the routes, e.g. "/books".
+	Debug        bool              `toml:"debug" yaml:"debug"`                 // Enables the debug logs and routes.
+	Indent       string            `toml:"indent" yaml:"indent"`               // Indentation used to pretty-print JSON files.
+	InferA11y    InferA11yMetadata `toml:"infer_a11y" yaml:"infer_a11y"`       // Inference of accessibility metadata.
+	ScanInterval duration          `toml:"scan_interval" yaml:"scan_interval"` // Interval between the scans of the directory.
+	HTTP2        bool              `toml:"http2" yaml:"http2"`                 // Enables HTTP/2, over TLS or cleartext (h2c).
+
+	TLS struct {
+		Cert string `toml:"cert" yaml:"cert"` // Path to the PEM certificate, enables HTTPS.
+		Key  string `toml:"key" yaml:"key"`   // Path to the PEM private key of the certificate.
+	} `toml:"tls" yaml:"tls"`
+
+	Timeouts struct {
+		Read  duration `toml:"read" yaml:"read"`   // Maximum duration for reading a request, 0 for no timeout.
+		Write duration `toml:"write" yaml:"write"` // Maximum duration for writing a response, 0 for no timeout.
+		Idle  duration `toml:"idle" yaml:"idle"`   // Maximum duration of an idle keep-alive connection.
+	} `toml:"timeouts" yaml:"timeouts"`
+
+	CORS struct {
+		Origins []string `toml:"origins" yaml:"origins"` // Allowed origins, "*" for any origin or none to disable CORS.
+		Headers []string `toml:"headers" yaml:"headers"` // Allowed request headers.
+	} `toml:"cors" yaml:"cors"`
+
+	Cache struct {
+		Size int      `toml:"size" yaml:"size"` // Maximum number of publications kept open.
+		TTL  duration `toml:"ttl" yaml:"ttl"`   // Duration after which an open publication is closed.
+	} `toml:"cache" yaml:"cache"`
+
+	Images struct {
+		CacheDirectory string `toml:"cache_directory" yaml:"cache_directory"` // Directory of the resized covers and pages, "" to disable the cache.
+		CacheSize      int64  `toml:"cache_size" yaml:"cache_size"`           // Maximum size of the cached images, in megabytes.
+		Concurrency    int    `toml:"concurrency" yaml:"concurrency"`         // Maximum number of images resized concurrently.
+	} `toml:"images" yaml:"images"`
+
+	Auth struct {
+		Tokens         []authToken `toml:"tokens" yaml:"tokens"`                     // Static bearer tokens.
+		SigningKey     string      `toml:"signing_key" yaml:"signing_key"`           // Key of the signed URLs.
+		SigningKeyFile string      `toml:"signing_key_file" yaml:"signing_key_file"` // File containing the key of the signed URLs.
+	} `toml:"auth" yaml:"auth"`
+}
+
+// Bearer token granting access to the publications matching the patterns, or to all of them if there are none.
+type authToken struct {
+	Token        string   `toml:"token" yaml:"token"`
+	Publications []string `toml:"publications" yaml:"publications"`
+}
+
+// Minimum length of the key of the signed URLs, in bytes.
+const minSigningKeyLength = 32
+
+func defaultServeConfig() serveConfig {
+	c := serveConfig{
+		Address:      "localhost",
+		Port:         15080,
+		ScanInterval: duration(serve.DefaultScanInterval),
+		HTTP2:        true,
+	}
+	c.Timeouts.Read = duration(10 * time.Second)
+	c.Timeouts.Idle = duration(2 * time.Minute)
+	c.CORS.Origins = []string{"*"}
+	c.CORS.Headers = []string{"Authorization", "Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+	c.Cache.Size = serve.MaxCachedPublicationAmount
+	c.Cache.TTL = duration(serve.MaxCachedPublicationTTL)
+	c.Images.CacheSize = serve.DefaultImageCacheSize >> 20
+	c.Images.Concurrency = runtime.NumCPU()
+	if dir, err := os.UserCacheDir(); err == nil {
+		c.Images.CacheDirectory = filepath.Join(dir, "rwp", "images")
+	}
+	return c
+}
+
+// Binds the flags of the command to the fields of the configuration, using their current values as defaults.
+func (c *serveConfig) bindFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&c.Address, "address", "a", c.Address, "Address to bind the HTTP server to")
+	flags.Uint16VarP(&c.Port, "port", "p", c.Port, "Port to bind the HTTP server to")
+	flags.StringVar(&c.BasePath, "base-path", c.BasePath, "Path prefix of all the routes, e.g. /books")
+	flags.StringVarP(&c.Indent, "indent", "i", c.Indent, "Indentation used to pretty-print JSON files")
+	flags.Var(&c.InferA11y, "infer-a11y", "Infer accessibility metadata: no, merged, split")
+	flags.DurationVar((*time.Duration)(&c.ScanInterval), "scan-interval", time.Duration(c.ScanInterval), "Interval between the scans of the directory for new or modified publications, 0 to disable")
+	flags.BoolVar(&c.HTTP2, "http2", c.HTTP2, "Enable HTTP/2, over TLS or cleartext")
+	flags.StringVar(&c.TLS.Cert, "tls-cert", c.TLS.Cert, "Path to a PEM certificate, to serve over HTTPS")
+	flags.StringVar(&c.TLS.Key, "tls-key", c.TLS.Key, "Path to the PEM private key of the TLS certificate")
+	flags.DurationVar((*time.Duration)(&c.Timeouts.Read), "read-timeout", time.Duration(c.Timeouts.Read), "Maximum duration for reading a request, 0 for no timeout")
+	flags.DurationVar((*time.Duration)(&c.Timeouts.Write), "write-timeout", time.Duration(c.Timeouts.Write), "Maximum duration for writing a response, 0 for no timeout")
+	flags.DurationVar((*time.Duration)(&c.Timeouts.Idle), "idle-timeout", time.Duration(c.Timeouts.Idle), "Maximum duration of an idle keep-alive connection")
+	flags.StringSliceVar(&c.CORS.Origins, "cors-origin", c.CORS.Origins, "Origins allowed to make cross-origin requests, * for any origin")
+	flags.StringSliceVar(&c.CORS.Headers, "cors-header", c.CORS.Headers, "Request headers allowed in cross-origin requests")
+	flags.IntVar(&c.Cache.Size, "cache-size", c.Cache.Size, "Maximum number of publications kept open")
+	flags.DurationVar((*time.Duration)(&c.Cache.TTL), "cache-ttl", time.Duration(c.Cache.TTL), "Duration after which an open publication is closed")
+	flags.StringVar(&c.Images.CacheDirectory, "image-cache-dir", c.Images.CacheDirectory, "Directory where the resized covers and pages are cached, empty to disable the cache")
+	flags.Int64Var(&c.Images.CacheSize, "image-cache-size", c.Images.CacheSize, "Maximum size of the cached images, in megabytes")
+	flags.IntVar(&c.Images.Concurrency, "image-concurrency", c.Images.Concurrency, "Maximum number of images resized concurrently")
+	flags.StringVar(&c.Auth.SigningKeyFile, "signing-key-file", c.Auth.SigningKeyFile, "File containing the key of the signed URLs, which enables authentication")
+	flags.BoolVarP(&c.Debug, "debug", "d", c.Debug, "Enable debug mode")
+}
+
+// Loads the configuration file at [path], keeping the values of the flags explicitly set on the command line.
+// The format of the file is TOML, or YAML if its extension is .yaml or .yml.
+func (c *serveConfig) load(path string, cmd *cobra.Command) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading configuration file: %w", err)
+	}
+
+	// Values of the flags set on the command line, which are restored after decoding the file.
+	flags := map[*pflag.Flag]string{}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags[f] = f.Value.String()
+	})
+	sliceFlags := map[pflag.SliceValue][]string{}
+	for f := range flags {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			// Copied, as the decoders can reuse the backing array of the field
+			sliceFlags[sv] = append([]string(nil), sv.GetSlice()...)
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	default:
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	}
+	if err != nil {
+		var strictErr *toml.StrictMissingError
+		if errors.As(err, &strictErr) {
+			return fmt.Errorf("unknown keys in configuration file %s:\n%s", path, strictErr.String())
+		}
+		return fmt.Errorf("failed parsing configuration file %s: %w", path, err)
+	}
+
+	for f, value := range flags {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			err = sv.Replace(sliceFlags[sv])
+		} else {
+			err = f.Value.Set(value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for flag --%s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validates and normalizes the configuration.
+func (c *serveConfig) validate() error {
+	var errs []error
+
+	if c.Directory == "" {
+		errs = append(errs, errors.New("a directory of publications is required"))
+	} else {
+		c.Directory = filepath.Clean(c.Directory)
+		fi, err := os.Stat(c.Directory)
+		if err != nil {
+			if os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("given directory %s does not exist", c.Directory))
+			} else {
+				errs = append(errs, fmt.Errorf("failed to stat %s: %w", c.Directory, err))
+			}
+		} else if !fi.IsDir() {
+			errs = append(errs, fmt.Errorf("given path %s is not a directory", c.Directory))
+		}
+	}
+
+	if c.Port == 0 {
+		errs = append(errs, errors.New("port must be greater than 0"))
+	}
+
+	if c.BasePath != "" {
+		if !strings.HasPrefix(c.BasePath, "/") {
+			errs = append(errs, fmt.Errorf("base path %s must start with a /", c.BasePath))
+		}
+		c.BasePath = strings.TrimSuffix(path.Clean(c.BasePath), "/")
+	}
+
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		errs = append(errs, errors.New("both a TLS certificate and key are required"))
+	} else if c.TLS.Cert != "" {
+		if _, err := tls.LoadX509KeyPair(c.TLS.Cert, c.TLS.Key); err != nil {
+			errs = append(errs, fmt.Errorf("invalid TLS certificate or key: %w", err))
+		}
+	}
+
+	if c.Timeouts.Read < 0 || c.Timeouts.Write < 0 || c.Timeouts.Idle < 0 {
+		errs = append(errs, errors.New("timeouts can't be negative"))
+	}
+	if c.ScanInterval < 0 {
+		errs = append(errs, errors.New("scan interval can't be negative"))
+	}
+
+	for i, origin := range c.CORS.Origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			errs = append(errs, fmt.Errorf("invalid CORS origin %s, expected e.g. https://example.com", origin))
+			continue
+		}
+		c.CORS.Origins[i] = u.Scheme + "://" + u.Host
+	}
+
+	if c.Cache.Size < 1 {
+		errs = append(errs, errors.New("cache size must be at least 1"))
+	}
+	if c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache TTL must be greater than 0"))
+	}
+
+	if c.Images.CacheSize < 1 {
+		errs = append(errs, errors.New("image cache size must be at least 1 MB"))
+	}
+	if c.Images.Concurrency < 1 {
+		errs = append(errs, errors.New("image concurrency must be at least 1"))
+	}
+
+	for i, t := range c.Auth.Tokens {
+		if len(t.Token) < 16 {
+			errs = append(errs, fmt.Errorf("auth token #%d must be at least 16 characters long", i+1))
+		}
+		for _, pattern := range t.Publications {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("invalid publication pattern %q of auth token #%d", pattern, i+1))
+			}
+		}
+	}
+	if _, err := c.signingKey(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Returns the key of the signed URLs, or nil if they are disabled.
+func (c serveConfig) signingKey() ([]byte, error) {
+	key := []byte(c.Auth.SigningKey)
+	if c.Auth.SigningKeyFile != "" {
+		if len(key) > 0 {
+			return nil, errors.New("only one of signing_key and signing_key_file can be given")
+		}
+		data, err := os.ReadFile(c.Auth.SigningKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading signing key file: %w", err)
+		}
+		key = bytes.TrimSpace(data)
+	}
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) < minSigningKeyLength {
+		return nil, fmt.Errorf("signing key must be at least %d bytes long", minSigningKeyLength)
+	}
+	return key, nil
+}
+
+func (c serveConfig) serverConfig() serve.ServerConfig {
+	var authorizers []serve.Authorizer
+	if len(c.Auth.Tokens) > 0 {
+		tokens := serve.BearerTokens{}
+		for _, t := range c.Auth.Tokens {
+			tokens[t.Token] = t.Publications
+		}
+		authorizers = append(authorizers, tokens)
+	}
+	if key, _ := c.signingKey(); key != nil {
+		authorizers = append(authorizers, serve.URLSigner{Key: key})
+	}
+
+	return serve.ServerConfig{
+		Debug:               c.Debug,
+		BaseDirectory:       c.Directory,
+		BasePath:            c.BasePath,
+		JSONIndent:          c.Indent,
+		InferA11yMetadata:   streamer.InferA11yMetadata(c.InferA11y),
+		ScanInterval:        time.Duration(c.ScanInterval),
+		CORSAllowedOrigins:  c.CORS.Origins,
+		CORSAllowedHeaders:  c.CORS.Headers,
+		CacheSize:           c.Cache.Size,
+		CacheTTL:            time.Duration(c.Cache.TTL),
+		ImageCacheDirectory: c.Images.CacheDirectory,
+		ImageCacheSize:      c.Images.CacheSize << 20,
+		ImageConcurrency:    c.Images.Concurrency,
+		Authorizers:         authorizers,
+	}
+}
+
+// Duration which can be decoded from a string such as "1m30s" in the configuration files.
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve"
+	"github.com/readium/go-toolkit/pkg/streamer"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the default configuration bound to the flags of a command, parsed from [args].
+func parseServeFlags(t *testing.T, args ...string) (*serveConfig, *cobra.Command) {
+	c := defaultServeConfig()
+	cmd := &cobra.Command{Use: "serve"}
+	c.bindFlags(cmd.Flags())
+	require.NoError(t, cmd.Flags().Parse(args))
+	return &c, cmd
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+const tomlServeConfig = `
+directory = "/srv/books"
+address = "0.0.0.0"
+port = 8080
+base_path = "/books"
+infer_a11y = "merged"
+scan_interval = "1m30s"
+
+[timeouts]
+read = "30s"
+
+[cors]
+origins = ["https://a.example.com"]
+
+[cache]
+size = 42
+
+[auth]
+signing_key = "0123456789abcdef0123456789abcdef"
+
+[[auth.tokens]]
+token = "0123456789abcdef"
+publications = ["public/*"]
+`
+
+const yamlServeConfig = `
+directory: /srv/books
+address: 0.0.0.0
+port: 8080
+base_path: /books
+infer_a11y: merged
+scan_interval: 1m30s
+timeouts:
+  read: 30s
+cors:
+  origins: [https://a.example.com]
+cache:
+  size: 42
+auth:
+  signing_key: 0123456789abcdef0123456789abcdef
+  tokens:
+    - token: 0123456789abcdef
+      publications: [public/*]
+`
+
+func TestServeConfigLoad(t *testing.T) {
+	for _, file := range []struct{ name, content string }{
+		{"rwp.toml", tomlServeConfig},
+		{"rwp.yaml", yamlServeConfig},
+		{"rwp.yml", yamlServeConfig},
+	} {
+		c, cmd := parseServeFlags(t)
+		require.NoError(t, c.load(writeConfigFile(t, file.name, file.content), cmd), file.name)
+
+		assert.Equal(t, "/srv/books", c.Directory, file.name)
+		assert.Equal(t, "0.0.0.0", c.Address, file.name)
+		assert.Equal(t, uint16(8080), c.Port, file.name)
+		assert.Equal(t, "/books", c.BasePath, file.name)
+		assert.Equal(t, InferA11yMetadata(streamer.InferA11yMetadataMerged), c.InferA11y, file.name)
+		assert.Equal(t, duration(90*time.Second), c.ScanInterval, file.name)
+		assert.Equal(t, duration(30*time.Second), c.Timeouts.Read, file.name)
+		assert.Equal(t, []string{"https://a.example.com"}, c.CORS.Origins, file.name)
+		assert.Equal(t, 42, c.Cache.Size, file.name)
+		assert.Equal(t, "0123456789abcdef0123456789abcdef", c.Auth.SigningKey, file.name)
+		assert.Equal(t, []authToken{{Token: "0123456789abcdef", Publications: []string{"public/*"}}}, c.Auth.Tokens, file.name)
+
+		// The values missing from the file keep their defaults
+		assert.Equal(t, duration(2*time.Minute), c.Timeouts.Idle, file.name)
+		assert.True(t, c.HTTP2, file.name)
+	}
+}
+
+func TestServeConfigLoadKeepsExplicitFlags(t *testing.T) {
+	for _, file := range []struct{ name, content string }{
+		{"rwp.toml", tomlServeConfig},
+		{"rwp.yaml", yamlServeConfig},
+	} {
+		c, cmd := parseServeFlags(t,
+			"--port", "9000",
+			"--read-timeout", "5s",
+			"--cors-origin", "https://b.example.com,https://c.example.com",
+			"--infer-a11y", "split",
+		)
+		require.NoError(t, c.load(writeConfigFile(t, file.name, file.content), cmd), file.name)
+
+		assert.Equal(t, uint16(9000), c.Port, file.name)
+		assert.Equal(t, duration(5*time.Second), c.Timeouts.Read, file.name)
+		assert.Equal(t, []string{"https://b.example.com", "https://c.example.com"}, c.CORS.Origins, file.name)
+		assert.Equal(t, InferA11yMetadata(streamer.InferA11yMetadataSplit), c.InferA11y, file.name)
+		// The values without flags come from the file
+		assert.Equal(t, "0.0.0.0", c.Address, file.name)
+		assert.Equal(t, duration(90*time.Second), c.ScanInterval, file.name)
+	}
+}
+
+func TestServeConfigLoadRejectsUnknownKeys(t *testing.T) {
+	for _, file := range []struct{ name, content string }{
+		{"rwp.toml", "prot = 8080\n"},
+		{"rwp.toml", "[cache]\nsizes = 42\n"},
+		{"rwp.yaml", "prot: 8080\n"},
+		{"rwp.yaml", "cache:\n  sizes: 42\n"},
+	} {
+		c, cmd := parseServeFlags(t)
+		assert.Error(t, c.load(writeConfigFile(t, file.name, file.content), cmd), file.content)
+	}
+
+	c, cmd := parseServeFlags(t)
+	err := c.load(writeConfigFile(t, "rwp.toml", "prot = 8080\n"), cmd)
+	assert.ErrorContains(t, err, "unknown keys")
+
+	c, cmd = parseServeFlags(t)
+	assert.ErrorContains(t, c.load(filepath.Join(t.TempDir(), "missing.toml"), cmd), "failed reading configuration file")
+}
+
+func TestServeConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("not a directory"), 0o644))
+
+	tests := []struct {
+		name   string
+		modify func(c *serveConfig)
+		err    string
+	}{
+		{"missing directory", func(c *serveConfig) { c.Directory = "" }, "a directory of publications is required"},
+		{"nonexistent directory", func(c *serveConfig) { c.Directory = filepath.Join(dir, "missing") }, "does not exist"},
+		{"not a directory", func(c *serveConfig) { c.Directory = file }, "is not a directory"},
+		{"port", func(c *serveConfig) { c.Port = 0 }, "port must be greater than 0"},
+		{"base path", func(c *serveConfig) { c.BasePath = "books" }, "base path books must start with a /"},
+		{"TLS key", func(c *serveConfig) { c.TLS.Cert = file }, "both a TLS certificate and key are required"},
+		{"TLS certificate", func(c *serveConfig) { c.TLS.Cert, c.TLS.Key = file, file }, "invalid TLS certificate or key"},
+		{"timeouts", func(c *serveConfig) { c.Timeouts.Write = -1 }, "timeouts can't be negative"},
+		{"scan interval", func(c *serveConfig) { c.ScanInterval = -1 }, "scan interval can't be negative"},
+		{"CORS origin", func(c *serveConfig) { c.CORS.Origins = []string{"ftp://example.com"} }, "invalid CORS origin ftp://example.com"},
+		{"CORS origin path", func(c *serveConfig) { c.CORS.Origins = []string{"https://example.com/path"} }, "invalid CORS origin"},
+		{"cache size", func(c *serveConfig) { c.Cache.Size = 0 }, "cache size must be at least 1"},
+		{"cache TTL", func(c *serveConfig) { c.Cache.TTL = 0 }, "cache TTL must be greater than 0"},
+		{"image cache size", func(c *serveConfig) { c.Images.CacheSize = 0 }, "image cache size must be at least 1 MB"},
+		{"image concurrency", func(c *serveConfig) { c.Images.Concurrency = 0 }, "image concurrency must be at least 1"},
+		{"short token", func(c *serveConfig) { c.Auth.Tokens = []authToken{{Token: "short"}} }, "auth token #1 must be at least 16 characters long"},
+		{"token pattern", func(c *serveConfig) {
+			c.Auth.Tokens = []authToken{{Token: "0123456789abcdef", Publications: []string{"["}}}
+		}, `invalid publication pattern "[" of auth token #1`},
+		{"signing keys", func(c *serveConfig) { c.Auth.SigningKey, c.Auth.SigningKeyFile = strings.Repeat("k", 32), file }, "only one of signing_key and signing_key_file can be given"},
+		{"signing key file", func(c *serveConfig) { c.Auth.SigningKeyFile = filepath.Join(dir, "missing") }, "failed reading signing key file"},
+		{"short signing key", func(c *serveConfig) { c.Auth.SigningKey = "short" }, "signing key must be at least 32 bytes long"},
+	}
+	for _, tt := range tests {
+		c := defaultServeConfig()
+		c.Directory = dir
+		tt.modify(&c)
+		assert.ErrorContains(t, c.validate(), tt.err, tt.name)
+	}
+
+	// The errors are all reported at once
+	c := defaultServeConfig()
+	c.Port = 0
+	c.Cache.Size = 0
+	err := c.validate()
+	assert.ErrorContains(t, err, "a directory of publications is required")
+	assert.ErrorContains(t, err, "port must be greater than 0")
+	assert.ErrorContains(t, err, "cache size must be at least 1")
+}
+
+func TestServeConfigValidateNormalizes(t *testing.T) {
+	dir := t.TempDir()
+	c := defaultServeConfig()
+	c.Directory = dir + "/"
+	c.BasePath = "/books/"
+	c.CORS.Origins = []string{"*", "https://example.com/"}
+	require.NoError(t, c.validate())
+	assert.Equal(t, dir, c.Directory)
+	assert.Equal(t, "/books", c.BasePath)
+	assert.Equal(t, []string{"*", "https://example.com"}, c.CORS.Origins)
+}
+
+func TestServeConfigServerConfig(t *testing.T) {
+	keyFile := writeConfigFile(t, "key", strings.Repeat("k", 32)+"\n")
+	c := defaultServeConfig()
+	c.Directory = "/srv/books"
+	c.Cache.TTL = duration(time.Hour)
+	c.Images.CacheSize = 2
+	c.Auth.Tokens = []authToken{{Token: "0123456789abcdef", Publications: []string{"public/*"}}}
+	c.Auth.SigningKeyFile = keyFile
+
+	config := c.serverConfig()
+	assert.Equal(t, "/srv/books", config.BaseDirectory)
+	assert.Equal(t, time.Hour, config.CacheTTL)
+	assert.Equal(t, int64(2<<20), config.ImageCacheSize)
+	assert.Equal(t, []serve.Authorizer{
+		serve.BearerTokens{"0123456789abcdef": {"public/*"}},
+		serve.URLSigner{Key: []byte(strings.Repeat("k", 32))},
+	}, config.Authorizers)
+
+	// Without tokens nor signing key, the publications are public
+	assert.Empty(t, defaultServeConfig().serverConfig().Authorizers)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve"
+	"github.com/spf13/cobra"
+)
+
+// Configuration of the server the signed URLs are generated for.
+var signConf = defaultServeConfig()
+
+// Path to the TOML or YAML configuration file of the server.
+var signConfigFlag string
+
+// Duration of validity of the signed URL.
+var signExpiresFlag time.Duration
+
+// Base URL of the server, overriding the one derived from the configuration.
+var signBaseURLFlag string
+
+var signCmd = &cobra.Command{
+	Use:   "sign <pub-path>",
+	Short: "Generate a signed URL of a publication served with rwp serve",
+	Long: `Generate a signed URL of a publication served with rwp serve.
+
+The signed URL grants access to the manifest and resources of the publication
+until it expires, without sending an Authorization header. The path of the
+publication is relative to the directory served, as listed in the OPDS feed.
+
+The signing key is read from the configuration file of the server, or from the
+file given with --signing-key-file.
+
+Examples:
+  Print out the manifest URL of a publication, valid for 24 hours.
+  $ rwp sign --config rwp.toml novels/moby-dick.epub
+
+  Print out a URL valid for 1 hour, for a server behind a reverse proxy.
+  $ rwp sign --config rwp.toml --expires 1h --base-url https://example.com/books moby-dick.epub
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expects a path to the publication")
+		}
+		if signExpiresFlag <= 0 {
+			return errors.New("expiration must be greater than 0")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// By the time we reach this point, we know that the arguments were
+		// properly parsed, and we don't want to show the usage if an API error
+		// occurs.
+		cmd.SilenceUsage = true
+
+		if signConfigFlag != "" {
+			if err := signConf.load(signConfigFlag, cmd); err != nil {
+				return err
+			}
+		}
+		key, err := signConf.signingKey()
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return errors.New("a signing key is required, with --signing-key-file or in the configuration file")
+		}
+
+		baseURL := signBaseURLFlag
+		if baseURL == "" {
+			scheme := "http"
+			if signConf.TLS.Cert != "" {
+				scheme = "https"
+			}
+			baseURL = scheme + "://" + net.JoinHostPort(signConf.Address, strconv.Itoa(int(signConf.Port))) + signConf.BasePath
+		}
+
+		u, err := serve.URLSigner{Key: key}.ManifestURL(baseURL, args[0], time.Now().Add(signExpiresFlag))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), u)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+	signCmd.Flags().StringVarP(&signConfigFlag, "config", "c", "", "Path to the TOML or YAML configuration file of the server")
+	signCmd.Flags().StringVar(&signConf.Auth.SigningKeyFile, "signing-key-file", "", "File containing the key of the signed URLs")
+	signCmd.Flags().DurationVarP(&signExpiresFlag, "expires", "e", 24*time.Hour, "Duration of validity of the URL")
+	signCmd.Flags().StringVar(&signBaseURLFlag, "base-url", "", "Base URL of the server, defaults to the one of the configuration")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/cmd/rwp/cmd/serve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSigningKey = strings.Repeat("k", 32)
+
+// Runs the sign command with the given arguments, and returns the signed URL it prints.
+func runSign(t *testing.T, args ...string) (string, error) {
+	// The flags keep their values between the executions of the command
+	signConf = defaultServeConfig()
+	signConfigFlag, signExpiresFlag, signBaseURLFlag = "", 24*time.Hour, ""
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"sign"}, args...))
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	})
+	err := rootCmd.Execute()
+	return strings.TrimSpace(out.String()), err
+}
+
+// Returns the first segment of the path of the signed manifest URL, after the [basePath].
+func signedSegment(t *testing.T, signed string, basePath string) string {
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	segment, ok := strings.CutSuffix(strings.TrimPrefix(u.Path, basePath+"/"), "/manifest.json")
+	require.True(t, ok, signed)
+	return segment
+}
+
+func TestSignURL(t *testing.T) {
+	keyFile := writeConfigFile(t, "key", testSigningKey+"\n")
+	signed, err := runSign(t, "--signing-key-file", keyFile, "--base-url", "https://example.com/books/", "novels/moby dick.epub")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "https://example.com/books/"), signed)
+
+	signer := serve.URLSigner{Key: []byte(testSigningKey)}
+	segment := signedSegment(t, signed, "/books")
+	path, err := signer.Verify(segment, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "novels/moby dick.epub", path)
+
+	// Expired
+	_, err = signer.Verify(segment, time.Now().Add(25*time.Hour))
+	assert.ErrorIs(t, err, serve.ErrForbidden)
+
+	// Tampered signature, expiration or path
+	parts := strings.Split(segment, ".")
+	require.Len(t, parts, 3)
+	other := signer.Sign("novels/other.epub", time.Now().Add(time.Hour))
+	for _, tampered := range []string{
+		parts[0] + "." + parts[1] + "." + strings.ToUpper(parts[2]),
+		parts[0] + "." + parts[1] + "z." + parts[2],
+		strings.Split(other, ".")[0] + "." + parts[1] + "." + parts[2],
+	} {
+		_, err = signer.Verify(tampered, time.Now())
+		assert.ErrorIs(t, err, serve.ErrForbidden, tampered)
+	}
+
+	// Signed with another key
+	_, err = serve.URLSigner{Key: []byte(strings.Repeat("x", 32))}.Verify(segment, time.Now())
+	assert.ErrorIs(t, err, serve.ErrForbidden)
+}
+
+func TestSignURLExpiration(t *testing.T) {
+	keyFile := writeConfigFile(t, "key", testSigningKey)
+	signed, err := runSign(t, "--signing-key-file", keyFile, "--base-url", "https://example.com", "--expires", "1h", "book.epub")
+	require.NoError(t, err)
+
+	signer := serve.URLSigner{Key: []byte(testSigningKey)}
+	segment := signedSegment(t, signed, "")
+	_, err = signer.Verify(segment, time.Now().Add(59*time.Minute))
+	assert.NoError(t, err)
+	_, err = signer.Verify(segment, time.Now().Add(61*time.Minute))
+	assert.ErrorIs(t, err, serve.ErrForbidden)
+}
+
+func TestSignURLAcceptedByServer(t *testing.T) {
+	dir := t.TempDir()
+	epub, err := os.ReadFile(writeMediaOverlayEPUB(t))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "book.epub"), epub, 0o644))
+	config := writeConfigFile(t, "rwp.toml", `
+base_path = "/books"
+
+[auth]
+signing_key = "`+testSigningKey+`"
+`)
+
+	signed, err := runSign(t, "--config", config, "book.epub")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed, "http://localhost:15080/books/"), signed)
+
+	server := serve.NewServer(serve.ServerConfig{
+		BaseDirectory: dir,
+		Authorizers:   []serve.Authorizer{serve.URLSigner{Key: []byte(testSigningKey)}},
+	})
+	router := server.Routes()
+	segment := signedSegment(t, signed, "/books")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+segment+"/manifest.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Tampered signature
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/"+segment+"x/manifest.json", nil))
+	assert.NotEqual(t, http.StatusOK, w.Code)
+}
+
+func TestSignRequiresKey(t *testing.T) {
+	_, err := runSign(t, "book.epub")
+	assert.ErrorContains(t, err, "a signing key is required")
+
+	_, err = runSign(t, "--expires", "0s", "book.epub")
+	assert.ErrorContains(t, err, "expiration must be greater than 0")
+}
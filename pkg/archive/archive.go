@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 )
 
 type ArchiveFactory interface {
@@ -66,6 +67,12 @@ type Entry interface {
 
 }
 
+// Optionally implemented by an [Entry] to identify the version of its content, e.g. to validate cached copies.
+type EntryValidators interface {
+	ModTime() time.Time // Last modification time of the entry, or zero if unknown.
+	CRC32() uint32      // CRC-32 checksum of the uncompressed data, or 0 if unknown.
+}
+
 // Represents an immutable archive.
 type Archive interface {
 	Entries() []Entry                 // List of all the archived file entries.
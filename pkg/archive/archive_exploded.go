@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type explodedArchiveEntry struct {
@@ -30,6 +31,15 @@ func (e explodedArchiveEntry) CompressedAs(compressionMethod CompressionMethod)
 	return false
 }
 
+func (e explodedArchiveEntry) ModTime() time.Time {
+	return e.fi.ModTime()
+}
+
+// The checksum of files is not computed, as it would require reading them entirely.
+func (e explodedArchiveEntry) CRC32() uint32 {
+	return 0
+}
+
 func (e explodedArchiveEntry) Read(start int64, end int64) ([]byte, error) {
 	if end < start {
 		return nil, errors.New("range not satisfiable")
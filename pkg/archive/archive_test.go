@@ -2,6 +2,7 @@ package archive
 
 import (
 	"bytes"
+	"hash/crc32"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -146,3 +147,29 @@ func TestArchiveEntrySize(t *testing.T) {
 		}
 	})
 }
+
+func TestArchiveEntryModTime(t *testing.T) {
+	withArchives(t, func(archive Archive) {
+		entry, err := archive.Entry("mimetype")
+		if assert.NoError(t, err) {
+			if assert.Implements(t, (*EntryValidators)(nil), entry) {
+				assert.False(t, entry.(EntryValidators).ModTime().IsZero())
+			}
+		}
+	})
+}
+
+func TestArchiveEntryCRC32(t *testing.T) {
+	archive, err := DefaultArchiveFactory{}.Open("./testdata/epub.epub", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer archive.Close()
+	entry, err := archive.Entry("EPUB/package.opf")
+	if assert.NoError(t, err) {
+		b, err := entry.Read(0, 0)
+		if assert.NoError(t, err) {
+			assert.Equal(t, crc32.ChecksumIEEE(b), entry.(EntryValidators).CRC32())
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"math"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -41,6 +42,14 @@ func (e gozipArchiveEntry) CompressedAs(compressionMethod CompressionMethod) boo
 	return e.file.Method == zip.Deflate
 }
 
+func (e gozipArchiveEntry) ModTime() time.Time {
+	return e.file.Modified
+}
+
+func (e gozipArchiveEntry) CRC32() uint32 {
+	return e.file.CRC32
+}
+
 // This is a special mode to minimize the number of reads from the underlying reader.
 // It's especially useful when trying to stream the ZIP from a remote file, e.g.
 // cloud storage. It's only enabled when trying to read the entire file and compression
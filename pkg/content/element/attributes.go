@@ -1,9 +1,12 @@
 package element
 
+import "github.com/readium/go-toolkit/pkg/manifest"
+
 type AttributeKey string
 
 const AcessibilityLabelAttributeKey AttributeKey = "accessibilityLabel"
 const LanguageAttributeKey AttributeKey = "language"
+const NoteRefAttributeKey AttributeKey = "noteRef" // Locator of the note referenced by a piece of text, e.g. a footnote call.
 
 // An attribute is an arbitrary key-value metadata pair.
 type Attribute[T any] struct {
@@ -45,6 +48,22 @@ func (ah AttributesHolder) AccessibilityLabel() string {
 	return ""
 }
 
+// Locator of the note referenced by the holder, if any.
+func (ah AttributesHolder) NoteRef() *manifest.Locator {
+	v := ah.GetFirst(NoteRefAttributeKey)
+	if v != nil {
+		if l, ok := v.Value.(manifest.Locator); ok {
+			return &l
+		}
+	}
+	return nil
+}
+
+// Returns a copy of the holder with additional [attributes].
+func (ah AttributesHolder) With(attributes ...Attribute[any]) AttributesHolder {
+	return NewAttributesHolder(append(ah.attributes[:len(ah.attributes):len(ah.attributes)], attributes...))
+}
+
 // Gets the first attribute with the given [key].
 func (ah AttributesHolder) GetFirst(key AttributeKey) *Attribute[any] {
 	for _, at := range ah.attributes {
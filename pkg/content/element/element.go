@@ -87,7 +87,7 @@ func (e AudioElement) MarshalJSON() ([]byte, error) {
 	res := ElementToMap(e)
 	res["text"] = e.Text()
 	res["link"] = e.EmbeddedLink()
-	res["@type"] = "Video"
+	res["@type"] = "Audio"
 	return json.Marshal(res)
 }
 
@@ -223,6 +223,17 @@ func (e TextElement) Role() TextRole {
 	return e.role
 }
 
+// Ranged portions of text composing the element.
+func (e TextElement) Segments() []TextSegment {
+	return e.segments
+}
+
+// Returns a copy of the element with different [segments], e.g. after splitting them into sentences.
+func (e TextElement) WithSegments(segments []TextSegment) TextElement {
+	e.segments = segments
+	return e
+}
+
 func (e TextElement) MarshalJSON() ([]byte, error) {
 	res := ElementToMap(e)
 	res["role"] = e.role.Role()
@@ -238,6 +249,9 @@ func (e TextElement) MarshalJSON() ([]byte, error) {
 		if l := s.AccessibilityLabel(); l != "" {
 			te["accessibilityLabel"] = l
 		}
+		if l := s.NoteRef(); l != nil {
+			te["noteRef"] = l
+		}
 		textElements[i] = te
 	}
 	res["text"] = textElements
@@ -255,3 +269,204 @@ func NewTextElement(locator manifest.Locator, role TextRole, segments []TextSegm
 		segments: segments,
 	}
 }
+
+// A mathematical formula, such as a MathML <math> element.
+// The markup is the source of the formula, while the alternative text is a human-readable description of it.
+type MathElement struct {
+	locator manifest.Locator
+	markup  string
+	altText string
+	AttributesHolder
+}
+
+// Implements Element
+func (e MathElement) Locator() manifest.Locator {
+	return e.locator
+}
+
+// Source of the formula, e.g. MathML.
+func (e MathElement) Markup() string {
+	return e.markup
+}
+
+// Implements TextualElement
+func (e MathElement) Text() string {
+	if e.altText != "" {
+		return e.altText
+	}
+	return e.AccessibilityLabel()
+}
+
+func (e MathElement) MarshalJSON() ([]byte, error) {
+	res := ElementToMap(e)
+	res["markup"] = e.markup
+	if t := e.Text(); t != "" {
+		res["text"] = t
+	}
+	res["@type"] = "Math"
+	return json.Marshal(res)
+}
+
+func NewMathElement(locator manifest.Locator, markup string, altText string, attributes []Attribute[any]) MathElement {
+	return MathElement{
+		AttributesHolder: AttributesHolder{
+			attributes: attributes,
+		},
+		locator: locator,
+		markup:  markup,
+		altText: altText,
+	}
+}
+
+// An inline SVG image.
+type SVGElement struct {
+	locator manifest.Locator
+	markup  string
+	AttributesHolder
+}
+
+// Implements Element
+func (e SVGElement) Locator() manifest.Locator {
+	return e.locator
+}
+
+// Source of the <svg> element.
+func (e SVGElement) Markup() string {
+	return e.markup
+}
+
+// Implements TextualElement
+func (e SVGElement) Text() string {
+	return e.AccessibilityLabel()
+}
+
+func (e SVGElement) MarshalJSON() ([]byte, error) {
+	res := ElementToMap(e)
+	res["markup"] = e.markup
+	if t := e.Text(); t != "" {
+		res["text"] = t
+	}
+	res["@type"] = "SVG"
+	return json.Marshal(res)
+}
+
+func NewSVGElement(locator manifest.Locator, markup string, attributes []Attribute[any]) SVGElement {
+	return SVGElement{
+		AttributesHolder: AttributesHolder{
+			attributes: attributes,
+		},
+		locator: locator,
+		markup:  markup,
+	}
+}
+
+// A cell of a [TableRow].
+type TableCell struct {
+	Text    string // Text content of the cell, with normalized whitespaces.
+	Header  bool   // Whether the cell is a header of its row or column.
+	ColSpan int    // Number of columns spanned by the cell, at least 1.
+	RowSpan int    // Number of rows spanned by the cell, at least 1.
+}
+
+func (c TableCell) MarshalJSON() ([]byte, error) {
+	res := map[string]interface{}{
+		"text": c.Text,
+	}
+	if c.Header {
+		res["header"] = true
+	}
+	if c.ColSpan > 1 {
+		res["colSpan"] = c.ColSpan
+	}
+	if c.RowSpan > 1 {
+		res["rowSpan"] = c.RowSpan
+	}
+	return json.Marshal(res)
+}
+
+// A row of a [TableElement].
+type TableRow struct {
+	Cells []TableCell `json:"cells"`
+}
+
+// A table of data, made of rows of cells.
+// The caption is a short piece of text associated with the table.
+type TableElement struct {
+	locator manifest.Locator
+	caption string
+	rows    []TableRow
+	AttributesHolder
+}
+
+// Implements Element
+func (e TableElement) Locator() manifest.Locator {
+	return e.locator
+}
+
+func (e TableElement) Caption() string {
+	return e.caption
+}
+
+// Rows of the table, in the order of the document, including the header and footer rows.
+func (e TableElement) Rows() []TableRow {
+	return e.rows
+}
+
+// Returns the text of the header cells applying to the cell at the given [row] and [column] indices,
+// from the header cells of the same column in the previous rows and of the same row in the previous
+// columns. Spanned cells are not taken into account.
+func (e TableElement) HeadersOf(row int, column int) []string {
+	var headers []string
+	for i := 0; i < row && i < len(e.rows); i++ {
+		if cells := e.rows[i].Cells; column < len(cells) && cells[column].Header && cells[column].Text != "" {
+			headers = append(headers, cells[column].Text)
+		}
+	}
+	if row < len(e.rows) {
+		for j, cell := range e.rows[row].Cells {
+			if j >= column {
+				break
+			}
+			if cell.Header && cell.Text != "" {
+				headers = append(headers, cell.Text)
+			}
+		}
+	}
+	return headers
+}
+
+// Implements TextualElement
+func (e TableElement) Text() string {
+	if e.caption != "" {
+		return e.caption
+	}
+	return e.AccessibilityLabel()
+}
+
+func (e TableElement) MarshalJSON() ([]byte, error) {
+	res := ElementToMap(e)
+	if e.caption != "" {
+		res["caption"] = e.caption
+	}
+	rows := e.rows
+	if rows == nil {
+		rows = []TableRow{}
+	}
+	res["rows"] = rows
+	if t := e.Text(); t != "" {
+		res["text"] = t
+	}
+	res["@type"] = "Table"
+	return json.Marshal(res)
+}
+
+func NewTableElement(locator manifest.Locator, caption string, rows []TableRow, attributes []Attribute[any]) TableElement {
+	return TableElement{
+		AttributesHolder: AttributesHolder{
+			attributes: attributes,
+		},
+		locator: locator,
+		caption: caption,
+		rows:    rows,
+	}
+}
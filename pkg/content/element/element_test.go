@@ -0,0 +1,127 @@
+package element
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLocator = manifest.Locator{Href: "chapter.xhtml", Type: "application/xhtml+xml"}
+
+func TestMathElementJSON(t *testing.T) {
+	b, err := json.Marshal(NewMathElement(testLocator, `<math alttext="x"><mi>x</mi></math>`, "x", []Attribute[any]{
+		NewAttribute(LanguageAttributeKey, "en"),
+	}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "Math",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"language": "en",
+		"markup": "<math alttext=\"x\"><mi>x</mi></math>",
+		"text": "x"
+	}`, string(b))
+
+	// The accessibility label is the fallback of the alternative text
+	el := NewMathElement(testLocator, "<math/>", "", []Attribute[any]{
+		NewAttribute(AcessibilityLabelAttributeKey, "formula"),
+	})
+	b, err = json.Marshal(el)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "Math",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"accessibilityLabel": "formula",
+		"markup": "<math/>",
+		"text": "formula"
+	}`, string(b))
+}
+
+func TestSVGElementJSON(t *testing.T) {
+	b, err := json.Marshal(NewSVGElement(testLocator, "<svg></svg>", nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "SVG",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"markup": "<svg></svg>"
+	}`, string(b))
+
+	b, err = json.Marshal(NewSVGElement(testLocator, "<svg></svg>", []Attribute[any]{
+		NewAttribute(AcessibilityLabelAttributeKey, "A red circle"),
+	}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "SVG",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"accessibilityLabel": "A red circle",
+		"markup": "<svg></svg>",
+		"text": "A red circle"
+	}`, string(b))
+}
+
+func TestTableElementJSON(t *testing.T) {
+	b, err := json.Marshal(NewTableElement(testLocator, "Planets", []TableRow{
+		{Cells: []TableCell{
+			{Text: "Name", Header: true, ColSpan: 1, RowSpan: 1},
+			{Text: "Moons", Header: true, ColSpan: 1, RowSpan: 1},
+		}},
+		{Cells: []TableCell{
+			{Text: "Unknown", ColSpan: 2, RowSpan: 3},
+		}},
+	}, nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "Table",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"caption": "Planets",
+		"text": "Planets",
+		"rows": [
+			{"cells": [{"text": "Name", "header": true}, {"text": "Moons", "header": true}]},
+			{"cells": [{"text": "Unknown", "colSpan": 2, "rowSpan": 3}]}
+		]
+	}`, string(b))
+
+	b, err = json.Marshal(NewTableElement(testLocator, "", nil, nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "Table",
+		"locator": {"href": "chapter.xhtml", "type": "application/xhtml+xml"},
+		"rows": []
+	}`, string(b))
+}
+
+func TestTableElementHeadersOf(t *testing.T) {
+	table := NewTableElement(testLocator, "", []TableRow{
+		{Cells: []TableCell{{Text: "", Header: true}, {Text: "Moons", Header: true}}},
+		{Cells: []TableCell{{Text: "Earth", Header: true}, {Text: "1"}}},
+	}, nil)
+	assert.Equal(t, []string{"Moons", "Earth"}, table.HeadersOf(1, 1))
+	assert.Equal(t, []string{"Moons"}, table.HeadersOf(0, 5))
+	assert.Empty(t, table.HeadersOf(0, 0))
+}
+
+func TestEmbeddedElementsJSON(t *testing.T) {
+	locator := manifest.Locator{Href: "track.mp3", Type: "audio/mpeg"}
+	link := manifest.Link{Href: "/track.mp3", Type: "audio/mpeg"}
+	attributes := []Attribute[any]{NewAttribute(AcessibilityLabelAttributeKey, "Chapter 1")}
+
+	b, err := json.Marshal(NewAudioElement(locator, link, attributes))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@type": "Audio",
+		"locator": {"href": "track.mp3", "type": "audio/mpeg"},
+		"accessibilityLabel": "Chapter 1",
+		"link": {"href": "track.mp3", "type": "audio/mpeg"},
+		"text": "Chapter 1"
+	}`, string(b))
+
+	b, err = json.Marshal(NewVideoElement(locator, link, attributes))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"@type":"Video"`)
+
+	b, err = json.Marshal(NewImageElement(locator, link, "", attributes))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"@type":"Image"`)
+}
@@ -33,6 +33,36 @@ func (f Footnote) Role() string {
 	return "footnote"
 }
 
+// A note at the end of a section or publication.
+type Endnote struct{}
+
+func (e Endnote) Role() string {
+	return "endnote"
+}
+
+// A note which is neither a footnote nor an endnote, e.g. a marginal note.
+type Note struct{}
+
+func (n Note) Role() string {
+	return "note"
+}
+
+// Secondary content which is not part of the main flow, such as a sidebar.
+type Aside struct{}
+
+func (a Aside) Role() string {
+	return "aside"
+}
+
+// Returns whether the [role] is a footnote, an endnote or another kind of note.
+func IsNote(role TextRole) bool {
+	switch role.(type) {
+	case Footnote, Endnote, Note:
+		return true
+	}
+	return false
+}
+
 type Quote struct {
 	ReferenceURL   *url.URL // URL to the source for this quote.
 	ReferenceTitle string   // Name of the source for this quote.
@@ -0,0 +1,123 @@
+package iterator
+
+import (
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/internal/extensions"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Iterates a resource which is entirely made of a single [element.Element], such as a bitmap page of a comic
+// or an audio track of an audiobook.
+type SingleElementIterator struct {
+	element element.Element
+	index   int // 0 before the element, 1 after it
+}
+
+// Iterates over the single [element]. If the [locator] has a `progression` of 1.0, the iteration starts
+// from the end of the resource.
+func NewSingleElement(el element.Element, locator manifest.Locator) *SingleElementIterator {
+	it := &SingleElementIterator{element: el}
+	if p := locator.Locations.Progression; p != nil && *p >= 1 {
+		it.index = 1
+	}
+	return it
+}
+
+func (it *SingleElementIterator) HasPrevious() (bool, error) {
+	return it.index == 1, nil
+}
+
+func (it *SingleElementIterator) Previous() element.Element {
+	if it.index != 1 {
+		panic("Previous() in SingleElementIterator called without a previous call to HasPrevious()")
+	}
+	it.index = 0
+	return it.element
+}
+
+func (it *SingleElementIterator) HasNext() (bool, error) {
+	return it.index == 0, nil
+}
+
+func (it *SingleElementIterator) Next() element.Element {
+	if it.index != 0 {
+		panic("Next() in SingleElementIterator called without a previous call to HasNext()")
+	}
+	it.index = 1
+	return it.element
+}
+
+// Locator of the whole [link], used for the element of a single element resource.
+func embeddedLocator(link manifest.Link) manifest.Locator {
+	locator := manifest.Locator{
+		Href:  link.Href,
+		Type:  link.Type,
+		Title: link.Title,
+		Locations: manifest.Locations{
+			Progression: extensions.Pointer(0.0),
+		},
+	}
+	if locator.Type == "" {
+		locator.Type = link.MediaType().String()
+	}
+	return locator
+}
+
+// The title of a link, such as the title of an ACBF page, is the best description available for the resource.
+func embeddedAttributes(link manifest.Link) []element.Attribute[any] {
+	var attributes []element.Attribute[any]
+	if link.Title != "" {
+		attributes = append(attributes, element.NewAttribute(element.AcessibilityLabelAttributeKey, link.Title))
+	}
+	if languages := link.Languages; len(languages) > 0 {
+		attributes = append(attributes, element.NewAttribute(element.LanguageAttributeKey, languages[0]))
+	}
+	return attributes
+}
+
+// Creates an iterator emitting a single [element.ImageElement] for bitmap resources.
+func ImageFactory() ResourceContentIteratorFactory {
+	return func(resource fetcher.Resource, locator manifest.Locator) Iterator {
+		link := resource.Link()
+		if !link.MediaType().IsBitmap() {
+			return nil
+		}
+		return NewSingleElement(element.NewImageElement(
+			embeddedLocator(link), link, "", embeddedAttributes(link),
+		), locator)
+	}
+}
+
+// Creates an iterator emitting a single [element.AudioElement] for audio resources.
+// The captions of the track are not read: its text is the title of its link.
+func AudioFactory() ResourceContentIteratorFactory {
+	return func(resource fetcher.Resource, locator manifest.Locator) Iterator {
+		link := resource.Link()
+		if !link.MediaType().IsAudio() {
+			return nil
+		}
+		return NewSingleElement(element.NewAudioElement(
+			embeddedLocator(link), link, embeddedAttributes(link),
+		), locator)
+	}
+}
+
+// Creates an iterator emitting a single [element.VideoElement] for video resources.
+// The captions of the video are not read: its text is the title of its link.
+func VideoFactory() ResourceContentIteratorFactory {
+	return func(resource fetcher.Resource, locator manifest.Locator) Iterator {
+		link := resource.Link()
+		if !link.MediaType().IsVideo() {
+			return nil
+		}
+		return NewSingleElement(element.NewVideoElement(
+			embeddedLocator(link), link, embeddedAttributes(link),
+		), locator)
+	}
+}
+
+// Factories of all the resources which are made of a single embedded element.
+func EmbeddedFactories() []ResourceContentIteratorFactory {
+	return []ResourceContentIteratorFactory{ImageFactory(), AudioFactory(), VideoFactory()}
+}
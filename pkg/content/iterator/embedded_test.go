@@ -0,0 +1,158 @@
+package iterator
+
+import (
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/internal/extensions"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleElementIterator(t *testing.T) {
+	el := element.NewImageElement(manifest.Locator{Href: "page1.jpg", Type: "image/jpeg"}, manifest.Link{Href: "page1.jpg"}, "", nil)
+	it := NewSingleElement(el, manifest.Locator{Href: "page1.jpg"})
+
+	hasPrevious, err := it.HasPrevious()
+	require.NoError(t, err)
+	assert.False(t, hasPrevious)
+	assert.Panics(t, func() { it.Previous() })
+
+	hasNext, err := it.HasNext()
+	require.NoError(t, err)
+	assert.True(t, hasNext)
+	assert.Equal(t, el, it.Next())
+
+	hasNext, err = it.HasNext()
+	require.NoError(t, err)
+	assert.False(t, hasNext)
+	assert.Panics(t, func() { it.Next() })
+
+	// Back to the beginning
+	hasPrevious, err = it.HasPrevious()
+	require.NoError(t, err)
+	assert.True(t, hasPrevious)
+	assert.Equal(t, el, it.Previous())
+	hasPrevious, _ = it.HasPrevious()
+	assert.False(t, hasPrevious)
+	hasNext, _ = it.HasNext()
+	assert.True(t, hasNext)
+}
+
+func TestSingleElementIteratorSeeksFromLocator(t *testing.T) {
+	el := element.NewImageElement(manifest.Locator{Href: "page1.jpg", Type: "image/jpeg"}, manifest.Link{Href: "page1.jpg"}, "", nil)
+	progression := func(p float64) manifest.Locator {
+		return manifest.Locator{Href: "page1.jpg", Locations: manifest.Locations{Progression: extensions.Pointer(p)}}
+	}
+
+	// Only the end of the resource is after the element
+	for _, p := range []float64{0, 0.5, 0.99} {
+		it := NewSingleElement(el, progression(p))
+		hasNext, _ := it.HasNext()
+		assert.True(t, hasNext, p)
+		hasPrevious, _ := it.HasPrevious()
+		assert.False(t, hasPrevious, p)
+	}
+
+	it := NewSingleElement(el, progression(1))
+	hasNext, _ := it.HasNext()
+	assert.False(t, hasNext)
+	el2, err := ItPreviousOrNil(it)
+	require.NoError(t, err)
+	assert.Equal(t, el, el2)
+	el2, err = ItPreviousOrNil(it)
+	require.NoError(t, err)
+	assert.Nil(t, el2)
+}
+
+func embeddedTestResource(href string, mediaType string) fetcher.Resource {
+	return fetcher.NewBytesResource(manifest.Link{
+		Href:      href,
+		Type:      mediaType,
+		Title:     "Title of " + href,
+		Languages: []string{"fr", "en"},
+	}, func() []byte { return nil })
+}
+
+func TestEmbeddedFactories(t *testing.T) {
+	tests := []struct {
+		factory   ResourceContentIteratorFactory
+		href      string
+		mediaType string
+	}{
+		{ImageFactory(), "/page1.jpg", "image/jpeg"},
+		{AudioFactory(), "/track1.mp3", "audio/mpeg"},
+		{VideoFactory(), "/clip1.mp4", "video/mp4"},
+	}
+	for _, tt := range tests {
+		it := tt.factory(embeddedTestResource(tt.href, tt.mediaType), manifest.Locator{Href: tt.href})
+		require.NotNil(t, it, tt.href)
+		el, err := ItNextOrNil(it)
+		require.NoError(t, err, tt.href)
+		require.NotNil(t, el, tt.href)
+
+		switch tt.mediaType {
+		case "image/jpeg":
+			assert.IsType(t, element.ImageElement{}, el)
+		case "audio/mpeg":
+			assert.IsType(t, element.AudioElement{}, el)
+		case "video/mp4":
+			assert.IsType(t, element.VideoElement{}, el)
+		}
+		embedded := el.(element.EmbeddedElement)
+		assert.Equal(t, manifest.Locator{
+			Href:      tt.href,
+			Type:      tt.mediaType,
+			Title:     "Title of " + tt.href,
+			Locations: manifest.Locations{Progression: extensions.Pointer(0.0)},
+		}, embedded.Locator(), tt.href)
+		assert.Equal(t, tt.href[1:], embedded.EmbeddedLink().Href, tt.href)
+		assert.Equal(t, "Title of "+tt.href, embedded.AccessibilityLabel(), tt.href)
+		assert.Equal(t, "Title of "+tt.href, el.(element.TextualElement).Text(), tt.href)
+		assert.Equal(t, "fr", embedded.Language(), tt.href)
+
+		el, err = ItNextOrNil(it)
+		require.NoError(t, err, tt.href)
+		assert.Nil(t, el, tt.href)
+
+		// Other resources are left to the other factories
+		for _, other := range tests {
+			if other.href != tt.href {
+				assert.Nil(t, tt.factory(embeddedTestResource(other.href, other.mediaType), manifest.Locator{}), tt.href)
+			}
+		}
+		assert.Nil(t, tt.factory(embeddedTestResource("/chapter.xhtml", "application/xhtml+xml"), manifest.Locator{}), tt.href)
+	}
+}
+
+func TestEmbeddedFactoriesWithoutTitle(t *testing.T) {
+	resource := fetcher.NewBytesResource(manifest.Link{Href: "/page1.png", Type: "image/png"}, func() []byte { return nil })
+	it := ImageFactory()(resource, manifest.Locator{})
+	require.NotNil(t, it)
+	el, err := ItNextOrNil(it)
+	require.NoError(t, err)
+	assert.Empty(t, el.Locator().Title)
+	assert.Empty(t, el.(element.ImageElement).Text())
+	assert.Empty(t, el.(element.ImageElement).Attributes())
+}
+
+func TestEmbeddedFactoriesSeekToTheEnd(t *testing.T) {
+	for _, factory := range EmbeddedFactories() {
+		for _, r := range []fetcher.Resource{
+			embeddedTestResource("/page1.jpg", "image/jpeg"),
+			embeddedTestResource("/track1.mp3", "audio/mpeg"),
+			embeddedTestResource("/clip1.mp4", "video/mp4"),
+		} {
+			it := factory(r, manifest.Locator{Locations: manifest.Locations{Progression: extensions.Pointer(1.0)}})
+			if it == nil {
+				continue
+			}
+			hasNext, _ := it.HasNext()
+			assert.False(t, hasNext)
+			hasPrevious, _ := it.HasPrevious()
+			assert.True(t, hasPrevious)
+		}
+	}
+}
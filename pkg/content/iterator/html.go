@@ -9,6 +9,7 @@ import (
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/go-toolkit/pkg/util/langdetect"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
@@ -16,7 +17,13 @@ import (
 type HTMLContentIterator struct {
 	resource        fetcher.Resource
 	locator         manifest.Locator
-	BeforeMaxLength int // Locators will contain a `before` context of up to this amount of characters.
+	BeforeMaxLength int  // Locators will contain a `before` context of up to this amount of characters.
+	SkipNotes       bool // Footnotes and endnotes are not emitted, unless the iteration starts inside one of them.
+
+	// Detects the language of the text segments without a lang or xml:lang attribute.
+	// The language is set only if its confidence is at least [LanguageThreshold], or [langdetect.DefaultThreshold] if zero.
+	DetectLanguage    bool
+	LanguageThreshold float64
 
 	currentElement *ElementWithDelta
 	currentIndex   *int
@@ -34,10 +41,33 @@ func NewHTML(resource fetcher.Resource, locator manifest.Locator) *HTMLContentIt
 	}
 }
 
+// Creates an [HTMLContentIterator] for HTML and XHTML resources, with the default options.
 func HTMLFactory() ResourceContentIteratorFactory {
+	return HTMLFactoryWithOptions(HTMLOptions{})
+}
+
+// Options of the [HTMLContentIterator] created by [HTMLFactoryWithOptions].
+type HTMLOptions struct {
+	// Skips the footnotes and endnotes, which are usually not read aloud in the flow of the text.
+	// The note references still point to them with the noteRef attribute of their segment.
+	SkipNotes bool
+
+	// Detects the language of the text which doesn't declare one with a lang or xml:lang attribute, e.g. to select
+	// a TTS voice. The detected language is used only if its confidence is at least [LanguageThreshold], which
+	// defaults to [langdetect.DefaultThreshold].
+	DetectLanguage    bool
+	LanguageThreshold float64
+}
+
+// Creates an [HTMLContentIterator] for HTML and XHTML resources, with the given [options].
+func HTMLFactoryWithOptions(options HTMLOptions) ResourceContentIteratorFactory {
 	return func(resource fetcher.Resource, locator manifest.Locator) Iterator {
 		if resource.Link().MediaType().Matches(&mediatype.HTML, &mediatype.XHTML) {
-			return NewHTML(resource, locator)
+			it := NewHTML(resource, locator)
+			it.SkipNotes = options.SkipNotes
+			it.DetectLanguage = options.DetectLanguage
+			it.LanguageThreshold = options.LanguageThreshold
+			return it
 		}
 		return nil
 	}
@@ -148,6 +178,7 @@ func (it *HTMLContentIterator) parseElements() (*ParsedElements, error) {
 	contentConverter := HTMLConverter{
 		baseLocator:     it.locator,
 		beforeMaxLength: it.BeforeMaxLength,
+		skipNotes:       it.SkipNotes,
 	}
 	if sel := it.locator.Locations.CSSSelector(); sel != "" {
 		c, err := cascadia.Parse(sel)
@@ -163,5 +194,12 @@ func (it *HTMLContentIterator) parseElements() (*ParsedElements, error) {
 	TraverseNode(&contentConverter, body)
 
 	res := contentConverter.Result()
+	if it.DetectLanguage {
+		threshold := it.LanguageThreshold
+		if threshold == 0 {
+			threshold = langdetect.DefaultThreshold
+		}
+		detectLanguages(res.Elements, threshold)
+	}
 	return &res, nil
 }
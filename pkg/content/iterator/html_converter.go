@@ -1,6 +1,7 @@
 package iterator
 
 import (
+	"math"
 	"net/url"
 	"strings"
 	"unicode"
@@ -209,6 +210,13 @@ func nodeLanguage(n *html.Node) *string {
 	return nil
 }
 
+func sameLanguage(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // From JSoup: https://github.com/jhy/jsoup/blob/1762412a28fa7b08ccf71d93fc4c98dc73086e03/src/main/java/org/jsoup/internal/StringUtil.java#L233
 // Slight differing definition of what a whitespace characacter is
 func appendNormalizedWhitespace(accum *strings.Builder, text string, stripLeading bool) {
@@ -279,9 +287,11 @@ type HTMLConverter struct {
 	baseLocator     manifest.Locator
 	startElement    *html.Node
 	beforeMaxLength int
+	skipNotes       bool // Whether the notes are skipped, unless they contain the [startElement].
 
 	elements   []element.Element
 	startIndex int
+	startFound bool // Whether the [startIndex] was computed from the [startElement].
 
 	segmentsAcc       []element.TextSegment // Segments accumulated for the current element.
 	textAcc           strings.Builder       // Text since the beginning of the current segment, after coalescing whitespaces.
@@ -291,15 +301,22 @@ type HTMLConverter struct {
 	currentLanguage   *string               // Language of the current segment.
 
 	breadcrumbs []breadcrumbData // LIFO stack of the current element's block ancestors.
+	skipped     *html.Node       // Element whose descendants are not traversed, because it was converted as a whole.
+
+	noteRef       *html.Node        // Current link to a note, whose text is kept in its own segment.
+	noteRefTarget *manifest.Locator // Locator of the note targeted by [noteRef].
 }
 
 func (c *HTMLConverter) Result() ParsedElements {
 	p := ParsedElements{
 		Elements: c.elements,
 	}
-	one := 1.0
-	if c.baseLocator.Locations.Progression == &one {
+	progression := c.baseLocator.Locations.Progression
+	if progression != nil && *progression >= 1 {
 		p.StartIndex = len(c.elements)
+	} else if c.startElement == nil && progression != nil && *progression > 0 {
+		// Without a matching element, the progression is the best approximation of the starting point
+		p.StartIndex = int(math.Round(*progression * float64(len(c.elements))))
 	} else {
 		p.StartIndex = c.startIndex
 	}
@@ -308,7 +325,33 @@ func (c *HTMLConverter) Result() ParsedElements {
 
 // Implements NodeTraversor
 func (c *HTMLConverter) Head(n *html.Node, depth int) {
+	if c.skipped != nil {
+		return
+	}
 	if n.Type == html.ElementNode {
+		if c.skipNotes && isNote(n) && !containsNode(n, c.startElement) {
+			c.flushText()
+			c.skipped = n
+			return
+		}
+		if el, inline, skip := c.structuredElement(n); skip {
+			if el != nil {
+				c.flushText()
+			}
+			if !c.startFound && c.startElement == n {
+				// An inline element is part of the current text element, appended next
+				c.startIndex = len(c.elements)
+				c.startFound = true
+			}
+			if el != nil {
+				c.elements = append(c.elements, el)
+			} else if inline != "" {
+				c.appendInlineText(n, inline)
+			}
+			c.skipped = n
+			return
+		}
+
 		isBlock := !isInlineTag(n)
 		var cssSelector *string
 		if isBlock {
@@ -326,26 +369,27 @@ func (c *HTMLConverter) Head(n *html.Node, depth int) {
 			})
 		}
 
+		if c.noteRef == nil && isNoteRef(n) {
+			c.flushSegment()
+			c.noteRef = n
+			c.noteRefTarget = noteRefTarget(n, c.baseLocator)
+		}
+
 		if n.DataAtom == atom.Br {
 			c.flushText()
 		} else if n.DataAtom == atom.Img || n.DataAtom == atom.Audio || n.DataAtom == atom.Video {
 			c.flushText()
+			if !c.startFound && c.startElement == n {
+				// Embedded elements are not block breadcrumbs, but can be targeted by a locator too
+				c.startIndex = len(c.elements)
+				c.startFound = true
+			}
 
 			if cssSelector == nil {
 				cs := iutil.CSSSelector(n)
 				cssSelector = &cs
 			}
-			elementLocator := manifest.Locator{
-				Href:  c.baseLocator.Href,
-				Type:  c.baseLocator.Type,
-				Title: c.baseLocator.Title,
-				Text:  c.baseLocator.Text,
-				Locations: manifest.Locations{
-					OtherLocations: map[string]interface{}{
-						"cssSelector": cssSelector,
-					},
-				},
-			}
+			elementLocator := c.elementLocator(*cssSelector)
 
 			if n.DataAtom == atom.Img {
 				if href := srcRelativeToHref(n, c.baseLocator.Href); href != nil {
@@ -376,7 +420,7 @@ func (c *HTMLConverter) Head(n *html.Node, depth int) {
 					}
 				} else {
 					sourceNodes := childrenOfType(n, atom.Source, 1)
-					sources := make([]manifest.Link, len(sourceNodes))
+					sources := make([]manifest.Link, 0, len(sourceNodes))
 					for _, source := range sourceNodes {
 						if src := srcRelativeToHref(source, c.baseLocator.Href); src != nil {
 							l := manifest.Link{
@@ -422,9 +466,15 @@ func (c *HTMLConverter) Head(n *html.Node, depth int) {
 
 // Implements NodeTraversor
 func (c *HTMLConverter) Tail(n *html.Node, depth int) {
+	if c.skipped != nil {
+		if c.skipped == n {
+			c.skipped = nil
+		}
+		return
+	}
 	if n.Type == html.TextNode && !onlySpace(n.Data) {
 		language := nodeLanguage(n)
-		if c.currentLanguage != language {
+		if !sameLanguage(c.currentLanguage, language) {
 			c.flushSegment()
 			c.currentLanguage = language
 		}
@@ -437,6 +487,11 @@ func (c *HTMLConverter) Tail(n *html.Node, depth int) {
 		}
 		appendNormalizedWhitespace(&c.textAcc, n.Data, stripLeading)
 	} else if n.Type == html.ElementNode {
+		if n == c.noteRef {
+			c.flushSegment()
+			c.noteRef = nil
+			c.noteRefTarget = nil
+		}
 		if !isInlineTag(n) { // Is block
 			if len(c.breadcrumbs) > 0 && c.breadcrumbs[len(c.breadcrumbs)-1].node != n {
 				// TODO, should we panic? Kotlin does assert(breadcrumbs.last() == node) which throws
@@ -448,14 +503,30 @@ func (c *HTMLConverter) Tail(n *html.Node, depth int) {
 	}
 }
 
+// Locator of an element of the resource targeted by the given [cssSelector].
+func (c *HTMLConverter) elementLocator(cssSelector string) manifest.Locator {
+	return manifest.Locator{
+		Href:  c.baseLocator.Href,
+		Type:  c.baseLocator.Type,
+		Title: c.baseLocator.Title,
+		Text:  c.baseLocator.Text,
+		Locations: manifest.Locations{
+			OtherLocations: map[string]interface{}{
+				"cssSelector": cssSelector,
+			},
+		},
+	}
+}
+
 func (c *HTMLConverter) flushText() {
 	c.flushSegment()
 
-	if c.startIndex == 0 && c.startElement != nil &&
+	if !c.startFound && c.startElement != nil &&
 		((len(c.breadcrumbs) == 0 && c.startElement == nil) || // TODO is this right??
 			(c.startElement != nil && len(c.breadcrumbs) > 0 &&
 				c.breadcrumbs[len(c.breadcrumbs)-1].node == c.startElement)) {
 		c.startIndex = len(c.elements)
+		c.startFound = true
 	}
 
 	if len(c.segmentsAcc) == 0 {
@@ -469,39 +540,39 @@ func (c *HTMLConverter) flushText() {
 	var bestRole element.TextRole = element.Body{}
 	if len(c.breadcrumbs) > 0 {
 		el := c.breadcrumbs[len(c.breadcrumbs)-1].node
-		for _, at := range el.Attr {
-			if at.Namespace == "http://www.idpf.org/2007/ops" && at.Key == "type" && at.Val == "footnote" {
-				bestRole = element.Footnote{}
-				break
+		switch el.DataAtom {
+		case atom.H1:
+			bestRole = element.Heading{Level: 1}
+		case atom.H2:
+			bestRole = element.Heading{Level: 2}
+		case atom.H3:
+			bestRole = element.Heading{Level: 3}
+		case atom.H4:
+			bestRole = element.Heading{Level: 4}
+		case atom.H5:
+			bestRole = element.Heading{Level: 5}
+		case atom.H6:
+			bestRole = element.Heading{Level: 6}
+		case atom.Blockquote:
+			fallthrough
+		case atom.Q:
+			quote := element.Quote{}
+			for _, at := range el.Attr {
+				if at.Key == "cite" {
+					quote.ReferenceURL, _ = url.Parse(at.Val)
+				}
+				if at.Key == "title" {
+					quote.ReferenceTitle = at.Val
+				}
 			}
+			bestRole = quote
 		}
-		if bestRole.Role() == "body" { // Still a body
-			switch el.DataAtom {
-			case atom.H1:
-				bestRole = element.Heading{Level: 1}
-			case atom.H2:
-				bestRole = element.Heading{Level: 2}
-			case atom.H3:
-				bestRole = element.Heading{Level: 3}
-			case atom.H4:
-				bestRole = element.Heading{Level: 4}
-			case atom.H5:
-				bestRole = element.Heading{Level: 5}
-			case atom.H6:
-				bestRole = element.Heading{Level: 6}
-			case atom.Blockquote:
-				fallthrough
-			case atom.Q:
-				quote := element.Quote{}
-				for _, at := range el.Attr {
-					if at.Key == "cite" {
-						quote.ReferenceURL, _ = url.Parse(at.Val)
-					}
-					if at.Key == "title" {
-						quote.ReferenceTitle = at.Val
-					}
+		if bestRole.Role() == "body" { // Still a body, maybe inside a note or an aside
+			for i := len(c.breadcrumbs) - 1; i >= 0; i-- {
+				if role := semanticRole(c.breadcrumbs[i].node); role != nil {
+					bestRole = role
+					break
 				}
-				bestRole = quote
 			}
 		}
 	}
@@ -579,10 +650,15 @@ func (c *HTMLConverter) flushSegment() {
 				seg.Locator.Locations.OtherLocations["cssSelector"] = lastCrumb.cssSelector
 			}
 		}
+		var attributes []element.Attribute[any]
 		if c.currentLanguage != nil {
-			seg.AttributesHolder = element.NewAttributesHolder([]element.Attribute[any]{
-				element.NewAttribute(element.LanguageAttributeKey, c.currentLanguage),
-			})
+			attributes = append(attributes, element.NewAttribute(element.LanguageAttributeKey, *c.currentLanguage))
+		}
+		if c.noteRefTarget != nil {
+			attributes = append(attributes, element.NewAttribute(element.NoteRefAttributeKey, *c.noteRefTarget))
+		}
+		if len(attributes) > 0 {
+			seg.AttributesHolder = element.NewAttributesHolder(attributes)
 		}
 		c.segmentsAcc = append(c.segmentsAcc, seg)
 	}
@@ -0,0 +1,247 @@
+package iterator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Converts the body of the HTML [source] with the given [converter].
+func convertHTML(t *testing.T, converter HTMLConverter, source string) []element.Element {
+	document, err := html.Parse(strings.NewReader(source))
+	require.NoError(t, err)
+	body := childOfType(document, atom.Body, true)
+	require.NotNil(t, body)
+
+	converter.baseLocator = manifest.Locator{Href: "/OEBPS/chapter.xhtml", Type: "application/xhtml+xml"}
+	TraverseNode(&converter, body)
+	return converter.Result().Elements
+}
+
+func segmentTexts(el element.Element) []string {
+	var texts []string
+	for _, segment := range el.(element.TextElement).Segments() {
+		texts = append(texts, segment.Text)
+	}
+	return texts
+}
+
+func TestHTMLConverterBlockMath(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p>Consider the equation:</p>
+		<p><math alttext="x squared"><msup><mi>x</mi><mn>2</mn></msup></math></p>
+		<div>Then <math display="block" alttext="y"><mi>y</mi></math> follows.</div>
+	</body>`)
+	require.Len(t, elements, 5)
+
+	math, ok := elements[1].(element.MathElement)
+	require.True(t, ok)
+	assert.Equal(t, "x squared", math.Text())
+	assert.Equal(t, `<math alttext="x squared"><msup><mi>x</mi><mn>2</mn></msup></math>`, math.Markup())
+	assert.Equal(t, "body > p:nth-child(2) > math", math.Locator().Locations.CSSSelector())
+
+	// A formula displayed as a block splits the paragraph
+	assert.Equal(t, []string{"Then"}, segmentTexts(elements[2]))
+	assert.IsType(t, element.MathElement{}, elements[3])
+	assert.Equal(t, []string{"follows."}, segmentTexts(elements[4]))
+}
+
+func TestHTMLConverterInlineMath(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p>The area is <math alttext="pi r squared"><mi>π</mi><msup><mi>r</mi><mn>2</mn></msup></math> square meters.</p>
+		<p>With <math aria-label="x"><mi>x</mi></math> and <m:math xmlns:m="http://www.w3.org/1998/Math/MathML"><m:mi>y</m:mi></m:math>.</p>
+	</body>`)
+	require.Len(t, elements, 2)
+
+	assert.Equal(t, []string{"The area is ", "pi r squared", " square meters."}, segmentTexts(elements[0]))
+	assert.Equal(t, []string{"With ", "x", " and ", "y", "."}, segmentTexts(elements[1]))
+}
+
+func TestHTMLConverterPrefixedMath(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p><m:math xmlns:m="http://www.w3.org/1998/Math/MathML" alttext="y"><m:mi>y</m:mi></m:math></p>
+	</body>`)
+	require.Len(t, elements, 1)
+	math, ok := elements[0].(element.MathElement)
+	require.True(t, ok)
+	assert.Equal(t, "y", math.Text())
+}
+
+func TestHTMLConverterSVG(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<figure><svg><title>A red circle</title><circle r="10" fill="red"/></svg><figcaption>Figure 1</figcaption></figure>
+		<p>A <svg aria-label="star"><path d="M0 0"/></svg> marks the important sections.</p>
+		<p>Decorative <svg aria-hidden="true"><path d="M0 0"/></svg> ornament.</p>
+		<div><svg role="presentation"><path d="M0 0"/></svg></div>
+	</body>`)
+	require.Len(t, elements, 4)
+
+	svg, ok := elements[0].(element.SVGElement)
+	require.True(t, ok)
+	assert.Equal(t, "A red circle", svg.Text())
+	assert.True(t, strings.HasPrefix(svg.Markup(), "<svg><title>A red circle</title>"))
+
+	assert.Equal(t, []string{"Figure 1"}, segmentTexts(elements[1]))
+	assert.Equal(t, []string{"A ", "star", " marks the important sections."}, segmentTexts(elements[2]))
+	assert.Equal(t, []string{"Decorative ornament."}, segmentTexts(elements[3]))
+}
+
+func TestHTMLConverterTable(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<table lang="en">
+			<caption>Planets</caption>
+			<thead><tr><th>Name</th><th>Moons</th></tr></thead>
+			<tbody>
+				<tr><th>Earth</th><td>1</td></tr>
+				<tr><th>Mars</th><td>2</td></tr>
+				<tr><td colspan="2">Source: <em>NASA</em></td></tr>
+			</tbody>
+		</table>
+		<table role="presentation"><tr><td>Layout</td></tr></table>
+	</body>`)
+	require.Len(t, elements, 2)
+
+	table, ok := elements[0].(element.TableElement)
+	require.True(t, ok)
+	assert.Equal(t, "Planets", table.Caption())
+	assert.Equal(t, "Planets", table.Text())
+	assert.Equal(t, "en", table.Language())
+	require.Len(t, table.Rows(), 4)
+	assert.Equal(t, element.TableCell{Text: "Moons", Header: true, ColSpan: 1, RowSpan: 1}, table.Rows()[0].Cells[1])
+	assert.Equal(t, element.TableCell{Text: "Source: NASA", ColSpan: 2, RowSpan: 1}, table.Rows()[3].Cells[0])
+	assert.Equal(t, []string{"Moons", "Mars"}, table.HeadersOf(2, 1))
+
+	// Layout tables are read as regular text
+	assert.Equal(t, []string{"Layout"}, segmentTexts(elements[1]))
+}
+
+func TestHTMLConverterStartsAtInlineElement(t *testing.T) {
+	document, err := html.Parse(strings.NewReader(`<body><p>First</p><p>Then <math alttext="x"><mi>x</mi></math>.</p></body>`))
+	require.NoError(t, err)
+	body := childOfType(document, atom.Body, true)
+	math := childOfType(body, atom.Math, true)
+	require.NotNil(t, math)
+
+	converter := HTMLConverter{startElement: math}
+	TraverseNode(&converter, body)
+	res := converter.Result()
+	require.Len(t, res.Elements, 2)
+	assert.Equal(t, 1, res.StartIndex)
+}
+
+func TestHTMLConverterNoteRefs(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p>A claim<a epub:type="noteref" href="#n1">1</a> and another<a role="doc-noteref" href="notes.xhtml#n2">2</a>, with <a href="#n1">a link</a>.</p>
+		<aside epub:type="footnote" id="n1"><p>The first note.</p></aside>
+	</body>`)
+	require.Len(t, elements, 2)
+
+	segments := elements[0].(element.TextElement).Segments()
+	require.Equal(t, []string{"A claim", "1", " and another", "2", ", with a link."}, segmentTexts(elements[0]))
+	assert.Nil(t, segments[0].NoteRef())
+
+	ref := segments[1].NoteRef()
+	require.NotNil(t, ref)
+	assert.Equal(t, "/OEBPS/chapter.xhtml", ref.Href)
+	assert.Equal(t, []string{"n1"}, ref.Locations.Fragments)
+	assert.Equal(t, "#n1", ref.Locations.CSSSelector())
+
+	ref = segments[3].NoteRef()
+	require.NotNil(t, ref)
+	assert.Equal(t, "/OEBPS/notes.xhtml", ref.Href)
+	assert.Equal(t, []string{"n2"}, ref.Locations.Fragments)
+
+	// Regular links are part of the text
+	assert.Nil(t, segments[4].NoteRef())
+
+	assert.Equal(t, element.Footnote{}, elements[1].(element.TextElement).Role())
+}
+
+func TestHTMLConverterSkipNotes(t *testing.T) {
+	source := `<body>
+		<p>A claim<a epub:type="noteref" href="#n1">1</a>.</p>
+		<aside epub:type="footnote" id="n1"><p>The first note.</p></aside>
+		<section role="doc-endnotes"><ol><li role="doc-endnote" id="n2">An endnote.</li></ol></section>
+		<aside><p>A sidebar.</p></aside>
+		<p>The end.</p>
+	</body>`
+
+	elements := convertHTML(t, HTMLConverter{}, source)
+	require.Len(t, elements, 5)
+
+	elements = convertHTML(t, HTMLConverter{skipNotes: true}, source)
+	require.Len(t, elements, 3)
+	assert.Equal(t, []string{"A claim", "1", "."}, segmentTexts(elements[0]))
+	assert.Equal(t, element.Aside{}, elements[1].(element.TextElement).Role())
+	assert.Equal(t, []string{"The end."}, segmentTexts(elements[2]))
+}
+
+func TestHTMLConverterSkipNotesKeepsTheStartingNote(t *testing.T) {
+	document, err := html.Parse(strings.NewReader(`<body>
+		<p>A claim.</p>
+		<aside epub:type="footnote" id="n1"><p>The first note.</p></aside>
+		<aside epub:type="footnote" id="n2"><p>The second note.</p></aside>
+	</body>`))
+	require.NoError(t, err)
+	body := childOfType(document, atom.Body, true)
+	start := body.LastChild.PrevSibling.PrevSibling.PrevSibling.FirstChild // Paragraph of the first note
+	require.Equal(t, atom.P, start.DataAtom)
+
+	converter := HTMLConverter{skipNotes: true, startElement: start}
+	TraverseNode(&converter, body)
+	res := converter.Result()
+	require.Len(t, res.Elements, 2)
+	assert.Equal(t, 1, res.StartIndex)
+	assert.Equal(t, []string{"The first note."}, segmentTexts(res.Elements[1]))
+}
+
+func TestHTMLConverterRoles(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<aside epub:type="footnote"><h2>Heading of a note</h2><blockquote>Quote in a note</blockquote><div><p>Text of a note</p></div></aside>
+		<div role="doc-endnote"><p>Endnote</p></div>
+		<div role="note"><p>Note</p></div>
+		<section epub:type="sidebar"><div role="doc-footnote"><p>Nested footnote</p></div></section>
+		<blockquote cite="https://example.com" title="Example">Quote</blockquote>
+		<p>Body</p>
+	</body>`)
+	require.Len(t, elements, 8)
+
+	roles := make([]element.TextRole, len(elements))
+	for i, el := range elements {
+		roles[i] = el.(element.TextElement).Role()
+	}
+	// Headings and quotes take precedence over the role of their note, which applies to its other descendants
+	assert.Equal(t, element.Heading{Level: 2}, roles[0])
+	assert.IsType(t, element.Quote{}, roles[1])
+	assert.Equal(t, element.Footnote{}, roles[2])
+	assert.Equal(t, element.Endnote{}, roles[3])
+	assert.Equal(t, element.Note{}, roles[4])
+	// The closest semantic role applies
+	assert.Equal(t, element.Footnote{}, roles[5])
+	quote, ok := roles[6].(element.Quote)
+	require.True(t, ok)
+	assert.Equal(t, "Example", quote.ReferenceTitle)
+	assert.Equal(t, "https://example.com", quote.ReferenceURL.String())
+	assert.Equal(t, element.Body{}, roles[7])
+}
+
+// The language of the segments used to be stored as a *string, which made [element.AttributesHolder.Language] panic,
+// and split the text at every node as the pointers were compared instead of the languages.
+func TestHTMLConverterSegmentLanguages(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p lang="en">The French say <em>bonjour</em> or <span lang="fr">bonne <em>journée</em></span> to greet.</p>
+	</body>`)
+	require.Len(t, elements, 1)
+
+	segments := elements[0].(element.TextElement).Segments()
+	require.Equal(t, []string{"The French say bonjour or ", "bonne journée", " to greet."}, segmentTexts(elements[0]))
+	assert.Equal(t, "en", segments[0].Language())
+	assert.Equal(t, "fr", segments[1].Language())
+	assert.Equal(t, "en", segments[2].Language())
+}
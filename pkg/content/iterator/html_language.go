@@ -0,0 +1,62 @@
+package iterator
+
+import (
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/util/langdetect"
+)
+
+// Sets the language of the text segments which don't declare one, when it is detected with a confidence of at
+// least [threshold]. The text of each element is detected on its own, falling back on the language detected for
+// the whole resource when it is too short or ambiguous, e.g. for a heading.
+func detectLanguages(elements []element.Element, threshold float64) {
+	undeclaredText := func(el element.TextElement) string {
+		var sb strings.Builder
+		for _, s := range el.Segments() {
+			if s.Language() == "" {
+				sb.WriteString(s.Text)
+			}
+		}
+		return sb.String()
+	}
+
+	var all strings.Builder
+	for _, el := range elements {
+		if tel, ok := el.(element.TextElement); ok {
+			all.WriteString(undeclaredText(tel))
+			all.WriteRune(' ')
+		}
+	}
+	if strings.TrimSpace(all.String()) == "" {
+		return
+	}
+	resource := langdetect.Detect(all.String())
+
+	for i, el := range elements {
+		tel, ok := el.(element.TextElement)
+		if !ok {
+			continue
+		}
+		text := undeclaredText(tel)
+		if text == "" {
+			continue
+		}
+		detected := langdetect.Detect(text)
+		if !detected.IsReliable(threshold) {
+			detected = resource
+		}
+		if !detected.IsReliable(threshold) {
+			continue
+		}
+
+		segments := make([]element.TextSegment, len(tel.Segments()))
+		for j, s := range tel.Segments() {
+			if s.Language() == "" {
+				s.AttributesHolder = s.AttributesHolder.With(element.NewAttribute(element.LanguageAttributeKey, detected.Language))
+			}
+			segments[j] = s
+		}
+		elements[i] = tel.WithSegments(segments)
+	}
+}
@@ -0,0 +1,114 @@
+package iterator
+
+import (
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	iutil "github.com/readium/go-toolkit/pkg/internal/util"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/util"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const namespaceOPS = "http://www.idpf.org/2007/ops"
+
+// Values of the epub:type attribute of the node.
+// The HTML parser keeps the prefixed name, while the attribute is namespaced when parsed as XML.
+func epubTypes(n *html.Node) []string {
+	for _, at := range n.Attr {
+		if at.Key == "epub:type" || (at.Namespace == namespaceOPS && at.Key == "type") {
+			return strings.Fields(at.Val)
+		}
+	}
+	return nil
+}
+
+// Returns whether the node has one of the given epub:type values or ARIA roles.
+func hasSemantics(n *html.Node, types []string, roles []string) bool {
+	for _, t := range epubTypes(n) {
+		for _, v := range types {
+			if t == v {
+				return true
+			}
+		}
+	}
+	for _, r := range strings.Fields(getAttr(n, "role")) {
+		for _, v := range roles {
+			if r == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Role of a note or aside from the EPUB 3 structural semantics or DPUB-ARIA roles of the node, or nil.
+// https://www.w3.org/TR/epub-ssv-11/#notes
+// https://www.w3.org/TR/dpub-aria-1.1/
+func semanticRole(n *html.Node) element.TextRole {
+	switch {
+	case hasSemantics(n, []string{"footnote"}, []string{"doc-footnote"}):
+		return element.Footnote{}
+	case hasSemantics(n, []string{"endnote", "rearnote"}, []string{"doc-endnote"}):
+		return element.Endnote{}
+	case hasSemantics(n, []string{"note"}, []string{"note"}):
+		return element.Note{}
+	case hasSemantics(n, []string{"sidebar"}, []string{"complementary"}):
+		return element.Aside{}
+	case n.DataAtom == atom.Aside:
+		return element.Aside{}
+	}
+	return nil
+}
+
+// Returns whether the node is a note or a collection of notes, which are not part of the linear reading.
+func isNote(n *html.Node) bool {
+	if role := semanticRole(n); role != nil && element.IsNote(role) {
+		return true
+	}
+	return hasSemantics(n, []string{"footnotes", "endnotes", "rearnotes"}, []string{"doc-endnotes"})
+}
+
+// Returns whether the node is a reference to a note, e.g. a footnote call.
+func isNoteRef(n *html.Node) bool {
+	return n.DataAtom == atom.A && hasSemantics(n, []string{"noteref"}, []string{"doc-noteref"})
+}
+
+// Returns whether [n] is [descendant] or one of its ancestors.
+func containsNode(n *html.Node, descendant *html.Node) bool {
+	for ; descendant != nil; descendant = descendant.Parent {
+		if descendant == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Locator of the note targeted by the [noteRef] link, relative to the [base] locator of the resource.
+func noteRefTarget(noteRef *html.Node, base manifest.Locator) *manifest.Locator {
+	href := getAttr(noteRef, "href")
+	if href == "" {
+		return nil
+	}
+	target, err := util.NewHREF(href, base.Href).String()
+	if err != nil {
+		return nil
+	}
+	target, fragment, _ := strings.Cut(target, "#")
+	if target == "" {
+		target = base.Href
+	}
+
+	locator := &manifest.Locator{
+		Href: target,
+		Type: base.Type,
+	}
+	if fragment != "" {
+		locator.Locations.Fragments = []string{fragment}
+		locator.Locations.OtherLocations = map[string]interface{}{
+			"cssSelector": iutil.CSSIDSelector(fragment),
+		}
+	}
+	return locator
+}
@@ -0,0 +1,206 @@
+package iterator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	iutil "github.com/readium/go-toolkit/pkg/internal/util"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Converts the elements which are not read as text, such as formulas, inline images and tables.
+// Returns nil if the node must be traversed as usual, or [skip] if its subtree must be ignored nonetheless,
+// e.g. for a decorative SVG.
+//
+// Formulas and images flowing in a text are not converted to their own element, but read with their text
+// alternative, returned in [inline].
+func (c *HTMLConverter) structuredElement(n *html.Node) (el element.Element, inline string, skip bool) {
+	switch {
+	case isMath(n):
+		altText := getAttr(n, "alttext")
+		if !isBlockLevel(n) {
+			if inline = altText; inline == "" {
+				if inline = getAttr(n, "aria-label"); inline == "" {
+					inline = textContent(n)
+				}
+			}
+			return nil, inline, true
+		}
+		return element.NewMathElement(
+			c.elementLocator(iutil.CSSSelector(n)),
+			renderNode(n),
+			altText,
+			structuredAttributes(n, ""),
+		), "", true
+	case n.DataAtom == atom.Svg:
+		if isDecorative(n) {
+			return nil, "", true
+		}
+		var title string
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.DataAtom == atom.Title {
+				title = textContent(child)
+				break
+			}
+		}
+		if !isBlockLevel(n) {
+			if inline = getAttr(n, "aria-label"); inline == "" {
+				inline = title
+			}
+			return nil, inline, true
+		}
+		return element.NewSVGElement(
+			c.elementLocator(iutil.CSSSelector(n)),
+			renderNode(n),
+			structuredAttributes(n, title),
+		), "", true
+	case n.DataAtom == atom.Table && n.Namespace == "":
+		if isDecorative(n) {
+			// Layout table, read as regular text
+			return nil, "", false
+		}
+		caption, rows := tableContent(n)
+		return element.NewTableElement(
+			c.elementLocator(iutil.CSSSelector(n)),
+			caption,
+			rows,
+			structuredAttributes(n, ""),
+		), "", true
+	}
+	return nil, "", false
+}
+
+// Appends the text alternative of an inline formula or image to the current element, in its own segment.
+func (c *HTMLConverter) appendInlineText(n *html.Node, text string) {
+	c.flushSegment()
+	c.currentLanguage = nodeLanguage(n)
+	c.textAcc.WriteString(text)
+	c.flushSegment()
+}
+
+// Whether the node is a MathML formula, including when its tag is prefixed in an XHTML document, e.g. <m:math>.
+func isMath(n *html.Node) bool {
+	return n.DataAtom == atom.Math || strings.HasSuffix(n.Data, ":math")
+}
+
+// Whether the formula or image is displayed as a block rather than flowing in a text: either explicitly with
+// display="block", or by being the only content of its parent block or figure.
+func isBlockLevel(n *html.Node) bool {
+	if getAttr(n, "display") == "block" {
+		return true
+	}
+	parent := n.Parent
+	if parent == nil || parent.Type != html.ElementNode || isInlineTag(parent) {
+		return false
+	}
+	if parent.DataAtom == atom.Figure {
+		return true
+	}
+	for sibling := parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == n || sibling.Type == html.CommentNode || (sibling.Type == html.TextNode && onlySpace(sibling.Data)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Whether the element is only used for presentation purposes.
+func isDecorative(n *html.Node) bool {
+	role := getAttr(n, "role")
+	return role == "presentation" || role == "none" || getAttr(n, "aria-hidden") == "true"
+}
+
+// Attributes of a structured element, with the given fallback [label] when there's no ARIA label.
+func structuredAttributes(n *html.Node, label string) []element.Attribute[any] {
+	var attributes []element.Attribute[any]
+	if l := getAttr(n, "aria-label"); l != "" {
+		label = l
+	}
+	if label != "" {
+		attributes = append(attributes, element.NewAttribute(element.AcessibilityLabelAttributeKey, label))
+	}
+	if language := nodeLanguage(n); language != nil {
+		attributes = append(attributes, element.NewAttribute(element.LanguageAttributeKey, *language))
+	}
+	return attributes
+}
+
+func renderNode(n *html.Node) string {
+	var sb strings.Builder
+	if err := html.Render(&sb, n); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// Text content of the node and its descendants, with normalized whitespaces.
+// Blocks are separated by a space.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+		case html.ElementNode:
+			if !isInlineTag(n) {
+				sb.WriteRune(' ')
+			}
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				f(child)
+			}
+		}
+	}
+	f(n)
+
+	var normalized strings.Builder
+	appendNormalizedWhitespace(&normalized, sb.String(), true)
+	return strings.TrimSpace(normalized.String())
+}
+
+// Reads the caption and rows of the [table], including the ones of its header, body and footer sections.
+// Rows of nested tables are part of the text of their cell.
+func tableContent(table *html.Node) (caption string, rows []element.TableRow) {
+	var readRows func(*html.Node)
+	readRows = func(parent *html.Node) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Caption:
+				if caption == "" {
+					caption = textContent(child)
+				}
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				readRows(child)
+			case atom.Tr:
+				row := element.TableRow{Cells: []element.TableCell{}}
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					row.Cells = append(row.Cells, element.TableCell{
+						Text:    textContent(cell),
+						Header:  cell.DataAtom == atom.Th,
+						ColSpan: spanAttr(cell, "colspan"),
+						RowSpan: spanAttr(cell, "rowspan"),
+					})
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	readRows(table)
+	return
+}
+
+func spanAttr(n *html.Node, key string) int {
+	if span, err := strconv.Atoi(getAttr(n, key)); err == nil && span > 1 {
+		return span
+	}
+	return 1
+}
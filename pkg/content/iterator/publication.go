@@ -13,6 +13,7 @@ type PublicationContentIterator struct {
 	fetcher                          fetcher.Fetcher
 	startLocator                     *manifest.Locator
 	resourceContentIteratorFactories []ResourceContentIteratorFactory
+	nonLinearPolicy                  manifest.NonLinearPolicy
 
 	_currentIterator *IndexedIterator
 	currentElement   *ElementInDirection
@@ -28,6 +29,14 @@ func NewPublicationContent(manifest manifest.Manifest, fetcher fetcher.Fetcher,
 	}
 }
 
+// Same as [NewPublicationContent], with the given [policy] for the non-linear resources of the reading order.
+// A skipped resource is still iterated when the [startLocator] points to it.
+func NewPublicationContentWithPolicy(m manifest.Manifest, fetcher fetcher.Fetcher, startLocator *manifest.Locator, resourceContentIteratorFactories []ResourceContentIteratorFactory, policy manifest.NonLinearPolicy) *PublicationContentIterator {
+	it := NewPublicationContent(m, fetcher, startLocator, resourceContentIteratorFactories)
+	it.nonLinearPolicy = policy
+	return it
+}
+
 func (it *PublicationContentIterator) HasPrevious() (bool, error) {
 	e, err := it.nextIn(Backward)
 	if err != nil {
@@ -147,6 +156,9 @@ func (it *PublicationContentIterator) loadIteratorAt(index int, locator manifest
 // The [progression] will be used to build a locator and call [loadIteratorAt].
 func (it *PublicationContentIterator) loadIteratorAtProgression(index int, progression float64) *IndexedIterator {
 	link := it.manifest.ReadingOrder[index]
+	if it.nonLinearPolicy.Skips(link) {
+		return nil
+	}
 	locator := it.manifest.LocatorFromLink(link)
 	if locator == nil {
 		return nil
@@ -0,0 +1,140 @@
+package iterator
+
+import (
+	"unicode/utf8"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/content/tokenizer"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Maximum length of the text context (before and after) of the locators of tokenized segments.
+const tokenContextLength = 50
+
+// Wraps the iterators created by [factory], to split the segments of their text elements using the [tokenizer].
+// For example, a sentence tokenizer produces one segment per utterance to be read aloud, each with its own locator.
+func TokenizingFactory(factory ResourceContentIteratorFactory, tokenizer tokenizer.Tokenizer) ResourceContentIteratorFactory {
+	return func(resource fetcher.Resource, locator manifest.Locator) Iterator {
+		it := factory(resource, locator)
+		if it == nil {
+			return nil
+		}
+		return &tokenizingIterator{
+			Iterator:  it,
+			tokenizer: tokenizer,
+		}
+	}
+}
+
+type tokenizingIterator struct {
+	Iterator
+	tokenizer tokenizer.Tokenizer
+}
+
+func (it *tokenizingIterator) Next() element.Element {
+	return TokenizeElement(it.Iterator.Next(), it.tokenizer)
+}
+
+func (it *tokenizingIterator) Previous() element.Element {
+	return TokenizeElement(it.Iterator.Previous(), it.tokenizer)
+}
+
+// Splits the segments of a text element using the [tokenizer]. Other elements are returned as is.
+// Consecutive segments in the same language are tokenized together, so that a token can span several of them,
+// e.g. a sentence containing emphasized words. The text of the element is left unchanged.
+func TokenizeElement(el element.Element, tokenizer tokenizer.Tokenizer) element.Element {
+	te, ok := el.(element.TextElement)
+	if !ok {
+		return el
+	}
+
+	segments := te.Segments()
+	tokenized := make([]element.TextSegment, 0, len(segments))
+	for start := 0; start < len(segments); {
+		end := start + 1
+		for end < len(segments) && segments[end].Language() == segments[start].Language() {
+			end++
+		}
+		tokenized = append(tokenized, tokenizeSegments(segments[start:end], tokenizer)...)
+		start = end
+	}
+	return te.WithSegments(tokenized)
+}
+
+func tokenizeSegments(segments []element.TextSegment, tokenizer tokenizer.Tokenizer) []element.TextSegment {
+	var text string
+	offsets := make([]int, len(segments))
+	for i, s := range segments {
+		offsets[i] = len(text)
+		text += s.Text
+	}
+
+	ranges := tokenizer.Tokenize(text, segments[0].Language())
+	if len(ranges) == 0 {
+		return segments
+	}
+
+	before := segments[0].Locator.Text.Before
+	after := segments[len(segments)-1].Locator.Text.After
+	result := make([]element.TextSegment, len(ranges))
+	for i, r := range ranges {
+		// Whitespace between the tokens is kept, so that the text of the element doesn't change
+		from, to := r.Start, len(text)
+		if i == 0 {
+			from = 0
+		}
+		if i+1 < len(ranges) {
+			to = ranges[i+1].Start
+		}
+
+		// The locator of the token is based on the segment where it starts
+		source := segments[0]
+		for j := range segments {
+			if offsets[j] <= r.Start {
+				source = segments[j]
+			}
+		}
+		locator := source.Locator
+		locator.Text = manifest.Text{
+			Before:    lastRunes(before+text[:r.Start], tokenContextLength),
+			Highlight: text[r.Start:r.End],
+			After:     firstRunes(text[r.End:]+after, tokenContextLength),
+		}
+
+		// A token overlapping a note reference still points to the note
+		holder := source.AttributesHolder
+		for j := range segments {
+			end := offsets[j] + len(segments[j].Text)
+			if segments[j].NoteRef() == nil || holder.NoteRef() != nil || end <= r.Start || offsets[j] >= r.End {
+				continue
+			}
+			holder = holder.With(*segments[j].GetFirst(element.NoteRefAttributeKey))
+		}
+
+		result[i] = element.TextSegment{
+			AttributesHolder: holder,
+			Locator:          locator,
+			Text:             text[from:to],
+		}
+	}
+	return result
+}
+
+func lastRunes(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return s[i:]
+}
+
+func firstRunes(s string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i]
+}
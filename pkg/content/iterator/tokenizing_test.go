@@ -0,0 +1,95 @@
+package iterator
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/content/tokenizer"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeElementSplitsSegments(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p>It was a <em>dark</em> and stormy night. The rain fell in torrents. Except at occasional intervals.</p>
+	</body>`)
+	require.Len(t, elements, 1)
+	original := elements[0].(element.TextElement)
+
+	tokenized := TokenizeElement(original, tokenizer.NewTextTokenizer(tokenizer.UnitSentence)).(element.TextElement)
+	assert.Equal(t, []string{
+		"It was a dark and stormy night. ",
+		"The rain fell in torrents. ",
+		"Except at occasional intervals.",
+	}, segmentTexts(tokenized))
+	assert.Equal(t, original.Text(), tokenized.Text(), "the text of the element is unchanged")
+	assert.Equal(t, original.Locator(), tokenized.Locator())
+}
+
+func TestTokenizeElementLocators(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p>First.</p>
+		<p>One sentence. Another<a epub:type="noteref" href="#n1">1</a> one. Last sentence.</p>
+		<p>Next.</p>
+	</body>`)
+	require.Len(t, elements, 3)
+	segments := TokenizeElement(elements[1], tokenizer.NewTextTokenizer(tokenizer.UnitSentence)).(element.TextElement).Segments()
+	require.Len(t, segments, 3)
+
+	// The context of the tokens spans the other tokens of the element
+	assert.Equal(t, manifest.Text{Before: "", Highlight: "One sentence.", After: " Another1 one. Last sentence."}, segments[0].Locator.Text)
+	assert.Equal(t, manifest.Text{Before: "One sentence. ", Highlight: "Another1 one.", After: " Last sentence."}, segments[1].Locator.Text)
+	assert.Equal(t, manifest.Text{Before: "One sentence. Another1 one. ", Highlight: "Last sentence.", After: ""}, segments[2].Locator.Text)
+	for _, segment := range segments {
+		assert.Equal(t, "/OEBPS/chapter.xhtml", segment.Locator.Href)
+		assert.Equal(t, "body > p:nth-child(2)", segment.Locator.Locations.CSSSelector())
+	}
+
+	// A sentence containing a note reference points to the note
+	assert.Nil(t, segments[0].NoteRef())
+	if ref := segments[1].NoteRef(); assert.NotNil(t, ref) {
+		assert.Equal(t, []string{"n1"}, ref.Locations.Fragments)
+	}
+	assert.Nil(t, segments[2].NoteRef())
+}
+
+func TestTokenizeElementTruncatesContext(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, "<body><p>"+strings.Repeat("Ça débute. ", 12)+"Fin.</p></body>")
+	require.Len(t, elements, 1)
+	segments := TokenizeElement(elements[0], tokenizer.NewTextTokenizer(tokenizer.UnitSentence)).(element.TextElement).Segments()
+	require.Len(t, segments, 13)
+
+	text := segments[6].Locator.Text
+	assert.Equal(t, "Ça débute.", text.Highlight)
+	assert.Equal(t, tokenContextLength, utf8.RuneCountInString(text.Before))
+	assert.Equal(t, tokenContextLength, utf8.RuneCountInString(text.After))
+	assert.True(t, strings.HasSuffix(text.Before, "débute. "))
+	assert.True(t, strings.HasPrefix(text.After, " Ça"))
+}
+
+func TestTokenizeElementByLanguage(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body>
+		<p lang="en">He said: <span lang="fr">Bonjour. Ça va ?</span> Then he left.</p>
+	</body>`)
+	require.Len(t, elements, 1)
+
+	segments := TokenizeElement(elements[0], tokenizer.NewTextTokenizer(tokenizer.UnitSentence)).(element.TextElement).Segments()
+	var texts, languages []string
+	for _, segment := range segments {
+		texts = append(texts, segment.Text)
+		languages = append(languages, segment.Language())
+	}
+	// Tokens never span segments in different languages
+	assert.Equal(t, []string{"He said: ", "Bonjour. ", "Ça va ?", " Then he left."}, texts)
+	assert.Equal(t, []string{"en", "fr", "fr", "en"}, languages)
+}
+
+func TestTokenizeElementIgnoresOtherElements(t *testing.T) {
+	elements := convertHTML(t, HTMLConverter{}, `<body><img src="cover.jpg" alt="Cover"/></body>`)
+	require.Len(t, elements, 1)
+	assert.Equal(t, elements[0], TokenizeElement(elements[0], tokenizer.NewTextTokenizer(tokenizer.UnitSentence)))
+	assert.Nil(t, TokenizeElement(nil, tokenizer.NewTextTokenizer(tokenizer.UnitSentence)))
+}
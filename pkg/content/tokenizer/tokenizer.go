@@ -0,0 +1,286 @@
+// Package tokenizer splits text into smaller units, such as sentences or words, e.g. to be read aloud one utterance at a time.
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A range of bytes in a text, from Start (included) to End (excluded).
+type Range struct {
+	Start int
+	End   int
+}
+
+// Splits a text in the given BCP 47 [language] into ranges.
+// The ranges don't overlap, are sorted and never start or end with whitespace.
+type Tokenizer interface {
+	Tokenize(text string, language string) []Range
+}
+
+// Granularity of the tokens.
+type Unit uint8
+
+const (
+	UnitWord Unit = iota
+	UnitSentence
+	UnitParagraph
+)
+
+// Tokenizer based on the punctuation and whitespace of the text, which doesn't need any language model.
+// Sentences ending with a period after an abbreviation of the language (e.g. "Mr." in English) are not split.
+type TextTokenizer struct {
+	Unit          Unit
+	Abbreviations map[string][]string // Additional abbreviations (without their trailing period), by primary language subtag.
+}
+
+func NewTextTokenizer(unit Unit) TextTokenizer {
+	return TextTokenizer{Unit: unit}
+}
+
+// Implements Tokenizer
+func (t TextTokenizer) Tokenize(text string, language string) []Range {
+	switch t.Unit {
+	case UnitWord:
+		return words(text)
+	case UnitSentence:
+		return t.sentences(text, language)
+	default:
+		return paragraphs(text)
+	}
+}
+
+// Abbreviations which are usually followed by a period in the middle of a sentence, by primary language subtag.
+// The lookup is case-insensitive.
+var defaultAbbreviations = map[string][]string{
+	"en": {"mr", "mrs", "ms", "mx", "dr", "prof", "sr", "jr", "st", "mt", "ft", "vs", "etc", "e.g", "i.e", "cf", "al", "approx", "no", "vol", "fig", "p", "pp", "ch", "ed", "eds", "jan", "feb", "mar", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec", "inc", "ltd", "co", "corp", "gen", "col", "capt", "lt", "sgt", "rev", "hon", "a.m", "p.m", "u.s"},
+	"fr": {"m", "mm", "mme", "mmes", "mlle", "mlles", "dr", "pr", "me", "st", "ste", "etc", "cf", "p", "pp", "vol", "chap", "éd", "env", "av", "apr", "j.-c", "nº", "n°"},
+	"de": {"hr", "hrn", "fr", "dr", "prof", "st", "bzw", "ca", "usw", "vgl", "z.b", "d.h", "u.a", "s", "nr", "bd", "kap", "jh", "str"},
+	"es": {"sr", "sra", "srta", "dr", "dra", "d", "dña", "ud", "uds", "etc", "p", "pág", "vol", "cap", "av", "núm"},
+	"it": {"sig", "sigg", "sig.ra", "dott", "prof", "ing", "avv", "ecc", "pag", "vol", "cap", "ca", "n"},
+	"nl": {"dhr", "mevr", "dr", "prof", "ir", "mr", "bijv", "d.w.z", "enz", "o.a", "blz", "nr"},
+	"pt": {"sr", "sra", "dr", "dra", "prof", "etc", "p", "pág", "vol", "cap", "av", "n"},
+}
+
+func (t TextTokenizer) isAbbreviation(word string, language string) bool {
+	primary, _, _ := strings.Cut(strings.ToLower(language), "-")
+	word = strings.ToLower(word)
+	lists := [][]string{defaultAbbreviations[primary], t.Abbreviations[primary]}
+	if primary == "" {
+		// Unknown language, English is the most likely
+		lists = append(lists, defaultAbbreviations["en"], t.Abbreviations["en"])
+	}
+	for _, list := range lists {
+		for _, abbr := range list {
+			if abbr == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the range trimmed of its leading and trailing whitespace, or false if it's only whitespace.
+func trim(text string, r Range) (Range, bool) {
+	for r.Start < r.End {
+		c, size := utf8.DecodeRuneInString(text[r.Start:])
+		if !unicode.IsSpace(c) {
+			break
+		}
+		r.Start += size
+	}
+	for r.End > r.Start {
+		c, size := utf8.DecodeLastRuneInString(text[:r.End])
+		if !unicode.IsSpace(c) {
+			break
+		}
+		r.End -= size
+	}
+	return r, r.End > r.Start
+}
+
+func paragraphs(text string) []Range {
+	var ranges []Range
+	start := 0
+	for i := 0; i < len(text); {
+		// A paragraph ends with an empty line
+		if text[i] == '\n' {
+			j := i + 1
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\r') {
+				j++
+			}
+			if j < len(text) && text[j] == '\n' {
+				if r, ok := trim(text, Range{start, i}); ok {
+					ranges = append(ranges, r)
+				}
+				start = j + 1
+				i = j + 1
+				continue
+			}
+		}
+		i++
+	}
+	if r, ok := trim(text, Range{start, len(text)}); ok {
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// Scripts written without spaces between words, where each character is considered a word.
+func isUnspacedScript(c rune) bool {
+	return unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsNumber(c) || unicode.IsMark(c)
+}
+
+// Characters which can join two parts of a word, e.g. "don't", "well-known" or "3.14".
+func isWordJoiner(c rune) bool {
+	switch c {
+	case '\'', '’', '-', '‐', '.', ',', '_', '·':
+		return true
+	}
+	return false
+}
+
+func words(text string) []Range {
+	var ranges []Range
+	start := -1
+	for i := 0; i < len(text); {
+		c, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case isUnspacedScript(c):
+			if start >= 0 {
+				ranges = append(ranges, Range{start, i})
+				start = -1
+			}
+			ranges = append(ranges, Range{i, i + size})
+		case isWordRune(c):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0 && isWordJoiner(c):
+			// Only joins when followed by another part of the word, and a comma only joins numbers (e.g. "1,000")
+			next, _ := utf8.DecodeRuneInString(text[i+size:])
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			if !isWordRune(next) || isUnspacedScript(next) || (c == ',' && (!unicode.IsNumber(prev) || !unicode.IsNumber(next))) {
+				ranges = append(ranges, Range{start, i})
+				start = -1
+			}
+		default:
+			if start >= 0 {
+				ranges = append(ranges, Range{start, i})
+				start = -1
+			}
+		}
+		i += size
+	}
+	if start >= 0 {
+		ranges = append(ranges, Range{start, len(text)})
+	}
+	return ranges
+}
+
+func isSentenceTerminator(c rune) bool {
+	switch c {
+	case '.', '!', '?', '…', '‼', '⁇', '⁈', '⁉', '。', '！', '？', '｡', '؟', '।', '॥', '።':
+		return true
+	}
+	return false
+}
+
+// Characters which can follow the terminator of a sentence, e.g. closing quotes and brackets.
+func isSentenceCloser(c rune) bool {
+	return unicode.In(c, unicode.Pe, unicode.Pf) || c == '"' || c == '\'' || c == '»' || c == '」' || c == '』'
+}
+
+func (t TextTokenizer) sentences(text string, language string) []Range {
+	var ranges []Range
+	start := 0
+	for i := 0; i < len(text); {
+		c, size := utf8.DecodeRuneInString(text[i:])
+		if c == '\n' {
+			// Line breaks always end a sentence, e.g. in poetry
+			if r, ok := trim(text, Range{start, i}); ok {
+				ranges = append(ranges, r)
+			}
+			start = i + size
+			i += size
+			continue
+		}
+		if !isSentenceTerminator(c) {
+			i += size
+			continue
+		}
+
+		// Includes the following terminators and closing punctuation, e.g. ?!" or ...)
+		end := i + size
+		for end < len(text) {
+			n, nsize := utf8.DecodeRuneInString(text[end:])
+			if !isSentenceTerminator(n) && !isSentenceCloser(n) {
+				break
+			}
+			end += nsize
+		}
+
+		if t.isSentenceEnd(text, start, i, end, c, language) {
+			if r, ok := trim(text, Range{start, end}); ok {
+				ranges = append(ranges, r)
+			}
+			start = end
+		}
+		i = end
+	}
+	if r, ok := trim(text, Range{start, len(text)}); ok {
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// Whether the terminator [c] at [i], followed by closing punctuation until [end], ends the sentence started at [start].
+func (t TextTokenizer) isSentenceEnd(text string, start, i, end int, c rune, language string) bool {
+	if end >= len(text) {
+		return true
+	}
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	// Full-width terminators don't need to be followed by a space
+	if c >= 0x3000 {
+		return true
+	}
+	if !unicode.IsSpace(next) {
+		return false
+	}
+
+	// A sentence doesn't start with a lowercase letter, e.g. after a quotation: "Did he?" she asked.
+	following := strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+	if f, _ := utf8.DecodeRuneInString(following); unicode.IsLower(f) {
+		return false
+	}
+	if c != '.' || end != i+1 {
+		return true
+	}
+
+	// The period might be part of an abbreviation or an initial
+	wordStart := i
+	for wordStart > start {
+		p, psize := utf8.DecodeLastRuneInString(text[:wordStart])
+		if !isWordRune(p) && p != '.' && p != '-' {
+			break
+		}
+		wordStart -= psize
+	}
+	word := text[wordStart:i]
+	if word == "" {
+		return true
+	}
+	if t.isAbbreviation(word, language) {
+		return false
+	}
+	if first, size := utf8.DecodeRuneInString(word); size == len(word) && unicode.IsUpper(first) {
+		// Initial of a name, e.g. "J. R. R. Tolkien"
+		return false
+	}
+	return true
+}
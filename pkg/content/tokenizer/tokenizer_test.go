@@ -0,0 +1,56 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tokens(t Tokenizer, text string, language string) []string {
+	var res []string
+	for _, r := range t.Tokenize(text, language) {
+		res = append(res, text[r.Start:r.End])
+	}
+	return res
+}
+
+func TestTokenizeSentences(t *testing.T) {
+	tok := NewTextTokenizer(UnitSentence)
+
+	assert.Equal(t, []string{
+		"Mr. Bennet was among the earliest of those who waited on Mr. Bingley.",
+		"He had always intended to visit him!",
+		`"Did he?" she asked…`,
+		"It cost $3.50, i.e. not much.",
+	}, tokens(tok, `  Mr. Bennet was among the earliest of those who waited on Mr. Bingley. He had always intended to visit him! "Did he?" she asked… It cost $3.50, i.e. not much. `, "en"))
+
+	assert.Equal(t, []string{
+		"J. R. R. Tolkien wrote it.",
+		"Then he stopped.",
+	}, tokens(tok, "J. R. R. Tolkien wrote it. Then he stopped.", ""))
+
+	assert.Equal(t, []string{"Il parle à M. Dupont, etc. et s'en va.", "Voilà."}, tokens(tok, "Il parle à M. Dupont, etc. et s'en va. Voilà.", "fr-FR"))
+	assert.Equal(t, []string{"Wait.", "No."}, tokens(tok, "Wait.\nNo.", "en"), "line breaks end sentences")
+	assert.Equal(t, []string{"吾輩は猫である。", "名前はまだ無い。"}, tokens(tok, "吾輩は猫である。名前はまだ無い。", "ja"))
+	assert.Empty(t, tokens(tok, "   ", "en"))
+}
+
+func TestTokenizeSentencesCustomAbbreviations(t *testing.T) {
+	tok := TextTokenizer{
+		Unit:          UnitSentence,
+		Abbreviations: map[string][]string{"en": {"approx", "cap"}},
+	}
+	assert.Equal(t, []string{"See Cap. Three for details."}, tokens(tok, "See Cap. Three for details.", "en-GB"))
+	assert.Equal(t, []string{"See Cap.", "Three for details."}, tokens(NewTextTokenizer(UnitSentence), "See Cap. Three for details.", "en-GB"))
+}
+
+func TestTokenizeWords(t *testing.T) {
+	tok := NewTextTokenizer(UnitWord)
+	assert.Equal(t, []string{"Don't", "stop", "well-known", "1,000", "and", "3.14", "ok", "e.g"}, tokens(tok, "Don't stop -- well-known, 1,000 and 3.14: ok (e.g.)", "en"))
+	assert.Equal(t, []string{"猫", "が", "cat"}, tokens(tok, "猫が cat", "ja"))
+}
+
+func TestTokenizeParagraphs(t *testing.T) {
+	tok := NewTextTokenizer(UnitParagraph)
+	assert.Equal(t, []string{"First line\nsecond line.", "Second paragraph."}, tokens(tok, "First line\nsecond line.\n  \nSecond paragraph.\n", "en"))
+}
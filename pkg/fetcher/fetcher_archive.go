@@ -54,15 +54,21 @@ func (f *ArchiveFetcher) Get(link manifest.Link) Resource {
 		cl = entry.Length()
 	}
 
+	properties := manifest.Properties{}
+	if v, ok := entry.(archive.EntryValidators); ok {
+		for k, p := range validatorProperties(int64(entry.Length()), v.CRC32(), v.ModTime()) {
+			properties[k] = p
+		}
+	}
+	properties["https://readium.org/webpub-manifest/properties#archive"] = map[string]interface{}{
+		"entryLength":       cl,
+		"isEntryCompressed": entry.CompressedLength() > 0,
+	}
+
 	er := &entryResource{
-		link:  link,
-		entry: entry,
-		properties: manifest.Properties{
-			"https://readium.org/webpub-manifest/properties#archive": map[string]interface{}{
-				"entryLength":       cl,
-				"isEntryCompressed": entry.CompressedLength() > 0,
-			},
-		},
+		link:       link,
+		entry:      entry,
+		properties: properties,
 	}
 
 	return er
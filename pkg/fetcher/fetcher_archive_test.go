@@ -3,6 +3,7 @@ package fetcher
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/stretchr/testify/assert"
@@ -15,7 +16,8 @@ func withArchiveFetcher(t *testing.T, callback func(a *ArchiveFetcher)) {
 }
 
 func TestArchiveFetcherLinks(t *testing.T) {
-	makeTestLink := func(href string, typ string, entryLength uint64, isCompressed bool) struct {
+	modified := time.Date(2015, time.April, 24, 17, 25, 54, 0, time.UTC)
+	makeTestLink := func(href string, typ string, entryLength uint64, isCompressed bool, etag string, modified time.Time) struct {
 		manifest.Link
 		manifest.Properties
 	} {
@@ -28,6 +30,8 @@ func TestArchiveFetcherLinks(t *testing.T) {
 				"entryLength":       entryLength,
 				"isEntryCompressed": isCompressed,
 			},
+			"etag":     etag,
+			"modified": modified,
 		}
 		return struct {
 			manifest.Link
@@ -39,16 +43,16 @@ func TestArchiveFetcherLinks(t *testing.T) {
 		manifest.Link
 		manifest.Properties
 	}{
-		makeTestLink("/mimetype", "", 20, false),
-		makeTestLink("/EPUB/cover.xhtml", "application/xhtml+xml", 259, true),
-		makeTestLink("/EPUB/css/epub.css", "text/css", 595, true),
-		makeTestLink("/EPUB/css/nav.css", "text/css", 306, true),
-		makeTestLink("/EPUB/images/cover.png", "image/png", 35809, true),
-		makeTestLink("/EPUB/nav.xhtml", "application/xhtml+xml", 2293, true),
-		makeTestLink("/EPUB/package.opf", "application/oebps-package+xml", 773, true),
-		makeTestLink("/EPUB/s04.xhtml", "application/xhtml+xml", 118269, true),
-		makeTestLink("/EPUB/toc.ncx", "application/x-dtbncx+xml", 1697, true),
-		makeTestLink("/META-INF/container.xml", "application/xml", 176, true),
+		makeTestLink("/mimetype", "", 20, false, "2cab616f-14", modified),
+		makeTestLink("/EPUB/cover.xhtml", "application/xhtml+xml", 259, true, "b662776f-188", modified),
+		makeTestLink("/EPUB/css/epub.css", "text/css", 595, true, "68e9f991-5c1", modified),
+		makeTestLink("/EPUB/css/nav.css", "text/css", 306, true, "ab4ee2b4-23a", modified),
+		makeTestLink("/EPUB/images/cover.png", "image/png", 35809, true, "5fc41460-a0ae", modified),
+		makeTestLink("/EPUB/nav.xhtml", "application/xhtml+xml", 2293, true, "af41a0ab-316f", modified),
+		makeTestLink("/EPUB/package.opf", "application/oebps-package+xml", 773, true, "d9b82839-802", modified),
+		makeTestLink("/EPUB/s04.xhtml", "application/xhtml+xml", 118269, true, "6dc4e0c1-53b97", modified),
+		makeTestLink("/EPUB/toc.ncx", "application/x-dtbncx+xml", 1697, true, "154d8c28-43ac", time.Date(2017, time.June, 5, 18, 20, 36, 0, time.UTC)),
+		makeTestLink("/META-INF/container.xml", "application/xml", 176, true, "38f6513d-101", modified),
 	}
 
 	withArchiveFetcher(t, func(a *ArchiveFetcher) {
@@ -147,6 +151,9 @@ func TestArchiveFetcherAddsProperties(t *testing.T) {
 				"entryLength":       uint64(595),
 				"isEntryCompressed": true,
 			},
+			"etag":     "68e9f991-5c1",
+			"modified": time.Date(2015, time.April, 24, 17, 25, 54, 0, time.UTC),
 		}, resource.Properties())
+		assert.Equal(t, "68e9f991-5c1", ResourceETag(resource))
 	})
 }
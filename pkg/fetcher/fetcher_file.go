@@ -117,10 +117,11 @@ func NewFileFetcher(href string, fpath string) *FileFetcher {
 }
 
 type FileResource struct {
-	link manifest.Link
-	path string
-	file *os.File
-	read bool
+	link       manifest.Link
+	path       string
+	file       *os.File
+	read       bool
+	properties manifest.Properties // Validators of the file, computed on the first call to Properties.
 }
 
 // Link implements Resource
@@ -128,8 +129,19 @@ func (r *FileResource) Link() manifest.Link {
 	return r.link
 }
 
+// Properties implements Resource
 func (r *FileResource) Properties() manifest.Properties {
-	return manifest.Properties{}
+	if r.properties == nil {
+		fi, err := os.Stat(r.path)
+		if err != nil {
+			return manifest.Properties{}
+		}
+		r.properties = validatorProperties(fi.Size(), 0, fi.ModTime())
+		if r.properties == nil {
+			r.properties = manifest.Properties{}
+		}
+	}
+	return r.properties
 }
 
 // Close implements Resource
@@ -2,7 +2,11 @@ package fetcher
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/stretchr/testify/assert"
@@ -191,3 +195,36 @@ func TestFileFetcherLinks(t *testing.T) {
 
 	assert.ElementsMatch(t, mustContain, links)
 }
+
+func TestFileFetcherValidators(t *testing.T) {
+	fi, err := os.Stat("./testdata/text.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	resource := testFileFetcher.Get(manifest.Link{Href: "/file_href"})
+	assert.Equal(t, fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()), ResourceETag(resource))
+	assert.Equal(t, fi.ModTime(), ResourceModTime(resource))
+
+	unknown := testFileFetcher.Get(manifest.Link{Href: "/unknown"})
+	assert.Empty(t, ResourceETag(unknown))
+	assert.True(t, ResourceModTime(unknown).IsZero())
+
+	assert.Nil(t, validatorProperties(10, 0, time.Time{}))
+}
+
+func TestFileResourceComputesValidatorsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "text.txt")
+	if !assert.NoError(t, os.WriteFile(path, []byte("text"), 0o644)) {
+		return
+	}
+	resource := NewFileFetcher("/text.txt", path).Get(manifest.Link{Href: "/text.txt"})
+	defer resource.Close()
+	etag := ResourceETag(resource)
+	assert.NotEmpty(t, etag)
+
+	// The file is stated only once per resource
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(path, later, later))
+	assert.Equal(t, etag, ResourceETag(resource))
+	assert.NotEqual(t, etag, ResourceETag(NewFileFetcher("/text.txt", path).Get(manifest.Link{Href: "/text.txt"})))
+}
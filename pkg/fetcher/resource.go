@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/readium/go-toolkit/pkg/archive"
 	"github.com/readium/go-toolkit/pkg/manifest"
@@ -114,6 +115,41 @@ func ReadResourceAsXML(r Resource, prefixes map[string]string) (*xmlquery.Node,
 	return node, nil
 }
 
+// Keys of the [Resource] properties identifying the version of its content, used to validate cached copies.
+const (
+	PropertyETag     = "etag"     // Strong entity tag of the content, without quotes.
+	PropertyModified = "modified" // Last modification time of the content, as a [time.Time].
+)
+
+// Properties identifying the version of content of the given [length], from a [crc] checksum or a modification
+// time. Returns nil when neither are known.
+func validatorProperties(length int64, crc uint32, modified time.Time) manifest.Properties {
+	if crc == 0 && modified.IsZero() {
+		return nil
+	}
+	props := manifest.Properties{}
+	if crc != 0 {
+		props[PropertyETag] = fmt.Sprintf("%08x-%x", crc, length)
+	} else if !modified.IsZero() {
+		props[PropertyETag] = fmt.Sprintf("%x-%x", modified.UnixNano(), length)
+	}
+	if !modified.IsZero() {
+		props[PropertyModified] = modified
+	}
+	return props
+}
+
+// Returns the strong entity tag of the resource content, or an empty string if unknown.
+func ResourceETag(r Resource) string {
+	return r.Properties().GetString(PropertyETag)
+}
+
+// Returns the last modification time of the resource content, or a zero time if unknown.
+func ResourceModTime(r Resource) time.Time {
+	t, _ := r.Properties()[PropertyModified].(time.Time)
+	return t
+}
+
 type ResourceErrorCode uint16
 
 // Error codes with HTTP equivalents
@@ -59,16 +59,17 @@ func (r *BytesResource) Read(start int64, end int64) ([]byte, *ResourceError) {
 		return r._bytes, nil
 	}
 
-	// Bounds check
-	length := int64(len(r._bytes))
-	if start > (length - 1) {
-		start = length - 1
-	}
-	if end > length {
-		end = length
-	}
+	from, to := r.clamp(start, end)
+	return r._bytes[from:to], nil
+}
 
-	return r._bytes[start : end+1], nil
+// Returns the bounds of the range from [start] to [end] (inclusive), clamped to the length of the bytes.
+// A range starting past the end is empty, as with the other resources.
+func (r *BytesResource) clamp(start int64, end int64) (int64, int64) {
+	length := int64(len(r._bytes))
+	start = min(start, length)
+	end = min(end+1, length)
+	return start, max(start, end)
 }
 
 // Stream implements Resource
@@ -84,7 +85,8 @@ func (r *BytesResource) Stream(w io.Writer, start int64, end int64) (int64, *Res
 	if start == 0 && end == 0 {
 		buff = bytes.NewBuffer(r._bytes)
 	} else {
-		buff = bytes.NewBuffer(r._bytes[start : end+1])
+		from, to := r.clamp(start, end)
+		buff = bytes.NewBuffer(r._bytes[from:to])
 	}
 	n, err := io.Copy(w, buff)
 	if err != nil {
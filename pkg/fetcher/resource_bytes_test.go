@@ -0,0 +1,40 @@
+package fetcher
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytesResourceReadRange(t *testing.T) {
+	resource := NewBytesResource(manifest.Link{}, func() []byte { return []byte("0123456789") })
+	tests := []struct {
+		start, end int64
+		expected   string
+	}{
+		{0, 0, "0123456789"},
+		{0, 3, "0123"},
+		{4, 4, "4"},
+		{5, 9, "56789"},
+		{5, 100, "56789"},
+		{9, 100, "9"},
+		{10, 100, ""},
+		{50, 100, ""},
+	}
+	for _, tt := range tests {
+		data, err := resource.Read(tt.start, tt.end)
+		assert.Nil(t, err, "%d-%d", tt.start, tt.end)
+		assert.Equal(t, tt.expected, string(data), "%d-%d", tt.start, tt.end)
+
+		var buf bytes.Buffer
+		n, err := resource.Stream(&buf, tt.start, tt.end)
+		assert.Nil(t, err, "%d-%d", tt.start, tt.end)
+		assert.Equal(t, int64(len(tt.expected)), n, "%d-%d", tt.start, tt.end)
+		assert.Equal(t, tt.expected, buf.String(), "%d-%d", tt.start, tt.end)
+	}
+
+	_, err := resource.Read(5, 4)
+	assert.Equal(t, RangeNotSatisfiable(err.Cause), err)
+}
@@ -43,6 +43,11 @@ func escapeCSSIdentifier(input string) string {
 	return input
 }
 
+// Get a CSS selector matching the element with the given ID.
+func CSSIDSelector(id string) string {
+	return "#" + escapeCSSIdentifier(id)
+}
+
 func getAttr(n *html.Node, key string) string {
 	for _, attr := range n.Attr {
 		if attr.Key == key {
@@ -1,5 +1,12 @@
 package manifest
 
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // Readium Guided Navigation Document
 // https://readium.org/guided-navigation/schema/document.schema.json
 type GuidedNavigationDocument struct {
@@ -19,5 +26,81 @@ type GuidedNavigationObject struct {
 	Children []GuidedNavigationObject `json:"children,omitempty"` // Items that are children of the containing Guided Navigation Object.
 }
 
-// TODO: functions for objects to get e.g. audio time, audio file, text file, fragment id, audio "clip", image xywh, etc.
+// TODO: functions for objects to get e.g. text file, fragment id, image xywh, etc.
 // This will come after the URL utility revamp to avoid implementation twice
+
+// Audio resource referenced by the object, without its media fragment.
+func (o GuidedNavigationObject) AudioFile() string {
+	file, _, _ := strings.Cut(o.AudioRef, "#")
+	return file
+}
+
+// Temporal media fragment (#t=begin,end) of an audio clip, in seconds.
+// https://www.w3.org/TR/media-frags/#naming-time
+type AudioClipTime struct {
+	Begin    float64  // Beginning of the clip, 0 when it isn't given.
+	HasBegin bool     // Whether the beginning is given, otherwise the clip starts at the beginning of the audio resource.
+	End      *float64 // End of the clip, nil when it lasts until the end of the audio resource.
+}
+
+// Temporal media fragment of the audio clip referenced by the object, or false if it has none.
+func (o GuidedNavigationObject) AudioTime() (AudioClipTime, bool) {
+	_, fragment, found := strings.Cut(o.AudioRef, "#")
+	if !found {
+		return AudioClipTime{}, false
+	}
+	for _, dimension := range strings.Split(fragment, "&") {
+		value, isTime := strings.CutPrefix(dimension, "t=")
+		if !isTime {
+			continue
+		}
+		var clip AudioClipTime
+		value = strings.TrimPrefix(value, "npt:")
+		b, e, hasEnd := strings.Cut(value, ",")
+		if b != "" {
+			v, err := parseNPT(b)
+			if err != nil {
+				return AudioClipTime{}, false
+			}
+			clip.Begin, clip.HasBegin = v, true
+		}
+		if hasEnd {
+			v, err := parseNPT(e)
+			if err != nil || v < clip.Begin {
+				return AudioClipTime{}, false
+			}
+			clip.End = &v
+		}
+		return clip, true
+	}
+	return AudioClipTime{}, false
+}
+
+// Parses a Normal Play Time value, either in seconds or as [[hh:]mm:]ss[.fraction].
+func parseNPT(value string) (float64, error) {
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0, errors.Errorf("invalid NPT value %q", value)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+// Total duration of the audio clips referenced by the document, in seconds.
+// Clips without an explicit end are ignored, since their duration depends on the audio resource.
+func (d GuidedNavigationDocument) AudioDuration() float64 {
+	return audioDuration(d.Guided)
+}
+
+func audioDuration(objects []GuidedNavigationObject) (duration float64) {
+	for _, o := range objects {
+		if clip, ok := o.AudioTime(); ok && clip.End != nil {
+			duration += *clip.End - clip.Begin
+		}
+		duration += audioDuration(o.Children)
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuidedNavigationObjectAudioClip(t *testing.T) {
+	o := GuidedNavigationObject{AudioRef: "audio/page1.m4a#t=0.84,3.69"}
+	assert.Equal(t, "audio/page1.m4a", o.AudioFile())
+	clip, ok := o.AudioTime()
+	assert.True(t, ok)
+	assert.Equal(t, 0.84, clip.Begin)
+	assert.True(t, clip.HasBegin)
+	assert.Equal(t, 3.69, *clip.End)
+
+	clip, ok = GuidedNavigationObject{AudioRef: "a.mp3#t=npt:1:02.5"}.AudioTime()
+	assert.True(t, ok)
+	assert.Equal(t, 62.5, clip.Begin)
+	assert.True(t, clip.HasBegin)
+	assert.Nil(t, clip.End)
+
+	for _, ref := range []string{"a.mp3#t=,10", "a.mp3#t=npt:,10", "a.mp3#xywh=0,0,1,1&t=,10"} {
+		clip, ok = GuidedNavigationObject{AudioRef: ref}.AudioTime()
+		assert.True(t, ok, ref)
+		assert.Equal(t, 0.0, clip.Begin, ref)
+		assert.False(t, clip.HasBegin, ref)
+		assert.Equal(t, 10.0, *clip.End, ref)
+	}
+
+	clip, ok = GuidedNavigationObject{AudioRef: "a.mp3#t=0,10"}.AudioTime()
+	assert.True(t, ok)
+	assert.True(t, clip.HasBegin, "an explicit beginning at 0 is given")
+
+	_, ok = GuidedNavigationObject{AudioRef: "a.mp3"}.AudioTime()
+	assert.False(t, ok)
+	_, ok = GuidedNavigationObject{AudioRef: "a.mp3#t=5,2"}.AudioTime()
+	assert.False(t, ok)
+	assert.Equal(t, "a.mp3", GuidedNavigationObject{AudioRef: "a.mp3"}.AudioFile())
+}
+
+func TestGuidedNavigationDocumentAudioDuration(t *testing.T) {
+	doc := GuidedNavigationDocument{
+		Guided: []GuidedNavigationObject{
+			{AudioRef: "a.mp3#t=0,1.5"},
+			{Children: []GuidedNavigationObject{
+				{AudioRef: "a.mp3#t=1.5,4"},
+				{AudioRef: "a.mp3#t=4"},
+			}},
+		},
+	}
+	assert.Equal(t, 4.0, doc.AudioDuration())
+}
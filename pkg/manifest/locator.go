@@ -219,7 +219,7 @@ func (l Locator) MarshalJSON() ([]byte, error) {
 	}
 
 	ll := l.Locations
-	if len(ll.Fragments) > 0 || len(ll.OtherLocations) > 1 || ll.Position != nil || ll.Progression != nil || ll.TotalProgression != nil {
+	if len(ll.Fragments) > 0 || len(ll.OtherLocations) > 0 || ll.Position != nil || ll.Progression != nil || ll.TotalProgression != nil {
 		j["locations"] = ll
 	}
 
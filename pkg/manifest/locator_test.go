@@ -59,6 +59,24 @@ func TestLocatorMinimalJSON(t *testing.T) {
 	}`, string(s), "JSON objects should be equal")
 }
 
+func TestLocatorJSONWithSingleOtherLocation(t *testing.T) {
+	s, err := json.Marshal(&Locator{
+		Href: "http://locator",
+		Type: "text/html",
+		Locations: Locations{
+			OtherLocations: map[string]interface{}{"cssSelector": "#id"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"href": "http://locator",
+		"type": "text/html",
+		"locations": {
+			"cssSelector": "#id"
+		}
+	}`, string(s), "JSON objects should be equal")
+}
+
 func TestLocatorJSON(t *testing.T) {
 	s, err := json.Marshal(&Locator{
 		Href:  "http://locator",
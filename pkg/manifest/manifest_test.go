@@ -489,3 +489,30 @@ func TestManifestLocatorLinkNotFound(t *testing.T) {
 		Href: "/notfound",
 	}))
 }
+
+func TestManifestConformsToProfilesOfReadingOrder(t *testing.T) {
+	manifest := Manifest{
+		Metadata: Metadata{
+			LocalizedTitle: NewLocalizedStringFromString(""),
+		},
+		Links: LinkList{{
+			Href: "/manifest.json",
+			Type: "application/webpub+json",
+			Rels: Strings{"self"},
+		}},
+		ReadingOrder: LinkList{
+			{Href: "/page1.jpg", Type: "image/jpeg"},
+			{Href: "/page2.png", Type: "image/png"},
+		},
+	}
+	assert.True(t, manifest.ConformsTo(ProfileDivina))
+	assert.False(t, manifest.ConformsTo(ProfileAudiobook))
+	assert.False(t, manifest.ConformsTo(ProfilePDF))
+
+	manifest.ReadingOrder = LinkList{{Href: "/track.mp3", Type: "audio/mpeg"}}
+	assert.True(t, manifest.ConformsTo(ProfileAudiobook))
+	assert.False(t, manifest.ConformsTo(ProfileDivina))
+
+	manifest.ReadingOrder = LinkList{{Href: "/doc.pdf", Type: "application/pdf"}}
+	assert.True(t, manifest.ConformsTo(ProfilePDF))
+}
@@ -101,6 +101,28 @@ func (p Properties) Layout() EPUBLayout {
 	return EPUBLayout(p.GetString("layout"))
 }
 
+// Indicates whether the linked resource is part of the linear reading flow of the publication.
+// Resources are linear unless stated otherwise, e.g. for an EPUB spine item with linear="no".
+func (p Properties) Linear() bool {
+	if linear := p.GetBool("linear"); linear != nil {
+		return *linear
+	}
+	return true
+}
+
+// Determines how the services walking through the reading order handle its non-linear resources.
+type NonLinearPolicy uint8
+
+const (
+	NonLinearInclude NonLinearPolicy = iota // Non-linear resources are handled like the other resources of the reading order.
+	NonLinearSkip                           // Non-linear resources are skipped.
+)
+
+// Returns whether the [link] should be skipped according to the policy.
+func (p NonLinearPolicy) Skips(link Link) bool {
+	return p == NonLinearSkip && !link.Properties.Linear()
+}
+
 func (p Properties) Encryption() *Encryption {
 	mp, ok := p.Get("encrypted").(map[string]interface{})
 	if mp == nil || !ok {
@@ -107,3 +107,14 @@ func TestPropertiesSpreadAvailable(t *testing.T) {
 func TestPropertiesSpreadMissing(t *testing.T) {
 	assert.Empty(t, Properties{}.Spread(), "Spread empty when missing")
 }
+
+func TestPropertiesLinear(t *testing.T) {
+	assert.True(t, Properties{}.Linear())
+	assert.True(t, Properties{"linear": true}.Linear())
+	assert.False(t, Properties{"linear": false}.Linear())
+
+	nonLinear := Link{Href: "notes.html", Properties: Properties{"linear": false}}
+	assert.False(t, NonLinearInclude.Skips(nonLinear))
+	assert.True(t, NonLinearSkip.Skips(nonLinear))
+	assert.False(t, NonLinearSkip.Skips(Link{Href: "chapter.html"}))
+}
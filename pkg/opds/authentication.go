@@ -0,0 +1,125 @@
+package opds
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Authentication flows supported by an OPDS catalog.
+const (
+	AuthTypeBasic                  = "http://opds-spec.org/auth/basic"
+	AuthTypeOAuthImplicit          = "http://opds-spec.org/auth/oauth/implicit"
+	AuthTypeOAuthPassword          = "http://opds-spec.org/auth/oauth/password"
+	AuthTypeOAuthClientCredentials = "http://opds-spec.org/auth/oauth/client_credentials"
+)
+
+// Relations of the links of an authentication flow.
+const (
+	RelAuthenticate = "authenticate"
+	RelRefresh      = "refresh"
+	RelLogo         = "logo"
+	RelRegister     = "register"
+	RelHelp         = "help"
+)
+
+// Authentication document, describing how to authenticate with a catalog.
+// https://drafts.opds.io/authentication-for-opds-1.0
+type AuthenticationDocument struct {
+	ID             string            `json:"id"`
+	Title          string            `json:"title"`
+	Description    string            `json:"description,omitempty"`
+	Links          manifest.LinkList `json:"links,omitempty"`
+	Authentication []Authentication  `json:"authentication"`
+}
+
+// Authentication flow supported by a catalog, by order of preference in the [AuthenticationDocument].
+type Authentication struct {
+	Type   string            `json:"type"`
+	Labels *AuthLabels       `json:"labels,omitempty"`
+	Links  manifest.LinkList `json:"links,omitempty"`
+}
+
+// Labels of the fields of a login form, for the basic and OAuth password flows.
+type AuthLabels struct {
+	Login    string `json:"login,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Returns the first authentication flow of the given [authType], or nil if it's not supported.
+func (d AuthenticationDocument) Flow(authType string) *Authentication {
+	for i := range d.Authentication {
+		if d.Authentication[i].Type == authType {
+			return &d.Authentication[i]
+		}
+	}
+	return nil
+}
+
+// Parses an [AuthenticationDocument] from its JSON representation.
+func AuthenticationDocumentFromJSON(rawJson map[string]interface{}, normalizeHref manifest.LinkHrefNormalizer) (*AuthenticationDocument, error) {
+	if rawJson == nil {
+		return nil, nil
+	}
+
+	id, ok := rawJson["id"].(string)
+	if !ok {
+		return nil, errors.New("'id' is required in authentication document")
+	}
+	title, ok := rawJson["title"].(string)
+	if !ok {
+		return nil, errors.New("'title' is required in authentication document")
+	}
+
+	doc := &AuthenticationDocument{
+		ID:          id,
+		Title:       title,
+		Description: optString(rawJson["description"]),
+	}
+	var err error
+	if doc.Links, err = linksFromJSON(rawJson["links"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'links'")
+	}
+
+	rawFlows, ok := rawJson["authentication"].([]interface{})
+	if !ok {
+		return nil, errors.New("'authentication' is required in authentication document")
+	}
+	for i, rawFlow := range rawFlows {
+		object, ok := rawFlow.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		flow := Authentication{Type: optString(object["type"])}
+		if flow.Type == "" {
+			return nil, errors.Errorf("'type' is required in authentication at position %d", i)
+		}
+		if labels, ok := object["labels"].(map[string]interface{}); ok {
+			flow.Labels = &AuthLabels{
+				Login:    optString(labels["login"]),
+				Password: optString(labels["password"]),
+			}
+		}
+		if flow.Links, err = linksFromJSON(object["links"], normalizeHref); err != nil {
+			return nil, errors.Wrapf(err, "failed parsing 'links' of authentication at position %d", i)
+		}
+		doc.Authentication = append(doc.Authentication, flow)
+	}
+
+	return doc, nil
+}
+
+func (d *AuthenticationDocument) UnmarshalJSON(b []byte) error {
+	var object map[string]interface{}
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+	fd, err := AuthenticationDocumentFromJSON(object, manifest.LinkHrefNormalizerIdentity)
+	if err != nil {
+		return err
+	}
+	*d = *fd
+	return nil
+}
@@ -0,0 +1,48 @@
+package opds
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticationDocumentFromJSON(t *testing.T) {
+	data, err := os.ReadFile("./testdata/authentication.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var doc AuthenticationDocument
+	if !assert.NoError(t, json.Unmarshal(data, &doc)) {
+		return
+	}
+
+	assert.Equal(t, "http://example.com/auth.json", doc.ID)
+	assert.Equal(t, "Public Library", doc.Title)
+	assert.Equal(t, "http://example.com/logo.jpg", doc.Links.FirstWithRel(RelLogo).Href)
+
+	assert.Equal(t, []Authentication{
+		{
+			Type:   AuthTypeOAuthPassword,
+			Labels: &AuthLabels{Login: "Library card", Password: "PIN"},
+			Links: manifest.LinkList{
+				{Href: "http://example.com/oauth", Type: "application/json", Rels: manifest.Strings{RelAuthenticate}},
+			},
+		},
+		{
+			Type:   AuthTypeBasic,
+			Labels: &AuthLabels{Login: "Library card", Password: "PIN"},
+		},
+	}, doc.Authentication)
+
+	assert.Equal(t, AuthTypeBasic, doc.Flow(AuthTypeBasic).Type)
+	assert.Nil(t, doc.Flow(AuthTypeOAuthImplicit))
+}
+
+func TestAuthenticationDocumentRequiresFlows(t *testing.T) {
+	var doc AuthenticationDocument
+	assert.Error(t, json.Unmarshal([]byte(`{"id": "auth", "title": "Library"}`), &doc))
+	assert.Error(t, json.Unmarshal([]byte(`{"id": "auth", "title": "Library", "authentication": [{}]}`), &doc))
+}
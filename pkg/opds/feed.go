@@ -0,0 +1,237 @@
+// Package opds models OPDS 1 and OPDS 2 catalogs, parses them and converts OPDS 1 feeds to OPDS 2.
+//
+// https://drafts.opds.io/opds-2.0
+// https://specs.opds.io/opds-1.2
+package opds
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// OPDS 2 feed, listing publications, navigation links and facets.
+// https://drafts.opds.io/schema/feed.schema.json
+type Feed struct {
+	Metadata     FeedMetadata      `json:"metadata"`
+	Links        manifest.LinkList `json:"links"`
+	Navigation   manifest.LinkList `json:"navigation,omitempty"`
+	Facets       []Facet           `json:"facets,omitempty"`
+	Groups       []Group           `json:"groups,omitempty"`
+	Publications []Publication     `json:"publications,omitempty"`
+}
+
+// Metadata of a feed, a group or a facet.
+// https://drafts.opds.io/schema/feed-metadata.schema.json
+type FeedMetadata struct {
+	Identifier    string     `json:"identifier,omitempty"`
+	Type          string     `json:"@type,omitempty"`
+	Title         string     `json:"title"`
+	Subtitle      string     `json:"subtitle,omitempty"`
+	Modified      *time.Time `json:"modified,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	ItemsPerPage  *int       `json:"itemsPerPage,omitempty"`
+	CurrentPage   *int       `json:"currentPage,omitempty"`
+	NumberOfItems *int       `json:"numberOfItems,omitempty"`
+
+	OtherMetadata map[string]interface{} `json:"-"` // Extension point for other metadata.
+}
+
+// Group of publications or navigation links in a feed, e.g. a curated selection on the home page of a catalog.
+type Group struct {
+	Metadata     FeedMetadata      `json:"metadata"`
+	Links        manifest.LinkList `json:"links,omitempty"`
+	Navigation   manifest.LinkList `json:"navigation,omitempty"`
+	Publications []Publication     `json:"publications,omitempty"`
+}
+
+// Facet of a feed, with the links to the filtered feeds.
+// The link of the active facet has a "self" relation.
+type Facet struct {
+	Metadata FeedMetadata      `json:"metadata"`
+	Links    manifest.LinkList `json:"links"`
+}
+
+// Parses a [Feed] from its OPDS 2 JSON representation.
+// The [links]' href and their children's will be normalized recursively using the provided [normalizeHref] closure.
+func FeedFromJSON(rawJson map[string]interface{}, normalizeHref manifest.LinkHrefNormalizer) (*Feed, error) {
+	if rawJson == nil {
+		return nil, nil
+	}
+
+	rawMetadata, ok := rawJson["metadata"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("'metadata' is required in feed")
+	}
+	metadata, err := FeedMetadataFromJSON(rawMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'metadata'")
+	}
+
+	feed := &Feed{Metadata: *metadata}
+	if feed.Links, err = linksFromJSON(rawJson["links"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'links'")
+	}
+	if feed.Navigation, err = linksFromJSON(rawJson["navigation"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'navigation'")
+	}
+	if feed.Publications, err = publicationsFromJSON(rawJson["publications"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'publications'")
+	}
+
+	if rawFacets, ok := rawJson["facets"].([]interface{}); ok {
+		for i, rawFacet := range rawFacets {
+			group, err := groupFromJSON(rawFacet, normalizeHref)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed parsing facet at position %d", i)
+			}
+			feed.Facets = append(feed.Facets, Facet{Metadata: group.Metadata, Links: group.Links})
+		}
+	}
+	if rawGroups, ok := rawJson["groups"].([]interface{}); ok {
+		for i, rawGroup := range rawGroups {
+			group, err := groupFromJSON(rawGroup, normalizeHref)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed parsing group at position %d", i)
+			}
+			feed.Groups = append(feed.Groups, *group)
+		}
+	}
+
+	return feed, nil
+}
+
+func (f *Feed) UnmarshalJSON(b []byte) error {
+	var object map[string]interface{}
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+	ff, err := FeedFromJSON(object, manifest.LinkHrefNormalizerIdentity)
+	if err != nil {
+		return err
+	}
+	*f = *ff
+	return nil
+}
+
+func groupFromJSON(rawJson interface{}, normalizeHref manifest.LinkHrefNormalizer) (*Group, error) {
+	object, ok := rawJson.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("group is not a JSON object")
+	}
+	rawMetadata, ok := object["metadata"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("'metadata' is required in group")
+	}
+	metadata, err := FeedMetadataFromJSON(rawMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'metadata'")
+	}
+
+	group := &Group{Metadata: *metadata}
+	if group.Links, err = linksFromJSON(object["links"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'links'")
+	}
+	if group.Navigation, err = linksFromJSON(object["navigation"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'navigation'")
+	}
+	if group.Publications, err = publicationsFromJSON(object["publications"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'publications'")
+	}
+	return group, nil
+}
+
+func linksFromJSON(rawJson interface{}, normalizeHref manifest.LinkHrefNormalizer) (manifest.LinkList, error) {
+	rawLinks, ok := rawJson.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return manifest.LinksFromJSONArray(rawLinks, normalizeHref)
+}
+
+// Parses a [FeedMetadata] from its OPDS 2 JSON representation.
+func FeedMetadataFromJSON(rawJson map[string]interface{}) (*FeedMetadata, error) {
+	if rawJson == nil {
+		return nil, nil
+	}
+
+	title, ok := rawJson["title"].(string)
+	if !ok {
+		return nil, errors.New("'title' is required in metadata")
+	}
+
+	metadata := &FeedMetadata{
+		Title:         title,
+		Identifier:    optString(rawJson["identifier"]),
+		Type:          optString(rawJson["@type"]),
+		Subtitle:      optString(rawJson["subtitle"]),
+		Description:   optString(rawJson["description"]),
+		ItemsPerPage:  optPositiveInt(rawJson["itemsPerPage"]),
+		CurrentPage:   optPositiveInt(rawJson["currentPage"]),
+		NumberOfItems: optPositiveInt(rawJson["numberOfItems"]),
+		Modified:      optTime(rawJson["modified"]),
+	}
+
+	for k, v := range rawJson {
+		switch k {
+		case "title", "identifier", "@type", "subtitle", "description", "itemsPerPage", "currentPage", "numberOfItems", "modified":
+			continue
+		}
+		if metadata.OtherMetadata == nil {
+			metadata.OtherMetadata = make(map[string]interface{})
+		}
+		metadata.OtherMetadata[k] = v
+	}
+
+	return metadata, nil
+}
+
+func (m *FeedMetadata) UnmarshalJSON(b []byte) error {
+	var object map[string]interface{}
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+	fm, err := FeedMetadataFromJSON(object)
+	if err != nil {
+		return err
+	}
+	*m = *fm
+	return nil
+}
+
+func (m FeedMetadata) MarshalJSON() ([]byte, error) {
+	j := make(map[string]interface{})
+	for k, v := range m.OtherMetadata {
+		j[k] = v
+	}
+	j["title"] = m.Title
+	if m.Identifier != "" {
+		j["identifier"] = m.Identifier
+	}
+	if m.Type != "" {
+		j["@type"] = m.Type
+	}
+	if m.Subtitle != "" {
+		j["subtitle"] = m.Subtitle
+	}
+	if m.Modified != nil {
+		j["modified"] = m.Modified.Format(time.RFC3339)
+	}
+	if m.Description != "" {
+		j["description"] = m.Description
+	}
+	if m.ItemsPerPage != nil {
+		j["itemsPerPage"] = *m.ItemsPerPage
+	}
+	if m.CurrentPage != nil {
+		j["currentPage"] = *m.CurrentPage
+	}
+	if m.NumberOfItems != nil {
+		j["numberOfItems"] = *m.NumberOfItems
+	}
+	return json.Marshal(j)
+}
@@ -0,0 +1,89 @@
+package opds
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadOPDS2Feed(t *testing.T) *Feed {
+	data, err := os.ReadFile("./testdata/opds2-feed.json")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	feed, err := ParseFeed(data, "http://example.com/new")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return feed
+}
+
+func TestFeedFromJSON(t *testing.T) {
+	feed := loadOPDS2Feed(t)
+
+	modified := time.Date(2016, 9, 20, 12, 0, 0, 0, time.UTC)
+	total, perPage, page := 2, 50, 1
+	assert.Equal(t, FeedMetadata{
+		Title:         "Example listing publications",
+		Modified:      &modified,
+		NumberOfItems: &total,
+		ItemsPerPage:  &perPage,
+		CurrentPage:   &page,
+	}, feed.Metadata)
+
+	assert.Equal(t, "http://example.com/search{?query}", feed.Links[1].Href)
+	assert.True(t, feed.Links[1].Templated)
+	assert.Equal(t, "http://example.com/new", feed.Navigation[0].Href)
+	assert.Equal(t, 10, *Properties(feed.Navigation[0].Properties).NumberOfItems())
+
+	if assert.Len(t, feed.Facets, 1) {
+		assert.Equal(t, "Language", feed.Facets[0].Metadata.Title)
+		assert.Equal(t, "English", feed.Facets[0].Links.FirstWithRel("self").Title)
+	}
+	if assert.Len(t, feed.Groups, 1) && assert.Len(t, feed.Groups[0].Publications, 1) {
+		p := feed.Groups[0].Publications[0]
+		assert.Equal(t, "Moby-Dick", p.Metadata.Title())
+		assert.Equal(t, "http://example.com/moby-dick.jpg", p.Images[0].Href)
+	}
+}
+
+func TestPublicationAcquisitionProperties(t *testing.T) {
+	feed := loadOPDS2Feed(t)
+	if !assert.Len(t, feed.Publications, 1) {
+		return
+	}
+	acquisitions := feed.Publications[0].AcquisitionLinks()
+	if !assert.Len(t, acquisitions, 1) {
+		return
+	}
+
+	p := Properties(acquisitions[0].Properties)
+	three, one, five, zero := 3, 1, 5, 0
+	until := time.Date(2016, 10, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, &Price{Currency: "EUR", Value: 0}, p.Price())
+	assert.Equal(t, []Acquisition{{Type: "application/epub+zip"}}, p.IndirectAcquisitions())
+	assert.Equal(t, &Holds{Total: &three, Position: &one}, p.Holds())
+	assert.Equal(t, &Copies{Total: &five, Available: &zero}, p.Copies())
+	assert.Equal(t, &Availability{State: AvailabilityReserved, Until: &until}, p.Availability())
+	assert.Equal(t, &manifest.Link{Href: "/auth.json", Type: "application/opds-authentication+json"}, p.Authenticate())
+}
+
+func TestFeedJSONRoundTrip(t *testing.T) {
+	feed := loadOPDS2Feed(t)
+
+	data, err := json.Marshal(feed)
+	assert.NoError(t, err)
+	var parsed Feed
+	assert.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, *feed, parsed)
+}
+
+func TestFeedMetadataRequiresTitle(t *testing.T) {
+	var feed Feed
+	assert.Error(t, json.Unmarshal([]byte(`{"metadata": {}, "links": []}`), &feed))
+	assert.Error(t, json.Unmarshal([]byte(`{"links": []}`), &feed))
+}
@@ -0,0 +1,406 @@
+package opds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+const (
+	NamespaceAtom       = "http://www.w3.org/2005/Atom"
+	NamespaceOPDS       = "http://opds-spec.org/2010/catalog"
+	NamespaceDCTerms    = "http://purl.org/dc/terms/"
+	NamespaceDC         = "http://purl.org/dc/elements/1.1/"
+	NamespaceOpenSearch = "http://a9.com/-/spec/opensearch/1.1/"
+	NamespaceThread     = "http://purl.org/syndication/thread/1.0"
+)
+
+type atomFeed struct {
+	XMLName      xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID           string      `xml:"http://www.w3.org/2005/Atom id"`
+	Title        string      `xml:"http://www.w3.org/2005/Atom title"`
+	Subtitle     string      `xml:"http://www.w3.org/2005/Atom subtitle"`
+	Updated      string      `xml:"http://www.w3.org/2005/Atom updated"`
+	TotalResults *int        `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	ItemsPerPage *int        `xml:"http://a9.com/-/spec/opensearch/1.1/ itemsPerPage"`
+	StartIndex   *int        `xml:"http://a9.com/-/spec/opensearch/1.1/ startIndex"`
+	Links        []atomLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Entries      []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	XMLName       xml.Name       `xml:"http://www.w3.org/2005/Atom entry"`
+	ID            string         `xml:"http://www.w3.org/2005/Atom id"`
+	Title         string         `xml:"http://www.w3.org/2005/Atom title"`
+	Updated       string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Published     string         `xml:"http://www.w3.org/2005/Atom published"`
+	Summary       atomText       `xml:"http://www.w3.org/2005/Atom summary"`
+	Content       atomText       `xml:"http://www.w3.org/2005/Atom content"`
+	Authors       []atomPerson   `xml:"http://www.w3.org/2005/Atom author"`
+	Contributors  []atomPerson   `xml:"http://www.w3.org/2005/Atom contributor"`
+	Categories    []atomCategory `xml:"http://www.w3.org/2005/Atom category"`
+	Links         []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	Identifiers   []string       `xml:"http://purl.org/dc/terms/ identifier"`
+	Languages     []string       `xml:"http://purl.org/dc/terms/ language"`
+	Publishers    []string       `xml:"http://purl.org/dc/terms/ publisher"`
+	Issued        string         `xml:"http://purl.org/dc/terms/ issued"`
+	DCIdentifiers []string       `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+	DCLanguages   []string       `xml:"http://purl.org/dc/elements/1.1/ language"`
+	DCPublishers  []string       `xml:"http://purl.org/dc/elements/1.1/ publisher"`
+	DCIssued      string         `xml:"http://purl.org/dc/elements/1.1/ issued"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// Returns the text, or the markup of an XHTML text construct.
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+type atomPerson struct {
+	Name string `xml:"http://www.w3.org/2005/Atom name"`
+	URI  string `xml:"http://www.w3.org/2005/Atom uri"`
+}
+
+type atomCategory struct {
+	Term   string `xml:"term,attr"`
+	Label  string `xml:"label,attr"`
+	Scheme string `xml:"scheme,attr"`
+}
+
+type atomLink struct {
+	Href                 string                    `xml:"href,attr"`
+	Rel                  string                    `xml:"rel,attr"`
+	Type                 string                    `xml:"type,attr"`
+	Title                string                    `xml:"title,attr"`
+	FacetGroup           string                    `xml:"http://opds-spec.org/2010/catalog facetGroup,attr"`
+	ActiveFacet          bool                      `xml:"http://opds-spec.org/2010/catalog activeFacet,attr"`
+	Count                *int                      `xml:"http://purl.org/syndication/thread/1.0 count,attr"`
+	Prices               []atomPrice               `xml:"http://opds-spec.org/2010/catalog price"`
+	IndirectAcquisitions []atomIndirectAcquisition `xml:"http://opds-spec.org/2010/catalog indirectAcquisition"`
+}
+
+type atomPrice struct {
+	CurrencyCode string `xml:"currencycode,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type atomIndirectAcquisition struct {
+	Type     string                    `xml:"type,attr"`
+	Children []atomIndirectAcquisition `xml:"http://opds-spec.org/2010/catalog indirectAcquisition"`
+}
+
+// Parses a feed from either its OPDS 1 (Atom) or OPDS 2 (JSON) representation.
+// The relative hrefs are resolved against the [feedURL] of the feed, if provided.
+func ParseFeed(data []byte, feedURL string) (*Feed, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var object map[string]interface{}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, errors.Wrap(err, "failed parsing OPDS 2 feed")
+		}
+		return FeedFromJSON(object, hrefResolver(feedURL))
+	}
+	return ParseOPDS1(data, feedURL)
+}
+
+// Parses an OPDS 1 feed and converts it to an OPDS 2 [Feed].
+// The relative hrefs are resolved against the [feedURL] of the feed, if provided.
+//
+// Entries with an acquisition link are converted to publications, and the other ones to navigation links.
+// Entries belonging to a collection (OPDS 1.2 grouping) are gathered in groups, and the facet links in facets.
+func ParseOPDS1(data []byte, feedURL string) (*Feed, error) {
+	var af atomFeed
+	if err := xml.Unmarshal(data, &af); err != nil {
+		return nil, errors.Wrap(err, "failed parsing OPDS 1 feed")
+	}
+	resolve := hrefResolver(feedURL)
+
+	feed := &Feed{
+		Metadata: FeedMetadata{
+			Identifier:    strings.TrimSpace(af.ID),
+			Title:         strings.TrimSpace(af.Title),
+			Subtitle:      strings.TrimSpace(af.Subtitle),
+			Modified:      parseDate(af.Updated),
+			NumberOfItems: af.TotalResults,
+			ItemsPerPage:  af.ItemsPerPage,
+		},
+	}
+	if af.StartIndex != nil && af.ItemsPerPage != nil && *af.ItemsPerPage > 0 {
+		page := (*af.StartIndex-1) / *af.ItemsPerPage + 1
+		feed.Metadata.CurrentPage = &page
+	}
+
+	// Links and facets, keeping the order of the facet groups
+	for _, al := range af.Links {
+		link := al.toLink(resolve)
+		if al.Rel != RelFacet {
+			feed.Links = append(feed.Links, link)
+			continue
+		}
+
+		link.Rels = nil
+		if al.ActiveFacet {
+			link.Rels = manifest.Strings{"self"}
+		}
+		if al.Count != nil {
+			link.Properties = manifest.Properties{"numberOfItems": *al.Count}
+		}
+		i := 0
+		for ; i < len(feed.Facets); i++ {
+			if feed.Facets[i].Metadata.Title == al.FacetGroup {
+				break
+			}
+		}
+		if i == len(feed.Facets) {
+			feed.Facets = append(feed.Facets, Facet{Metadata: FeedMetadata{Title: al.FacetGroup}})
+		}
+		feed.Facets[i].Links = append(feed.Facets[i].Links, link)
+	}
+
+	for _, entry := range af.Entries {
+		publication, navigation := entry.convert(resolve)
+
+		// Entries of a collection are gathered in a group
+		var group *Group
+		for _, al := range entry.Links {
+			if al.Rel != RelCollection {
+				continue
+			}
+			href := resolvedHref(resolve, al.Href)
+			for i := range feed.Groups {
+				if self := feed.Groups[i].Links.FirstWithRel("self"); self != nil && self.Href == href {
+					group = &feed.Groups[i]
+					break
+				}
+			}
+			if group == nil {
+				feed.Groups = append(feed.Groups, Group{
+					Metadata: FeedMetadata{Title: al.Title},
+					Links:    manifest.LinkList{{Href: href, Type: al.Type, Rels: manifest.Strings{"self"}}},
+				})
+				group = &feed.Groups[len(feed.Groups)-1]
+			}
+			break
+		}
+
+		switch {
+		case publication != nil && group != nil:
+			group.Publications = append(group.Publications, *publication)
+		case publication != nil:
+			feed.Publications = append(feed.Publications, *publication)
+		case navigation != nil && group != nil:
+			group.Navigation = append(group.Navigation, *navigation)
+		case navigation != nil:
+			feed.Navigation = append(feed.Navigation, *navigation)
+		}
+	}
+
+	return feed, nil
+}
+
+// Parses an OPDS 1 entry document and converts it to an OPDS 2 [Publication].
+// The relative hrefs are resolved against the [entryURL] of the entry, if provided.
+func ParseOPDS1Entry(data []byte, entryURL string) (*Publication, error) {
+	var entry atomEntry
+	if err := xml.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Wrap(err, "failed parsing OPDS 1 entry")
+	}
+	publication, _ := entry.convert(hrefResolver(entryURL))
+	if publication == nil {
+		return nil, errors.New("OPDS 1 entry is not a publication")
+	}
+	return publication, nil
+}
+
+// Converts the entry to a publication if it has acquisition links, or to a navigation link otherwise.
+func (e atomEntry) convert(resolve manifest.LinkHrefNormalizer) (*Publication, *manifest.Link) {
+	isPublication := false
+	for _, al := range e.Links {
+		if IsAcquisitionRel(al.Rel) {
+			isPublication = true
+			break
+		}
+	}
+
+	if !isPublication {
+		for _, al := range e.Links {
+			if al.Rel == RelCollection || al.Href == "" {
+				continue
+			}
+			link := al.toLink(resolve)
+			link.Title = strings.TrimSpace(e.Title)
+			if al.Count != nil {
+				link.Properties = manifest.Properties{"numberOfItems": *al.Count}
+			}
+			return nil, &link
+		}
+		return nil, nil
+	}
+
+	metadata := manifest.Metadata{
+		Identifier:     strings.TrimSpace(e.ID),
+		LocalizedTitle: manifest.NewLocalizedStringFromString(strings.TrimSpace(e.Title)),
+		Modified:       parseDate(e.Updated),
+		Published:      parseDate(firstNonEmpty(e.Issued, e.DCIssued, e.Published)),
+		Languages:      append(e.Languages[:len(e.Languages):len(e.Languages)], e.DCLanguages...),
+		Description:    e.Summary.String(),
+	}
+	if identifier := firstNonEmpty(append(e.Identifiers[:len(e.Identifiers):len(e.Identifiers)], e.DCIdentifiers...)...); identifier != "" {
+		metadata.Identifier = strings.TrimSpace(identifier)
+	}
+	if metadata.Description == "" {
+		metadata.Description = e.Content.String()
+	}
+	for _, author := range e.Authors {
+		metadata.Authors = append(metadata.Authors, author.toContributor(resolve))
+	}
+	for _, contributor := range e.Contributors {
+		metadata.Contributors = append(metadata.Contributors, contributor.toContributor(resolve))
+	}
+	for _, publisher := range append(e.Publishers[:len(e.Publishers):len(e.Publishers)], e.DCPublishers...) {
+		metadata.Publishers = append(metadata.Publishers, manifest.Contributor{
+			LocalizedName: manifest.NewLocalizedStringFromString(strings.TrimSpace(publisher)),
+		})
+	}
+	for _, category := range e.Categories {
+		name := category.Label
+		if name == "" {
+			name = category.Term
+		}
+		if name == "" {
+			continue
+		}
+		metadata.Subjects = append(metadata.Subjects, manifest.Subject{
+			LocalizedName: manifest.NewLocalizedStringFromString(name),
+			Code:          category.Term,
+			Scheme:        category.Scheme,
+		})
+	}
+
+	publication := &Publication{Metadata: metadata}
+	for _, al := range e.Links {
+		if al.Rel == RelCollection {
+			continue
+		}
+		link := al.toLink(resolve)
+		if al.Rel == RelImage || al.Rel == RelThumbnail || al.Rel == "http://opds-spec.org/cover" || al.Rel == "http://opds-spec.org/thumbnail" {
+			publication.Images = append(publication.Images, link)
+			continue
+		}
+		publication.Links = append(publication.Links, link)
+	}
+	return publication, nil
+}
+
+func (p atomPerson) toContributor(resolve manifest.LinkHrefNormalizer) manifest.Contributor {
+	contributor := manifest.Contributor{
+		LocalizedName: manifest.NewLocalizedStringFromString(strings.TrimSpace(p.Name)),
+	}
+	if uri := strings.TrimSpace(p.URI); uri != "" {
+		contributor.Links = []manifest.Link{{Href: resolvedHref(resolve, uri)}}
+	}
+	return contributor
+}
+
+func (al atomLink) toLink(resolve manifest.LinkHrefNormalizer) manifest.Link {
+	link := manifest.Link{
+		Href:  resolvedHref(resolve, al.Href),
+		Type:  al.Type,
+		Title: al.Title,
+	}
+	if al.Rel != "" {
+		link.Rels = manifest.Strings{al.Rel}
+	}
+
+	properties := manifest.Properties{}
+	for _, p := range al.Prices {
+		value, err := strconv.ParseFloat(strings.TrimSpace(p.Value), 64)
+		if err != nil || p.CurrencyCode == "" {
+			continue
+		}
+		properties["price"] = Price{Currency: p.CurrencyCode, Value: value}.toJSON()
+		break
+	}
+	if len(al.IndirectAcquisitions) > 0 {
+		acquisitions := make([]interface{}, 0, len(al.IndirectAcquisitions))
+		for _, ia := range al.IndirectAcquisitions {
+			acquisitions = append(acquisitions, ia.toAcquisition().toJSON())
+		}
+		properties["indirectAcquisition"] = acquisitions
+	}
+	if len(properties) > 0 {
+		link.Properties = properties
+	}
+	return link
+}
+
+func (ia atomIndirectAcquisition) toAcquisition() Acquisition {
+	acquisition := Acquisition{Type: ia.Type}
+	for _, child := range ia.Children {
+		acquisition.Children = append(acquisition.Children, child.toAcquisition())
+	}
+	return acquisition
+}
+
+// Returns a normalizer resolving the hrefs relative to the [base] URL.
+func hrefResolver(base string) manifest.LinkHrefNormalizer {
+	baseURL, err := url.Parse(base)
+	if base == "" || err != nil {
+		return manifest.LinkHrefNormalizerIdentity
+	}
+	return func(href string) (string, error) {
+		// URI templates (e.g. search links) are not valid URLs, so only the
+		// part before the first expression is resolved.
+		href, template, _ := strings.Cut(strings.TrimSpace(href), "{")
+		if template != "" {
+			template = "{" + template
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			return href + template, nil
+		}
+		return baseURL.ResolveReference(ref).String() + template, nil
+	}
+}
+
+func resolvedHref(resolve manifest.LinkHrefNormalizer, href string) string {
+	resolved, err := resolve(href)
+	if err != nil {
+		return href
+	}
+	return resolved
+}
+
+// Parses the dates found in OPDS 1 feeds, which can be a full timestamp or only a year, e.g. for dcterms:issued.
+func parseDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
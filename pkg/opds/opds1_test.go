@@ -0,0 +1,151 @@
+package opds
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadOPDS1Feed(t *testing.T) *Feed {
+	data, err := os.ReadFile("./testdata/opds1-feed.xml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	feed, err := ParseOPDS1(data, "https://example.com/opds-catalogs/unpopular.xml")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return feed
+}
+
+func TestOPDS1FeedMetadata(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	modified := time.Date(2010, 1, 10, 10, 1, 11, 0, time.UTC)
+	total, perPage, page := 45, 20, 2
+	assert.Equal(t, FeedMetadata{
+		Identifier:    "urn:uuid:433a5d6a-0b8c-4933-af65-4ca4f02763eb",
+		Title:         "Unpopular Publications",
+		Modified:      &modified,
+		NumberOfItems: &total,
+		ItemsPerPage:  &perPage,
+		CurrentPage:   &page,
+	}, feed.Metadata)
+}
+
+func TestOPDS1FeedLinksAreResolved(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	assert.Equal(t, manifest.LinkList{
+		{Href: "https://example.com/opds-catalogs/unpopular.xml?page=2", Rels: manifest.Strings{"self"}, Type: "application/atom+xml;profile=opds-catalog;kind=acquisition"},
+		{Href: "https://example.com/opds-catalogs/root.xml", Rels: manifest.Strings{"start"}, Type: "application/atom+xml;profile=opds-catalog;kind=navigation"},
+		{Href: "https://example.com/opds-catalogs/unpopular.xml?page=3", Rels: manifest.Strings{"next"}, Type: "application/atom+xml;profile=opds-catalog;kind=acquisition"},
+	}, feed.Links)
+}
+
+func TestOPDS1FeedFacets(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	assert.Equal(t, []Facet{
+		{
+			Metadata: FeedMetadata{Title: "Language"},
+			Links: manifest.LinkList{
+				{Href: "https://example.com/opds-catalogs/unpopular.xml?lang=en", Title: "English", Rels: manifest.Strings{"self"}, Properties: manifest.Properties{"numberOfItems": 12}},
+				{Href: "https://example.com/opds-catalogs/unpopular.xml?lang=fr", Title: "French", Properties: manifest.Properties{"numberOfItems": 33}},
+			},
+		},
+		{
+			Metadata: FeedMetadata{Title: "Sort"},
+			Links: manifest.LinkList{
+				{Href: "https://example.com/opds-catalogs/unpopular.xml?sort=new", Title: "Newest"},
+			},
+		},
+	}, feed.Facets)
+}
+
+func TestOPDS1FeedNavigation(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	if assert.Len(t, feed.Navigation, 1) {
+		nav := feed.Navigation[0]
+		assert.Equal(t, "Popular Publications", nav.Title)
+		assert.Equal(t, "https://example.com/opds-catalogs/popular.xml", nav.Href)
+		assert.Equal(t, 120, *Properties(nav.Properties).NumberOfItems())
+	}
+}
+
+func TestOPDS1FeedPublication(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	if !assert.Len(t, feed.Publications, 1) {
+		return
+	}
+	p := feed.Publications[0]
+
+	published := time.Date(1917, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Bob, Son of Bob", p.Metadata.Title())
+	assert.Equal(t, "urn:isbn:9780000000001", p.Metadata.Identifier)
+	assert.Equal(t, manifest.Strings{"en"}, p.Metadata.Languages)
+	assert.Equal(t, &published, p.Metadata.Published)
+	assert.Equal(t, "Bob the Recursive", p.Metadata.Authors[0].Name())
+	assert.Equal(t, "https://example.com/authors/bob", p.Metadata.Authors[0].Links[0].Href)
+	assert.Equal(t, "Bob Press", p.Metadata.Publishers[0].Name())
+	assert.Equal(t, "Men's Adventure", p.Metadata.Subjects[0].Name())
+	assert.Equal(t, "FIC020000", p.Metadata.Subjects[0].Code)
+	assert.Equal(t, "The story of the son of the Bob and the gallant part he played in the lives of a man and a woman.", p.Metadata.Description)
+
+	assert.Equal(t, []string{"https://example.com/covers/4561.lrg.png", "https://example.com/covers/4561.thmb.gif"}, []string{p.Images[0].Href, p.Images[1].Href})
+
+	acquisitions := p.AcquisitionLinks()
+	if assert.Len(t, acquisitions, 2) {
+		buy := Properties(acquisitions[0].Properties)
+		assert.Equal(t, &Price{Currency: "USD", Value: 18.99}, buy.Price())
+		assert.Equal(t, []Acquisition{{Type: "application/epub+zip"}}, buy.IndirectAcquisitions())
+
+		borrow := Properties(acquisitions[1].Properties)
+		assert.Nil(t, borrow.Price())
+		assert.Equal(t, []Acquisition{{
+			Type:     "application/vnd.adobe.adept+xml",
+			Children: []Acquisition{{Type: "application/epub+zip"}},
+		}}, borrow.IndirectAcquisitions())
+	}
+}
+
+func TestOPDS1FeedGroups(t *testing.T) {
+	feed := loadOPDS1Feed(t)
+	if !assert.Len(t, feed.Groups, 1) {
+		return
+	}
+	group := feed.Groups[0]
+	assert.Equal(t, "Classics", group.Metadata.Title)
+	assert.Equal(t, "https://example.com/opds-catalogs/classics.xml", group.Links.FirstWithRel("self").Href)
+	if assert.Len(t, group.Publications, 1) {
+		assert.Equal(t, "Modern Classics", group.Publications[0].Metadata.Title())
+		assert.Equal(t, `<div xmlns="http://www.w3.org/1999/xhtml">A <b>free</b> classic.</div>`, group.Publications[0].Metadata.Description)
+	}
+}
+
+func TestOPDS1Entry(t *testing.T) {
+	data, err := os.ReadFile("./testdata/opds1-entry.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	p, err := ParseOPDS1Entry(data, "https://example.com/entries/garden-party.xml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	published := time.Date(1922, 2, 23, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "The Garden Party", p.Metadata.Title())
+	assert.Equal(t, "urn:uuid:0c5a2b3c-9f4e-4b7e-8a1d-2b7a9c8d1e2f", p.Metadata.Identifier)
+	assert.Equal(t, &published, p.Metadata.Published)
+	assert.Equal(t, manifest.LinkList{{
+		Href: "https://example.com/entries/garden-party.epub",
+		Type: "application/epub+zip",
+		Rels: manifest.Strings{RelAcquisition},
+	}}, p.Links)
+}
+
+func TestOPDS1InvalidDocument(t *testing.T) {
+	_, err := ParseOPDS1([]byte(`<entry xmlns="http://www.w3.org/2005/Atom"/>`), "")
+	assert.Error(t, err)
+	_, err = ParseOPDS1Entry([]byte(`<entry xmlns="http://www.w3.org/2005/Atom"><title>Navigation</title></entry>`), "")
+	assert.Error(t, err)
+}
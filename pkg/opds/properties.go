@@ -0,0 +1,201 @@
+package opds
+
+import (
+	"strings"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Relations of the OPDS acquisition links.
+const (
+	RelAcquisition           = "http://opds-spec.org/acquisition"
+	RelAcquisitionOpenAccess = "http://opds-spec.org/acquisition/open-access"
+	RelAcquisitionBorrow     = "http://opds-spec.org/acquisition/borrow"
+	RelAcquisitionBuy        = "http://opds-spec.org/acquisition/buy"
+	RelAcquisitionSample     = "http://opds-spec.org/acquisition/sample"
+	RelAcquisitionPreview    = "preview"
+	RelAcquisitionSubscribe  = "http://opds-spec.org/acquisition/subscribe"
+)
+
+// Other relations specific to OPDS.
+const (
+	RelFacet      = "http://opds-spec.org/facet"
+	RelImage      = "http://opds-spec.org/image"
+	RelThumbnail  = "http://opds-spec.org/image/thumbnail"
+	RelShelf      = "http://opds-spec.org/shelf"
+	RelCollection = "collection"
+)
+
+// Returns whether the link relation is an OPDS acquisition.
+func IsAcquisitionRel(rel string) bool {
+	return strings.HasPrefix(rel, RelAcquisition) || rel == RelAcquisitionPreview
+}
+
+// Properties of the links of OPDS feeds.
+// https://drafts.opds.io/schema/properties.schema.json
+//
+// Use Properties(link.Properties) to read the OPDS properties of a [manifest.Link].
+type Properties manifest.Properties
+
+// Number of items in the feed targeted by the link, e.g. for a facet or a navigation link.
+func (p Properties) NumberOfItems() *int {
+	return optPositiveInt(p["numberOfItems"])
+}
+
+// Price of the publication, for a buy or borrow acquisition link.
+func (p Properties) Price() *Price {
+	return priceFromJSON(p["price"])
+}
+
+// Types of the resources obtained indirectly from the acquisition link, e.g. an EPUB protected by an LCP license.
+func (p Properties) IndirectAcquisitions() []Acquisition {
+	return acquisitionsFromJSON(p["indirectAcquisition"])
+}
+
+// Holds on the publication, for a borrow acquisition link.
+func (p Properties) Holds() *Holds {
+	object, ok := p["holds"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return &Holds{
+		Total:    optPositiveInt(object["total"]),
+		Position: optPositiveInt(object["position"]),
+	}
+}
+
+// Copies of the publication available for lending, for a borrow acquisition link.
+func (p Properties) Copies() *Copies {
+	object, ok := p["copies"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return &Copies{
+		Total:     optPositiveInt(object["total"]),
+		Available: optPositiveInt(object["available"]),
+	}
+}
+
+// Availability of the publication through the acquisition link.
+func (p Properties) Availability() *Availability {
+	object, ok := p["availability"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	state, ok := object["state"].(string)
+	if !ok {
+		return nil
+	}
+	return &Availability{
+		State: AvailabilityState(state),
+		Since: optTime(object["since"]),
+		Until: optTime(object["until"]),
+	}
+}
+
+// Link to the authentication document needed to follow the link.
+func (p Properties) Authenticate() *manifest.Link {
+	object, ok := p["authenticate"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	link, err := manifest.LinkFromJSON(object, nil)
+	if err != nil {
+		return nil
+	}
+	return link
+}
+
+// Price of a publication.
+type Price struct {
+	Currency string  `json:"currency"` // ISO 4217 currency code, e.g. "EUR".
+	Value    float64 `json:"value"`
+}
+
+func priceFromJSON(rawJson interface{}) *Price {
+	object, ok := rawJson.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	currency, _ := object["currency"].(string)
+	value := optFloat64(object["value"])
+	if currency == "" || value == nil || *value < 0 {
+		return nil
+	}
+	return &Price{Currency: currency, Value: *value}
+}
+
+func (p Price) toJSON() map[string]interface{} {
+	return map[string]interface{}{"currency": p.Currency, "value": p.Value}
+}
+
+// Indirect acquisition of a publication, whose [Type] is the media type of the intermediate resource.
+// The [Children] are the types of the resources obtained from it.
+type Acquisition struct {
+	Type     string        `json:"type"`
+	Children []Acquisition `json:"child,omitempty"`
+}
+
+func acquisitionsFromJSON(rawJson interface{}) []Acquisition {
+	rawAcquisitions, ok := rawJson.([]interface{})
+	if !ok {
+		return nil
+	}
+	var acquisitions []Acquisition
+	for _, rawAcquisition := range rawAcquisitions {
+		object, ok := rawAcquisition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typ, _ := object["type"].(string)
+		if typ == "" {
+			continue
+		}
+		acquisitions = append(acquisitions, Acquisition{
+			Type:     typ,
+			Children: acquisitionsFromJSON(object["child"]),
+		})
+	}
+	return acquisitions
+}
+
+func (a Acquisition) toJSON() map[string]interface{} {
+	j := map[string]interface{}{"type": a.Type}
+	if len(a.Children) > 0 {
+		children := make([]interface{}, len(a.Children))
+		for i, child := range a.Children {
+			children[i] = child.toJSON()
+		}
+		j["child"] = children
+	}
+	return j
+}
+
+// Holds on a publication, when it can't be borrowed immediately.
+type Holds struct {
+	Total    *int `json:"total,omitempty"`
+	Position *int `json:"position,omitempty"`
+}
+
+// Copies of a publication which can be borrowed.
+type Copies struct {
+	Total     *int `json:"total,omitempty"`
+	Available *int `json:"available,omitempty"`
+}
+
+type AvailabilityState string
+
+const (
+	AvailabilityAvailable   AvailabilityState = "available"
+	AvailabilityUnavailable AvailabilityState = "unavailable"
+	AvailabilityReserved    AvailabilityState = "reserved"
+	AvailabilityReady       AvailabilityState = "ready"
+)
+
+// Availability of a publication, with the dates when it will change.
+type Availability struct {
+	State AvailabilityState `json:"state"`
+	Since *time.Time        `json:"since,omitempty"`
+	Until *time.Time        `json:"until,omitempty"`
+}
@@ -0,0 +1,90 @@
+package opds
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Publication listed in an OPDS 2 feed, with its acquisition links and cover images.
+// https://drafts.opds.io/schema/publication.schema.json
+type Publication struct {
+	Metadata manifest.Metadata `json:"metadata"`
+	Links    manifest.LinkList `json:"links"`
+	Images   manifest.LinkList `json:"images,omitempty"`
+}
+
+// Acquisition links of the publication, e.g. to buy, borrow or download it.
+func (p Publication) AcquisitionLinks() manifest.LinkList {
+	var links manifest.LinkList
+	for _, link := range p.Links {
+		for _, rel := range link.Rels {
+			if IsAcquisitionRel(rel) {
+				links = append(links, link)
+				break
+			}
+		}
+	}
+	return links
+}
+
+// Parses a [Publication] from its OPDS 2 JSON representation.
+// The [links]' href and their children's will be normalized recursively using the provided [normalizeHref] closure.
+func PublicationFromJSON(rawJson map[string]interface{}, normalizeHref manifest.LinkHrefNormalizer) (*Publication, error) {
+	if rawJson == nil {
+		return nil, nil
+	}
+
+	rawMetadata, ok := rawJson["metadata"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("'metadata' is required in publication")
+	}
+	metadata, err := manifest.MetadataFromJSON(rawMetadata, normalizeHref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'metadata'")
+	}
+
+	publication := &Publication{Metadata: *metadata}
+	if publication.Links, err = linksFromJSON(rawJson["links"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'links'")
+	}
+	if publication.Images, err = linksFromJSON(rawJson["images"], normalizeHref); err != nil {
+		return nil, errors.Wrap(err, "failed parsing 'images'")
+	}
+	return publication, nil
+}
+
+func (p *Publication) UnmarshalJSON(b []byte) error {
+	var object map[string]interface{}
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+	fp, err := PublicationFromJSON(object, manifest.LinkHrefNormalizerIdentity)
+	if err != nil {
+		return err
+	}
+	*p = *fp
+	return nil
+}
+
+func publicationsFromJSON(rawJson interface{}, normalizeHref manifest.LinkHrefNormalizer) ([]Publication, error) {
+	rawPublications, ok := rawJson.([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	publications := make([]Publication, 0, len(rawPublications))
+	for i, rawPublication := range rawPublications {
+		object, ok := rawPublication.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		publication, err := PublicationFromJSON(object, normalizeHref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parsing publication at position %d", i)
+		}
+		publications = append(publications, *publication)
+	}
+	return publications, nil
+}
@@ -0,0 +1,45 @@
+package opds
+
+import "time"
+
+func optString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func optFloat64(v interface{}) *float64 {
+	f, ok := v.(float64)
+	if !ok {
+		return nil
+	}
+	return &f
+}
+
+// Parses a positive integer, either decoded from JSON as a float64 or set as an int.
+func optPositiveInt(v interface{}) *int {
+	var i int
+	switch n := v.(type) {
+	case float64:
+		i = int(n)
+	case int:
+		i = n
+	default:
+		return nil
+	}
+	if i < 0 {
+		return nil
+	}
+	return &i
+}
+
+func optTime(v interface{}) *time.Time {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
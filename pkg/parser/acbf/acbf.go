@@ -0,0 +1,340 @@
+package acbf
+
+import (
+	"encoding/xml"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Advanced Comic Book Format document.
+// Elements are matched by their local name, so that the 1.0, 1.1 and 1.2 namespaces are all supported.
+// https://acbf.fandom.com/wiki/Advanced_Comic_Book_Format_Wiki
+type Document struct {
+	XMLName     xml.Name    `xml:"ACBF"`
+	BookInfo    BookInfo    `xml:"meta-data>book-info"`
+	PublishInfo PublishInfo `xml:"meta-data>publish-info"`
+	Pages       []Page      `xml:"body>page"`
+}
+
+type BookInfo struct {
+	Authors          []Author        `xml:"author"`
+	Titles           []LocalizedText `xml:"book-title"`
+	Genres           []string        `xml:"genre"`
+	Annotations      []Annotation    `xml:"annotation"`
+	Keywords         []LocalizedText `xml:"keywords"`
+	CoverPage        *Page           `xml:"coverpage"`
+	Languages        []TextLayer     `xml:"languages>text-layer"`
+	Sequences        []Sequence      `xml:"sequence"`
+	ReadingDirection string          `xml:"reading-direction"` // Introduced in ACBF 1.2, either LTR or RTL.
+}
+
+type PublishInfo struct {
+	Publisher   string `xml:"publisher"`
+	PublishDate struct {
+		Value string `xml:"value,attr"`
+		Text  string `xml:",chardata"`
+	} `xml:"publish-date"`
+	ISBN string `xml:"isbn"`
+}
+
+type Author struct {
+	Activity   string `xml:"activity,attr"`
+	Language   string `xml:"lang,attr"`
+	FirstName  string `xml:"first-name"`
+	MiddleName string `xml:"middle-name"`
+	LastName   string `xml:"last-name"`
+	Nickname   string `xml:"nickname"`
+}
+
+// Full name of the author, falling back on its nickname.
+func (a Author) Name() string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{a.FirstName, a.MiddleName, a.LastName} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return strings.TrimSpace(a.Nickname)
+	}
+	return strings.Join(parts, " ")
+}
+
+type LocalizedText struct {
+	Language string `xml:"lang,attr"`
+	Text     string `xml:",chardata"`
+}
+
+type Annotation struct {
+	Language   string   `xml:"lang,attr"`
+	Paragraphs []string `xml:"p"`
+}
+
+type TextLayer struct {
+	Language  string     `xml:"lang,attr"`
+	Show      string     `xml:"show,attr"`
+	TextAreas []TextArea `xml:"text-area"`
+}
+
+type TextArea struct {
+	Points     string   `xml:"points,attr"`
+	Type       string   `xml:"type,attr"`
+	Paragraphs []string `xml:"p"`
+}
+
+// Text of the area, with its paragraphs joined by a space.
+func (a TextArea) Text() string {
+	ps := make([]string, 0, len(a.Paragraphs))
+	for _, p := range a.Paragraphs {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			ps = append(ps, p)
+		}
+	}
+	return strings.Join(ps, " ")
+}
+
+type Sequence struct {
+	Title  string `xml:"title,attr"`
+	Volume string `xml:"volume,attr"`
+	Number string `xml:",chardata"`
+}
+
+type Page struct {
+	Titles     []LocalizedText `xml:"title"`
+	Image      Image           `xml:"image"`
+	TextLayers []TextLayer     `xml:"text-layer"`
+	Frames     []Frame         `xml:"frame"`
+}
+
+type Image struct {
+	Href string `xml:"href,attr"`
+}
+
+// A frame (panel) of a page, delimited by a polygon.
+type Frame struct {
+	Points string `xml:"points,attr"`
+}
+
+// Axis-aligned rectangle, in pixels of the page's image.
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// Media fragment identifying the rectangle in an image.
+// https://www.w3.org/TR/media-frags/#naming-space
+func (r Rect) Fragment() string {
+	return "xywh=" + strconv.Itoa(r.X) + "," + strconv.Itoa(r.Y) + "," + strconv.Itoa(r.Width) + "," + strconv.Itoa(r.Height)
+}
+
+func (r Rect) contains(x, y float64) bool {
+	return x >= float64(r.X) && x <= float64(r.X+r.Width) && y >= float64(r.Y) && y <= float64(r.Y+r.Height)
+}
+
+// Parses a list of points such as "10,10 200,10 200,300 10,300" and returns their bounding box.
+func ParsePoints(points string) (*Rect, error) {
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	n := 0
+	for _, point := range strings.Fields(points) {
+		x, y, ok := strings.Cut(point, ",")
+		if !ok {
+			return nil, errors.Errorf("invalid point %q", point)
+		}
+		fx, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid point %q", point)
+		}
+		fy, err := strconv.ParseFloat(y, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid point %q", point)
+		}
+		minX, maxX = math.Min(minX, fx), math.Max(maxX, fx)
+		minY, maxY = math.Min(minY, fy), math.Max(maxY, fy)
+		n++
+	}
+	if n < 2 {
+		return nil, errors.New("at least two points are needed to delimit an area")
+	}
+	x, y := int(math.Floor(minX)), int(math.Floor(minY))
+	return &Rect{
+		X:      x,
+		Y:      y,
+		Width:  int(math.Ceil(maxX)) - x,
+		Height: int(math.Ceil(maxY)) - y,
+	}, nil
+}
+
+// Parses an ACBF document.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed parsing ACBF document")
+	}
+	return &doc, nil
+}
+
+// All the pages of the comic, starting with the cover page if there is one.
+func (d Document) AllPages() []Page {
+	pages := make([]Page, 0, len(d.Pages)+1)
+	if d.BookInfo.CoverPage != nil && d.BookInfo.CoverPage.Image.Href != "" {
+		pages = append(pages, *d.BookInfo.CoverPage)
+	}
+	return append(pages, d.Pages...)
+}
+
+// Language of the comic, which is the first declared text layer.
+func (d Document) Language() string {
+	for _, l := range d.BookInfo.Languages {
+		if l.Language != "" {
+			return l.Language
+		}
+	}
+	return ""
+}
+
+// Title of the page in the given language, falling back on the first title.
+func (p Page) Title(language string) string {
+	for _, t := range p.Titles {
+		if t.Language == language {
+			return strings.TrimSpace(t.Text)
+		}
+	}
+	if len(p.Titles) > 0 {
+		return strings.TrimSpace(p.Titles[0].Text)
+	}
+	return ""
+}
+
+// Text layer of the page in the given language, falling back on the first one.
+func (p Page) TextLayer(language string) *TextLayer {
+	for i := range p.TextLayers {
+		if p.TextLayers[i].Language == language {
+			return &p.TextLayers[i]
+		}
+	}
+	if len(p.TextLayers) > 0 {
+		return &p.TextLayers[0]
+	}
+	return nil
+}
+
+func localizedString(texts []LocalizedText) *manifest.LocalizedString {
+	translations := make(map[string]string, len(texts))
+	for _, t := range texts {
+		if v := strings.TrimSpace(t.Text); v != "" {
+			translations[t.Language] = v
+		}
+	}
+	if len(translations) == 0 {
+		return nil
+	}
+	ls := manifest.NewLocalizedStringFromStrings(translations)
+	return &ls
+}
+
+// Builds the RWPM metadata of the comic. The fallback title is used when the document has none.
+func (d Document) Metadata(fallbackTitle string) manifest.Metadata {
+	info := d.BookInfo
+	m := manifest.Metadata{}
+
+	if title := localizedString(info.Titles); title != nil {
+		m.LocalizedTitle = *title
+	} else {
+		m.LocalizedTitle = manifest.NewLocalizedStringFromString(fallbackTitle)
+	}
+
+	for _, a := range info.Authors {
+		name := a.Name()
+		if name == "" {
+			continue
+		}
+		c := manifest.Contributor{LocalizedName: manifest.NewLocalizedStringFromString(name)}
+		switch strings.ToLower(a.Activity) {
+		case "", "writer", "adapter":
+			m.Authors = append(m.Authors, c)
+		case "artist", "coverartist", "photographer":
+			m.Artists = append(m.Artists, c)
+		case "penciller":
+			m.Pencilers = append(m.Pencilers, c)
+		case "inker":
+			m.Inkers = append(m.Inkers, c)
+		case "colorist":
+			m.Colorists = append(m.Colorists, c)
+		case "letterer":
+			m.Letterers = append(m.Letterers, c)
+		case "translator":
+			m.Translators = append(m.Translators, c)
+		case "editor", "assistanteditor":
+			m.Editors = append(m.Editors, c)
+		default:
+			c.Roles = manifest.Strings{a.Activity}
+			m.Contributors = append(m.Contributors, c)
+		}
+	}
+
+	for _, g := range info.Genres {
+		if g = strings.TrimSpace(g); g != "" {
+			m.Subjects = append(m.Subjects, manifest.Subject{
+				LocalizedName: manifest.NewLocalizedStringFromString(g),
+				Scheme:        "https://acbf.fandom.com/wiki/Meta-data_Section_Definition#Genre",
+			})
+		}
+	}
+
+	if len(info.Annotations) > 0 {
+		var paragraphs []string
+		for _, p := range info.Annotations[0].Paragraphs {
+			if p = strings.TrimSpace(p); p != "" {
+				paragraphs = append(paragraphs, p)
+			}
+		}
+		m.Description = strings.Join(paragraphs, "\n")
+	}
+
+	for _, l := range info.Languages {
+		if l.Language != "" && !slices.Contains(m.Languages, l.Language) {
+			m.Languages = append(m.Languages, l.Language)
+		}
+	}
+
+	for _, s := range info.Sequences {
+		if s.Title == "" {
+			continue
+		}
+		c := manifest.Collection{LocalizedName: manifest.NewLocalizedStringFromString(s.Title)}
+		if pos, err := strconv.ParseFloat(strings.TrimSpace(s.Number), 64); err == nil {
+			c.Position = &pos
+		}
+		if m.BelongsTo == nil {
+			m.BelongsTo = make(map[string]manifest.Collections)
+		}
+		m.BelongsTo["series"] = append(m.BelongsTo["series"], c)
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(info.ReadingDirection)) {
+	case "RTL":
+		m.ReadingProgression = manifest.RTL
+	case "LTR":
+		m.ReadingProgression = manifest.LTR
+	}
+
+	if p := strings.TrimSpace(d.PublishInfo.Publisher); p != "" {
+		m.Publishers = manifest.Contributors{{LocalizedName: manifest.NewLocalizedStringFromString(p)}}
+	}
+	if v := d.PublishInfo.PublishDate.Value; v != "" {
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			m.Published = &t
+		}
+	}
+	if isbn := strings.TrimSpace(d.PublishInfo.ISBN); isbn != "" {
+		m.Identifier = "urn:isbn:" + isbn
+	}
+
+	return m
+}
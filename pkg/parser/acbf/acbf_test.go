@@ -0,0 +1,106 @@
+package acbf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadDocument(t *testing.T, name string) *Document {
+	data, err := os.ReadFile("./testdata/" + name + ".acbf")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	doc, err := Parse(data)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return doc
+}
+
+func TestACBFMetadata(t *testing.T) {
+	m := loadDocument(t, "sample").Metadata("fallback")
+
+	assert.Equal(t, manifest.NewLocalizedStringFromStrings(map[string]string{
+		"en": "The Sample Comic",
+		"fr": "La bande dessinée exemple",
+	}), m.LocalizedTitle)
+	assert.Equal(t, "Jane Doe", m.Authors[0].Name())
+	assert.Equal(t, "Pencil", m.Pencilers[0].Name())
+	assert.Equal(t, "John Q. Public", m.Colorists[0].Name())
+	assert.Len(t, m.Subjects, 2)
+	assert.Equal(t, "science_fiction", m.Subjects[0].Name())
+	assert.Equal(t, "A short comic used to test the ACBF parser.\nIt has three pages.", m.Description)
+	assert.Equal(t, manifest.Strings{"en", "fr"}, m.Languages)
+	assert.Equal(t, manifest.RTL, m.ReadingProgression)
+	assert.Equal(t, "Samples", m.BelongsTo["series"][0].Name())
+	assert.Equal(t, 2.0, *m.BelongsTo["series"][0].Position)
+	assert.Equal(t, "Readium", m.Publishers[0].Name())
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), *m.Published)
+	assert.Equal(t, "urn:isbn:9780000000002", m.Identifier)
+}
+
+func TestACBFFallbackTitle(t *testing.T) {
+	doc, err := Parse([]byte(`<ACBF xmlns="http://www.fictionbook-lib.org/xml/acbf/1.0"><body/></ACBF>`))
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", doc.Metadata("fallback").Title())
+}
+
+func TestACBFPages(t *testing.T) {
+	pages := loadDocument(t, "sample").AllPages()
+	if assert.Len(t, pages, 4) {
+		assert.Equal(t, "cover.png", pages[0].Image.Href)
+		assert.Equal(t, "01.png", pages[1].Image.Href)
+		assert.Equal(t, "Première page", pages[1].Title("fr"))
+		assert.Equal(t, "First page", pages[1].Title("de"))
+		assert.Equal(t, "", pages[3].Title("en"))
+	}
+}
+
+func TestACBFParsePoints(t *testing.T) {
+	r, err := ParsePoints("10,20 110,20.5 109.2,220 10,220")
+	assert.NoError(t, err)
+	assert.Equal(t, Rect{X: 10, Y: 20, Width: 100, Height: 200}, *r)
+	assert.Equal(t, "xywh=10,20,100,200", r.Fragment())
+
+	_, err = ParsePoints("10,20")
+	assert.Error(t, err)
+	_, err = ParsePoints("10;20 30;40")
+	assert.Error(t, err)
+}
+
+func TestACBFGuides(t *testing.T) {
+	guides, order := Guides(loadDocument(t, "sample"), "/comic/sample.acbf")
+	assert.Equal(t, []string{"comic/01.png", "comic/02.png"}, order)
+	assert.Equal(t, []manifest.GuidedNavigationObject{
+		{ImgRef: "comic/01.png#xywh=0,0,100,70", Text: "Hello there!"},
+		{ImgRef: "comic/01.png#xywh=0,70,100,80", Text: "Who are you?"},
+	}, guides["comic/01.png"])
+}
+
+func TestACBFGuidedNavigationService(t *testing.T) {
+	s := &GuidedNavigationService{}
+	s.guides, s.order = Guides(loadDocument(t, "sample"), "/sample.acbf")
+
+	assert.True(t, s.HasGuideForResource("01.png"))
+	assert.False(t, s.HasGuideForResource("03.png"))
+
+	doc, err := s.GuideForResource("01.png")
+	assert.NoError(t, err)
+	if assert.Len(t, doc.Links, 1) {
+		assert.Equal(t, "/~readium/guided-navigation.json?ref=02.png", doc.Links[0].Href)
+		assert.Equal(t, manifest.Strings{"next"}, doc.Links[0].Rels)
+	}
+
+	res, ok := s.Get(manifest.Link{Href: "/~readium/guided-navigation.json?ref=02.png"})
+	assert.True(t, ok)
+	str, rerr := res.ReadAsString()
+	assert.Nil(t, rerr)
+	assert.JSONEq(t, `{
+		"links": [{"href": "/~readium/guided-navigation.json?ref=01.png", "type": "application/guided-navigation+json", "rel": "prev"}],
+		"guided": [{"imgref": "02.png#xywh=0,0,200,150"}]
+	}`, str)
+}
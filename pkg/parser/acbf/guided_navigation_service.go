@@ -0,0 +1,136 @@
+package acbf
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/readium/go-toolkit/pkg/util"
+)
+
+// Computes the guided navigation objects of each page of the document with frames, indexed by the href
+// of the page's image (without leading slash). The page images are resolved relative to the ACBF file's [baseHref].
+func Guides(doc *Document, baseHref string) (map[string][]manifest.GuidedNavigationObject, []string) {
+	language := doc.Language()
+	guides := make(map[string][]manifest.GuidedNavigationObject)
+	var order []string
+	for _, page := range doc.AllPages() {
+		if len(page.Frames) == 0 || page.Image.Href == "" {
+			continue
+		}
+		href, err := util.NewHREF(page.Image.Href, baseHref).String()
+		if err != nil {
+			continue
+		}
+		href = strings.TrimPrefix(href, "/")
+
+		// Text areas are assigned to the frame containing their center
+		var areas []TextArea
+		if layer := page.TextLayer(language); layer != nil {
+			areas = layer.TextAreas
+		}
+
+		objects := make([]manifest.GuidedNavigationObject, 0, len(page.Frames))
+		for _, frame := range page.Frames {
+			rect, err := ParsePoints(frame.Points)
+			if err != nil || rect.Width == 0 || rect.Height == 0 {
+				continue
+			}
+			o := manifest.GuidedNavigationObject{
+				ImgRef: href + "#" + rect.Fragment(),
+			}
+			var texts []string
+			for _, area := range areas {
+				ar, err := ParsePoints(area.Points)
+				if err != nil {
+					continue
+				}
+				if rect.contains(float64(ar.X)+float64(ar.Width)/2, float64(ar.Y)+float64(ar.Height)/2) {
+					if t := area.Text(); t != "" {
+						texts = append(texts, t)
+					}
+				}
+			}
+			o.Text = strings.Join(texts, " ")
+			objects = append(objects, o)
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		if _, ok := guides[href]; !ok {
+			order = append(order, href)
+		}
+		guides[href] = objects
+	}
+	return guides, order
+}
+
+// Creates a [GuidedNavigationService] exposing the frames (panels) of an ACBF document.
+// Returns nil if none of the pages has frames.
+func GuidedNavigationFactory(doc *Document, baseHref string) pub.ServiceFactory {
+	return func(context pub.Context) pub.Service {
+		guides, order := Guides(doc, baseHref)
+		if len(guides) == 0 {
+			return nil
+		}
+		return &GuidedNavigationService{
+			guides: guides,
+			order:  order,
+		}
+	}
+}
+
+// GuidedNavigationService implements pub.GuidedNavigationService
+// Provides panel-by-panel navigation for the pages of a comic described by an ACBF document.
+type GuidedNavigationService struct {
+	guides map[string][]manifest.GuidedNavigationObject
+	order  []string
+}
+
+func (s *GuidedNavigationService) Close() {
+	clear(s.guides)
+	clear(s.order)
+}
+
+func (s *GuidedNavigationService) Links() manifest.LinkList {
+	return manifest.LinkList{pub.GuidedNavigationLink}
+}
+
+func (s *GuidedNavigationService) HasGuideForResource(href string) bool {
+	_, ok := s.guides[href]
+	return ok
+}
+
+func (s *GuidedNavigationService) GuideForResource(href string) (*manifest.GuidedNavigationDocument, error) {
+	objects, ok := s.guides[href]
+	if !ok {
+		return nil, nil
+	}
+	doc := &manifest.GuidedNavigationDocument{
+		Guided: objects,
+	}
+
+	// Link to the previous and next pages having frames
+	idx := slices.Index(s.order, href)
+	if idx > 0 {
+		l := pub.GuidedNavigationLink.ExpandTemplate(map[string]string{
+			"ref": s.order[idx-1],
+		})
+		l.Rels = append(l.Rels, "prev")
+		doc.Links = append(doc.Links, l)
+	}
+	if idx < len(s.order)-1 {
+		l := pub.GuidedNavigationLink.ExpandTemplate(map[string]string{
+			"ref": s.order[idx+1],
+		})
+		l.Rels = append(l.Rels, "next")
+		doc.Links = append(doc.Links, l)
+	}
+	return doc, nil
+}
+
+func (s *GuidedNavigationService) Get(link manifest.Link) (fetcher.Resource, bool) {
+	return pub.GetForGuidedNavigationService(s, link)
+}
@@ -13,6 +13,9 @@ const (
 	NamespaceSMIL  = "http://www.w3.org/ns/SMIL"
 	NamespaceSMIL2 = "http://www.w3.org/2001/SMIL20/"
 	NamespaceNCX   = "http://www.daisy.org/z3986/2005/ncx/"
+
+	NamespaceRendition = "http://www.idpf.org/2013/rendition"
+	NamespaceMetadata  = "http://www.idpf.org/2013/metadata"
 )
 
 // Vocabularies
@@ -0,0 +1,246 @@
+package epub
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/xmlquery"
+)
+
+const mediaTypeOPF = "application/oebps-package+xml"
+
+// OCF container of an EPUB, declaring its renditions in META-INF/container.xml.
+// https://www.w3.org/TR/epub-multi-rend-11/
+type Container struct {
+	Renditions []Rendition        // Renditions of the publication, the first one being the default rendition.
+	Metadata   *ContainerMetadata // Publication-level metadata from META-INF/metadata.xml, if any.
+}
+
+// A rendition of the publication, i.e. a rootfile of the container with its selection attributes.
+type Rendition struct {
+	Path        string              // Path to the package document, relative to the root of the container.
+	MediaType   string              // Media type of the rootfile.
+	Layout      manifest.EPUBLayout // rendition:layout
+	Language    string              // rendition:language
+	AccessModes []string            // rendition:accessMode
+	Label       string              // rendition:label
+	Media       string              // rendition:media, a CSS media query.
+}
+
+// Returns whether the rootfile of the rendition is a package document. The other rootfiles, e.g. a PDF version
+// of the publication, can't be parsed. A rootfile without a media type is assumed to be a package document.
+func (r Rendition) IsPackageDocument() bool {
+	mt, _, _ := strings.Cut(r.MediaType, ";")
+	mt = strings.TrimSpace(mt)
+	return mt == "" || strings.EqualFold(mt, mediaTypeOPF)
+}
+
+// Absolute HREF of the package document of the rendition.
+func (r Rendition) Href() string {
+	if strings.HasPrefix(r.Path, "/") {
+		return r.Path
+	}
+	return "/" + r.Path
+}
+
+// Link to the package document of the rendition, to be exposed as an alternate of the selected one.
+func (r Rendition) Link() manifest.Link {
+	link := manifest.Link{
+		Href:  r.Href(),
+		Type:  mediaTypeOPF,
+		Title: r.Label,
+		Rels:  manifest.Strings{"alternate"},
+	}
+	if r.Language != "" {
+		link.Languages = manifest.Strings{r.Language}
+	}
+	properties := make(manifest.Properties)
+	if r.Layout != "" {
+		properties["layout"] = string(r.Layout)
+	}
+	if len(r.AccessModes) > 0 {
+		properties["accessModes"] = r.AccessModes
+	}
+	if r.Media != "" {
+		properties["media"] = r.Media
+	}
+	if len(properties) > 0 {
+		link.Properties = properties
+	}
+	return link
+}
+
+// Publication-level metadata shared by all the renditions.
+type ContainerMetadata struct {
+	Identifier string
+	Title      string
+	Languages  []string
+}
+
+// Preferences used to select a rendition of an EPUB with multiple renditions.
+// Languages have precedence over the access modes, which have precedence over the layout.
+// When no rendition matches any preference, the default (first) rendition is used.
+type RenditionSelection struct {
+	Layout      manifest.EPUBLayout // Preferred layout.
+	Languages   []string            // Preferred languages, by decreasing order of preference.
+	AccessModes []string            // Preferred access modes, e.g. "textual" for users of screen readers.
+}
+
+// Reads the OCF container of the publication.
+func ParseContainer(f fetcher.Fetcher) (*Container, error) {
+	n, err := f.Get(manifest.Link{Href: "/META-INF/container.xml"}).ReadAsXML(map[string]string{
+		NamespaceOPC:       "cn",
+		NamespaceRendition: "rendition",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed loading container.xml")
+	}
+
+	c := &Container{}
+	for _, rootfile := range n.SelectElements("/container/rootfiles/rootfile") {
+		path := rootfile.SelectAttr("full-path")
+		if path == "" {
+			continue
+		}
+		r := Rendition{
+			Path:        path,
+			MediaType:   rootfile.SelectAttr("media-type"),
+			Language:    strings.TrimSpace(rootfile.SelectAttr("rendition:language")),
+			AccessModes: strings.Fields(rootfile.SelectAttr("rendition:accessMode")),
+			Label:       strings.TrimSpace(rootfile.SelectAttr("rendition:label")),
+			Media:       strings.TrimSpace(rootfile.SelectAttr("rendition:media")),
+		}
+		switch rootfile.SelectAttr("rendition:layout") {
+		case "pre-paginated":
+			r.Layout = manifest.EPUBLayoutFixed
+		case "reflowable":
+			r.Layout = manifest.EPUBLayoutReflowable
+		}
+		c.Renditions = append(c.Renditions, r)
+	}
+	if len(c.Renditions) == 0 {
+		if n.SelectElement("/container/rootfiles/rootfile") != nil {
+			return nil, errors.New("no full-path in rootfile")
+		}
+		return nil, errors.New("rootfile not found in container")
+	}
+
+	c.Metadata = parseContainerMetadata(f)
+	return c, nil
+}
+
+func parseContainerMetadata(f fetcher.Fetcher) *ContainerMetadata {
+	n, err := f.Get(manifest.Link{Href: "/META-INF/metadata.xml"}).ReadAsXML(map[string]string{
+		NamespaceMetadata: "md",
+		NamespaceDC:       "dc",
+	})
+	if err != nil {
+		return nil
+	}
+	root := n.SelectElement("/metadata")
+	if root == nil {
+		return nil
+	}
+
+	m := &ContainerMetadata{}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != xmlquery.ElementNode || c.NamespaceURI != NamespaceDC {
+			continue
+		}
+		value := strings.TrimSpace(c.InnerText())
+		if value == "" {
+			continue
+		}
+		switch c.Data {
+		case "identifier":
+			if m.Identifier == "" {
+				m.Identifier = value
+			}
+		case "title":
+			if m.Title == "" {
+				m.Title = value
+			}
+		case "language":
+			m.Languages = append(m.Languages, value)
+		}
+	}
+	return m
+}
+
+// Default rendition of the publication, which is the first package document declared in the container.
+func (c Container) DefaultRendition() Rendition {
+	for _, r := range c.Renditions {
+		if r.IsPackageDocument() {
+			return r
+		}
+	}
+	return c.Renditions[0]
+}
+
+// Returns the rendition whose package document is located at [path].
+func (c Container) Rendition(path string) (Rendition, bool) {
+	path = strings.TrimPrefix(path, "/")
+	for _, r := range c.Renditions {
+		if strings.TrimPrefix(r.Path, "/") == path {
+			return r, true
+		}
+	}
+	return Rendition{}, false
+}
+
+// Selects the rendition best matching the [selection] preferences, among the package documents.
+// A nil [selection] always selects the default rendition.
+func (c Container) Select(selection *RenditionSelection) Rendition {
+	best := c.DefaultRendition()
+	if selection == nil {
+		return best
+	}
+
+	bestScore := 0
+	for _, r := range c.Renditions {
+		if !r.IsPackageDocument() {
+			continue
+		}
+		if score := selection.score(r); score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+	return best
+}
+
+func (s RenditionSelection) score(r Rendition) int {
+	score := 0
+	if r.Language != "" {
+		// An exact match of a language is better than a match of its primary subtag only (e.g. "en" for "en-US"),
+		// which is itself better than any match of a less preferred language.
+		for i, l := range s.Languages {
+			weight := 2000 * (len(s.Languages) - i)
+			if strings.EqualFold(l, r.Language) {
+				score += weight
+				break
+			}
+			if strings.EqualFold(primaryLanguage(l), primaryLanguage(r.Language)) {
+				score += weight - 1000
+				break
+			}
+		}
+	}
+	for _, mode := range s.AccessModes {
+		for _, rmode := range r.AccessModes {
+			if strings.EqualFold(mode, rmode) {
+				score += 10
+			}
+		}
+	}
+	if s.Layout != "" && s.Layout == r.Layout {
+		score += 1
+	}
+	return score
+}
+
+func primaryLanguage(tag string) string {
+	primary, _, _ := strings.Cut(tag, "-")
+	return primary
+}
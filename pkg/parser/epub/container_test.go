@@ -0,0 +1,143 @@
+package epub
+
+import (
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadContainer(t *testing.T) *Container {
+	c, err := ParseContainer(fetcher.NewFileFetcher("/", "./testdata/renditions"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return c
+}
+
+func TestContainerRenditions(t *testing.T) {
+	c := loadContainer(t)
+
+	assert.Equal(t, []Rendition{
+		{
+			Path:        "EPUB/fixed.opf",
+			MediaType:   "application/oebps-package+xml",
+			Layout:      manifest.EPUBLayoutFixed,
+			Language:    "en-US",
+			AccessModes: []string{"visual"},
+			Label:       "Fixed layout",
+		},
+		{
+			Path:        "EPUB/reflowable.opf",
+			MediaType:   "application/oebps-package+xml",
+			Layout:      manifest.EPUBLayoutReflowable,
+			Language:    "en-US",
+			AccessModes: []string{"textual", "visual"},
+			Label:       "Reflowable",
+		},
+		{
+			Path:        "EPUB/french.opf",
+			MediaType:   "application/oebps-package+xml",
+			Layout:      manifest.EPUBLayoutReflowable,
+			Language:    "fr",
+			AccessModes: []string{"textual"},
+			Media:       "(min-width: 600px)",
+		},
+	}, c.Renditions)
+
+	assert.Equal(t, &ContainerMetadata{
+		Identifier: "urn:uuid:6e2f6e3c-3f6b-4a4b-9a3e-6f1d3c2b1a00",
+		Title:      "Container title",
+		Languages:  []string{"en-US"},
+	}, c.Metadata)
+
+	path, err := GetRootFilePath(fetcher.NewFileFetcher("/", "./testdata/renditions"))
+	assert.NoError(t, err)
+	assert.Equal(t, "EPUB/fixed.opf", path)
+}
+
+func TestContainerRenditionSelection(t *testing.T) {
+	c := loadContainer(t)
+
+	assert.Equal(t, "EPUB/fixed.opf", c.Select(nil).Path)
+	assert.Equal(t, "EPUB/fixed.opf", c.Select(&RenditionSelection{}).Path)
+	assert.Equal(t, "EPUB/reflowable.opf", c.Select(&RenditionSelection{
+		Layout: manifest.EPUBLayoutReflowable,
+	}).Path)
+	assert.Equal(t, "EPUB/french.opf", c.Select(&RenditionSelection{
+		Languages: []string{"fr-CA", "en"},
+	}).Path, "a primary language match of a preferred language wins")
+	assert.Equal(t, "EPUB/reflowable.opf", c.Select(&RenditionSelection{
+		Languages:   []string{"en"},
+		AccessModes: []string{"textual"},
+	}).Path)
+	assert.Equal(t, "EPUB/fixed.opf", c.Select(&RenditionSelection{
+		Languages: []string{"de"},
+		Layout:    manifest.EPUBLayoutFixed,
+	}).Path)
+}
+
+func TestContainerSkipsOtherRootfiles(t *testing.T) {
+	f := fetcher.NewFileFetcher("/", "./testdata/renditions-pdf")
+	c, err := ParseContainer(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, c.Renditions, 3)
+	assert.False(t, c.Renditions[0].IsPackageDocument())
+
+	assert.Equal(t, "EPUB/english.opf", c.DefaultRendition().Path)
+	assert.Equal(t, "EPUB/english.opf", c.Select(nil).Path)
+	assert.Equal(t, "EPUB/english.opf", c.Select(&RenditionSelection{Layout: manifest.EPUBLayoutFixed}).Path)
+	assert.Equal(t, "EPUB/french.opf", c.Select(&RenditionSelection{Languages: []string{"fr"}}).Path)
+
+	path, err := GetRootFilePath(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "EPUB/english.opf", path)
+
+	_, err = NewParser(nil).ParseRendition(asset.FileWithMediaType("book.epub", &mediatype.EPUB), f, "book.pdf")
+	assert.Error(t, err)
+}
+
+func TestParserRenditions(t *testing.T) {
+	a := asset.FileWithMediaType("renditions.epub", &mediatype.EPUB)
+	f := fetcher.NewFileFetcher("/", "./testdata/renditions")
+
+	b, err := NewParserWithConfig(ParserConfig{
+		RenditionSelection: &RenditionSelection{Layout: manifest.EPUBLayoutReflowable},
+	}).Parse(a, f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	m := b.Build().Manifest
+	assert.Equal(t, "/EPUB/reflowable/chapter.xhtml", m.ReadingOrder[0].Href)
+	assert.Equal(t, "urn:uuid:reflowable", m.Metadata.Identifier)
+	assert.Equal(t, "Container title", m.Metadata.Title())
+	assert.Equal(t, manifest.Link{
+		Href:      "/EPUB/fixed.opf",
+		Type:      "application/oebps-package+xml",
+		Title:     "Fixed layout",
+		Rels:      manifest.Strings{"alternate"},
+		Languages: manifest.Strings{"en-US"},
+		Properties: manifest.Properties{
+			"layout":      "fixed",
+			"accessModes": []string{"visual"},
+		},
+	}, *m.Links.FirstWithHref("/EPUB/fixed.opf"))
+	assert.NotNil(t, m.Links.FirstWithHref("/EPUB/french.opf"))
+	assert.Nil(t, m.Links.FirstWithHref("/EPUB/reflowable.opf"))
+
+	b, err = NewParser(nil).ParseRendition(a, f, "/EPUB/french.opf")
+	if !assert.NoError(t, err) {
+		return
+	}
+	m = b.Build().Manifest
+	assert.Equal(t, "/EPUB/french/chapter.xhtml", m.ReadingOrder[0].Href)
+	assert.Equal(t, "urn:uuid:6e2f6e3c-3f6b-4a4b-9a3e-6f1d3c2b1a00", m.Metadata.Identifier, "the container identifier is a fallback")
+
+	_, err = NewParser(nil).ParseRendition(a, f, "EPUB/missing.opf")
+	assert.Error(t, err)
+}
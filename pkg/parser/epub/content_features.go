@@ -0,0 +1,195 @@
+package epub
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"golang.org/x/net/html"
+)
+
+// Values of the "contains" link property which can be detected by scanning the content of a resource.
+var scannedContentFeatures = []string{"js", "mathml", "svg", "remote-resources"}
+
+// Difference between the content features declared in the package document for a resource,
+// and the ones found in its content.
+type ContentFeaturesDiscrepancy struct {
+	Href       string   // HREF of the resource.
+	Undeclared []string // Features found in the content, but missing from the item's properties.
+	Unused     []string // Features declared in the item's properties, but not found in the content.
+}
+
+// Result of scanning the content of the XHTML resources of a publication.
+type ContentFeaturesReport struct {
+	Scanned       int                          // Number of scanned resources.
+	Discrepancies []ContentFeaturesDiscrepancy // Resources whose declared features don't match their content.
+}
+
+// Adds the undeclared features of the report to the "contains" property of the matching [links].
+func (r ContentFeaturesReport) Apply(links manifest.LinkList) {
+	for _, d := range r.Discrepancies {
+		if len(d.Undeclared) == 0 {
+			continue
+		}
+		for i := range links {
+			if links[i].Href != d.Href {
+				continue
+			}
+			// Properties are shared between copies of a link, so they are copied before being modified
+			properties := make(manifest.Properties, len(links[i].Properties)+1)
+			for k, v := range links[i].Properties {
+				properties[k] = v
+			}
+			contains := slices.Clone(links[i].Properties.Contains())
+			for _, feature := range d.Undeclared {
+				if !slices.Contains(contains, feature) {
+					contains = append(contains, feature)
+				}
+			}
+			properties["contains"] = contains
+			links[i].Properties = properties
+		}
+	}
+}
+
+// Scans the content of the (X)HTML resources among [links] to detect the MathML, SVG, scripts and remote resources
+// they contain, and reports the differences with the features declared in their "contains" property.
+// Encrypted resources are not scanned.
+func ScanContentFeatures(f fetcher.Fetcher, links manifest.LinkList) ContentFeaturesReport {
+	var report ContentFeaturesReport
+	for _, link := range links {
+		if !link.MediaType().IsHTML() || link.Properties.Encryption() != nil {
+			continue
+		}
+		res := f.Get(link)
+		data, err := res.Read(0, 0)
+		res.Close()
+		if err != nil {
+			continue
+		}
+		report.Scanned++
+
+		found := detectContentFeatures(data)
+		declared := link.Properties.Contains()
+		d := ContentFeaturesDiscrepancy{Href: link.Href}
+		for _, feature := range scannedContentFeatures {
+			isFound, isDeclared := slices.Contains(found, feature), slices.Contains(declared, feature)
+			if isFound && !isDeclared {
+				d.Undeclared = append(d.Undeclared, feature)
+			} else if isDeclared && !isFound {
+				d.Unused = append(d.Unused, feature)
+			}
+		}
+		if len(d.Undeclared) > 0 || len(d.Unused) > 0 {
+			report.Discrepancies = append(report.Discrepancies, d)
+		}
+	}
+	return report
+}
+
+// Elements whose attribute loads a resource when the document is rendered.
+// Hyperlinks are not included, as following them is up to the user.
+var resourceAttributes = map[string][]string{
+	"img":    {"src", "srcset"},
+	"image":  {"href", "xlink:href"},
+	"audio":  {"src"},
+	"video":  {"src", "poster"},
+	"source": {"src", "srcset"},
+	"track":  {"src"},
+	"iframe": {"src"},
+	"embed":  {"src"},
+	"object": {"data"},
+	"script": {"src"},
+	"link":   {"href"},
+}
+
+func detectContentFeatures(data []byte) []string {
+	var features []string
+	add := func(feature string) {
+		if !slices.Contains(features, feature) {
+			features = append(features, feature)
+		}
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for len(features) < len(scannedContentFeatures) {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		// Namespace prefixes are kept by the tokenizer, e.g. <m:math>
+		tag := string(name)
+		if i := strings.LastIndexByte(tag, ':'); i >= 0 {
+			tag = tag[i+1:]
+		}
+		attrs := make(map[string]string)
+		for hasAttr {
+			var key, val []byte
+			key, val, hasAttr = z.TagAttr()
+			attrs[string(key)] = string(val)
+			if bytes.HasPrefix(key, []byte("on")) && len(val) > 0 {
+				add("js") // Event handler
+			}
+		}
+
+		switch tag {
+		case "math":
+			add("mathml")
+		case "svg":
+			add("svg")
+		case "script":
+			if isJavaScriptType(attrs["type"]) {
+				add("js")
+			}
+		case "form":
+			add("js") // Forms also require the "scripted" property
+		}
+
+		if names, ok := resourceAttributes[tag]; ok {
+			if tag == "link" && !strings.EqualFold(strings.TrimSpace(attrs["rel"]), "stylesheet") {
+				continue
+			}
+			for _, name := range names {
+				if isRemoteReference(attrs[name]) {
+					add("remote-resources")
+				}
+			}
+		}
+	}
+	return features
+}
+
+// Scripts with a type other than JavaScript are data blocks, which are not executed.
+func isJavaScriptType(typ string) bool {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if i := strings.IndexByte(typ, ';'); i >= 0 {
+		typ = strings.TrimSpace(typ[:i])
+	}
+	switch typ {
+	case "", "module", "text/javascript", "application/javascript", "application/ecmascript", "text/ecmascript":
+		return true
+	}
+	return false
+}
+
+// Whether the URL (or any of the candidates of a srcset) points outside of the publication.
+func isRemoteReference(value string) bool {
+	for _, candidate := range strings.Split(value, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		u := strings.ToLower(fields[0])
+		if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "//") {
+			return true
+		}
+	}
+	return false
+}
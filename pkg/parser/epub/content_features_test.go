@@ -0,0 +1,43 @@
+package epub
+
+import (
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentFeaturesDetection(t *testing.T) {
+	assert.Equal(t, []string{"mathml"}, detectContentFeatures([]byte(`<html><body><math><mi>x</mi></math><a href="https://example.com">Link</a></body></html>`)))
+	assert.Equal(t, []string{"js"}, detectContentFeatures([]byte(`<html><head><script src="script.js"></script></head></html>`)))
+	assert.Equal(t, []string{"js", "remote-resources"}, detectContentFeatures([]byte(`<html><head><script type="module" src="https://example.com/script.js"></script></head></html>`)))
+	assert.Equal(t, []string{"remote-resources"}, detectContentFeatures([]byte(`<html><body><img srcset="small.png 1x, //example.com/large.png 2x"/></body></html>`)))
+	assert.Empty(t, detectContentFeatures([]byte(`<html><head><link rel="alternate" href="https://example.com"/><script type="text/template">x</script></head></html>`)))
+}
+
+func TestContentFeaturesScan(t *testing.T) {
+	f := fetcher.NewFileFetcher("/", "./testdata/features")
+	links := manifest.LinkList{
+		{Href: "/math.xhtml", Type: "application/xhtml+xml", Properties: manifest.Properties{"contains": []string{"svg"}}},
+		{Href: "/scripted.xhtml", Type: "application/xhtml+xml", Properties: manifest.Properties{"contains": []string{"js"}}},
+		{Href: "/plain.xhtml", Type: "application/xhtml+xml"},
+		{Href: "/images/local.png", Type: "image/png"},
+	}
+
+	report := ScanContentFeatures(f, links)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, []ContentFeaturesDiscrepancy{
+		{Href: "/math.xhtml", Undeclared: []string{"mathml"}, Unused: []string{"svg"}},
+		{Href: "/scripted.xhtml", Undeclared: []string{"svg", "remote-resources"}},
+		{Href: "/plain.xhtml", Undeclared: []string{"remote-resources"}},
+	}, report.Discrepancies)
+
+	declared := links[0].Properties
+	report.Apply(links)
+	assert.Equal(t, []string{"svg", "mathml"}, links[0].Properties.Contains(), "declared features are kept")
+	assert.Equal(t, []string{"js", "svg", "remote-resources"}, links[1].Properties.Contains())
+	assert.Equal(t, []string{"remote-resources"}, links[2].Properties.Contains())
+	assert.Nil(t, links[3].Properties)
+	assert.Equal(t, []string{"svg"}, declared.Contains(), "original properties are not modified")
+}
@@ -12,6 +12,10 @@ type PublicationFactory struct {
 	EncryptionData  map[string]manifest.Encryption
 	DisplayOptions  map[string]string
 
+	// Keeps the non-linear spine items in the reading order, flagged with a "linear" property set to false,
+	// instead of moving them to the resources.
+	NonLinearInReadingOrder bool
+
 	itemById       map[string]Item
 	pubMetadata    PubMetadataAdapter
 	itemrefByIdref map[string]ItemRef
@@ -62,7 +66,7 @@ func (f PublicationFactory) Create() manifest.Manifest {
 	// Compute Links
 	var readingOrderIds []string
 	for _, v := range spine.itemrefs {
-		if v.linear {
+		if v.linear || f.NonLinearInReadingOrder {
 			readingOrderIds = append(readingOrderIds, v.idref)
 		}
 	}
@@ -165,6 +169,21 @@ func (f PublicationFactory) computeLink(item Item, fallbackChain []string) manif
 		}
 	}
 
+	// Dimensions of fixed-layout resources declared in the package document.
+	// They can be overridden by the viewport of the resource itself when parsing the publication.
+	if f.pubMetadata.Presentation().LayoutOf(ret) == manifest.EPUBLayoutFixed {
+		width, height := f.pubMetadata.Viewport()
+		if itm, ok := f.itemMetadata[item.ID]; ok {
+			if w, h := itm.Viewport(); w > 0 && h > 0 {
+				width, height = w, h
+			}
+		}
+		if width > 0 && height > 0 {
+			ret.Width = width
+			ret.Height = height
+		}
+	}
+
 	return ret
 }
 
@@ -185,6 +204,9 @@ func (f PublicationFactory) computePropertiesAndRels(item Item, itemref *ItemRef
 		for k, v := range parseItemrefProperties(itemref.properties) {
 			properties[k] = v
 		}
+		if f.NonLinearInReadingOrder && itemref.idref != "" && !itemref.linear {
+			properties["linear"] = false
+		}
 	}
 
 	coverId := f.pubMetadata.Cover()
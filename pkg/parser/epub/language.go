@@ -0,0 +1,51 @@
+package epub
+
+import (
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/content/iterator"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/go-toolkit/pkg/util/langdetect"
+)
+
+// Amount of text read from the reading order to detect the language of a publication.
+const languageSampleLength = 10000
+
+// Detects the language of the publication from the text of the first resources of its [readingOrder], for the
+// EPUBs without any dc:language. Returns an empty string if the language can't be detected with a confidence of
+// at least [threshold].
+func InferLanguage(f fetcher.Fetcher, readingOrder manifest.LinkList, threshold float64) string {
+	if threshold == 0 {
+		threshold = langdetect.DefaultThreshold
+	}
+
+	var sb strings.Builder
+	for _, link := range readingOrder {
+		if sb.Len() >= languageSampleLength {
+			break
+		}
+		if !link.MediaType().Matches(&mediatype.HTML, &mediatype.XHTML) {
+			continue
+		}
+		resource := f.Get(link)
+		it := iterator.NewHTML(resource, manifest.Locator{Href: link.Href, Type: link.Type})
+		for sb.Len() < languageSampleLength {
+			if ok, err := it.HasNext(); err != nil || !ok {
+				break
+			}
+			if el, ok := it.Next().(element.TextualElement); ok {
+				sb.WriteString(el.Text())
+				sb.WriteRune(' ')
+			}
+		}
+		resource.Close()
+	}
+
+	if res := langdetect.Detect(sb.String()); res.IsReliable(threshold) {
+		return res.Language
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package epub
+
+import (
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferLanguage(t *testing.T) {
+	f := fetcher.NewFileFetcher("/", "./testdata/language")
+
+	assert.Equal(t, "fr", InferLanguage(f, manifest.LinkList{
+		{Href: "/numbers.xhtml", Type: "application/xhtml+xml"},
+		{Href: "/chapter1.xhtml", Type: "application/xhtml+xml"},
+	}, 0))
+	assert.Empty(t, InferLanguage(f, manifest.LinkList{
+		{Href: "/numbers.xhtml", Type: "application/xhtml+xml"},
+	}, 0))
+	assert.Empty(t, InferLanguage(f, manifest.LinkList{
+		{Href: "/missing.xhtml", Type: "application/xhtml+xml"},
+	}, 0))
+}
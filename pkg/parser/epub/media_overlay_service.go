@@ -78,20 +78,7 @@ func (s *MediaOverlayService) HasGuideForResource(href string) bool {
 func (s *MediaOverlayService) GuideForResource(href string) (*manifest.GuidedNavigationDocument, error) {
 	// Check if the provided resource has a guided navigation document
 	if link, ok := s.originalSmilAlternates[href]; ok {
-		res := s.fetcher.Get(link)
-		defer res.Close()
-
-		n, rerr := res.ReadAsXML(map[string]string{
-			NamespaceOPS:   "epub",
-			NamespaceSMIL:  "smil",
-			NamespaceSMIL2: "smil2",
-		})
-		if rerr != nil {
-			return nil, rerr.Cause
-		}
-
-		// Convert SMIL to guided navigation document
-		doc, err := ParseSMILDocument(n, link.Href)
+		doc, err := readSMILDocument(s.fetcher, link)
 		if err != nil {
 			return nil, err
 		}
@@ -121,3 +108,53 @@ func (s *MediaOverlayService) GuideForResource(href string) (*manifest.GuidedNav
 func (s *MediaOverlayService) Get(link manifest.Link) (fetcher.Resource, bool) {
 	return pub.GetForGuidedNavigationService(s, link)
 }
+
+// Reads the SMIL resource at [link] and converts it to a guided navigation document.
+func readSMILDocument(f fetcher.Fetcher, link manifest.Link) (*manifest.GuidedNavigationDocument, error) {
+	res := f.Get(link)
+	defer res.Close()
+
+	n, rerr := res.ReadAsXML(map[string]string{
+		NamespaceOPS:   "epub",
+		NamespaceSMIL:  "smil",
+		NamespaceSMIL2: "smil2",
+	})
+	if rerr != nil {
+		return nil, rerr.Cause
+	}
+
+	return ParseSMILDocument(n, link.Href)
+}
+
+// Sets the duration of the reading order items having a media overlay, and of the whole publication
+// when it is not declared in the metadata.
+// The duration of a media overlay comes from its media:duration refinement, or else from the sum of its audio clips.
+func setMediaOverlayDurations(m *manifest.Manifest, f fetcher.Fetcher) {
+	smilMediatype := mediatype.SMIL.String()
+	var total float64
+	for i := range m.ReadingOrder {
+		link := &m.ReadingOrder[i]
+		for j := range link.Alternates {
+			alt := &link.Alternates[j]
+			if alt.Type != smilMediatype {
+				continue
+			}
+			if alt.Duration == 0 {
+				doc, err := readSMILDocument(f, *alt)
+				if err != nil {
+					continue
+				}
+				alt.Duration = doc.AudioDuration()
+			}
+			if link.Duration == 0 {
+				link.Duration = alt.Duration
+			}
+			break
+		}
+		total += link.Duration
+	}
+
+	if m.Metadata.Duration == nil && total > 0 {
+		m.Metadata.Duration = &total
+	}
+}
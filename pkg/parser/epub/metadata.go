@@ -385,6 +385,11 @@ func (m metadataAdapter) Duration() *float64 {
 	return ParseClockValue(m.FirstValue(VocabularyMedia + "duration"))
 }
 
+// Dimensions declared with the deprecated rendition:viewport property, e.g. "width=1200, height=1600".
+func (m metadataAdapter) Viewport() (width uint, height uint) {
+	return ParseViewport(m.FirstValue(VocabularyRendition + "viewport"))
+}
+
 func (m metadataAdapter) First(property string) (item MetadataItem, ok bool) {
 	items, ok := m.items[property]
 	if !ok || len(items) == 0 {
@@ -1035,6 +1040,7 @@ func (m *PubMetadataAdapter) OtherMetadata() map[string]interface{} {
 			VocabularyRendition + "spread":            {},
 			VocabularyRendition + "orientation":       {},
 			VocabularyRendition + "layout":            {},
+			VocabularyRendition + "viewport":          {},
 
 			VocabularyDCTerms + "conformsto":          {},
 			VocabularyDCTerms + "conformsTo":          {},
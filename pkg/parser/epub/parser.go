@@ -4,55 +4,95 @@ import (
 	"github.com/pkg/errors"
 	"github.com/readium/go-toolkit/pkg/asset"
 	"github.com/readium/go-toolkit/pkg/content/iterator"
+	"github.com/readium/go-toolkit/pkg/content/tokenizer"
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/go-toolkit/pkg/mediatype"
 	"github.com/readium/go-toolkit/pkg/pub"
 	"github.com/readium/go-toolkit/pkg/util"
+	"github.com/readium/go-toolkit/pkg/util/imagesize"
 )
 
+// Configuration of the EPUB [Parser].
+type ParserConfig struct {
+	ReflowablePositionsStrategy ReflowableStrategy          // Strategy used to compute the positions of reflowable resources. Defaults to [RecommendedReflowableStrategy].
+	ProbeImageDimensions        bool                        // Reads the dimensions of the bitmap resources from their headers, to set the width and height of their links.
+	ReadViewportDimensions      bool                        // Reads the viewport of the fixed-layout resources, to set the width and height of their links. Otherwise only the rendition:viewport metadata is used.
+	ReadMediaOverlayDurations   bool                        // Sets the duration of the reading order items and of the publication from their media overlays, reading the SMIL documents without a media:duration refinement.
+	NonLinearInReadingOrder     bool                        // Keeps the non-linear spine items in the reading order, flagged with a "linear" property set to false.
+	NonLinearPolicy             manifest.NonLinearPolicy    // How the positions and content services handle the non-linear items of the reading order.
+	RenditionSelection          *RenditionSelection         // Preferences used to select a rendition of EPUBs with multiple renditions. Defaults to the first rendition.
+	ScanContentFeatures         bool                        // Scans the XHTML resources to add the MathML, SVG, scripts and remote resources missing from their "contains" property.
+	ContentFeaturesReporter     func(ContentFeaturesReport) // Called with the result of the content scan, e.g. to log the discrepancies with the package document.
+	ContentTokenizer            tokenizer.Tokenizer         // Splits the text of the content elements into smaller segments, e.g. sentences for TTS.
+	ContentSkipNotes            bool                        // Omits the footnotes and endnotes from the content elements, e.g. to read the text aloud without interruptions.
+	ContentDetectLanguage       bool                        // Detects the language of the text of the content elements which don't declare one.
+	InferLanguage               bool                        // Detects the language of the publication from its text when the package document has no dc:language.
+	LanguageThreshold           float64                     // Minimum confidence of a detected language. Defaults to [langdetect.DefaultThreshold].
+}
+
 type Parser struct {
-	reflowablePositionsStrategy ReflowableStrategy
+	config ParserConfig
 }
 
 func NewParser(strategy ReflowableStrategy) Parser {
-	if strategy == nil {
-		strategy = RecommendedReflowableStrategy
+	return NewParserWithConfig(ParserConfig{
+		ReflowablePositionsStrategy: strategy,
+	})
+}
+
+func NewParserWithConfig(config ParserConfig) Parser {
+	if config.ReflowablePositionsStrategy == nil {
+		config.ReflowablePositionsStrategy = RecommendedReflowableStrategy
 	}
 	return Parser{
-		reflowablePositionsStrategy: strategy,
+		config: config,
 	}
 }
 
 // Parse implements PublicationParser
 func (p Parser) Parse(asset asset.PublicationAsset, f fetcher.Fetcher) (*pub.Builder, error) {
-	fallbackTitle := asset.Name()
+	if !asset.MediaType().Equal(&mediatype.EPUB) {
+		return nil, nil
+	}
 
+	container, err := ParseContainer(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseRendition(asset.Name(), f, *container, container.Select(p.config.RenditionSelection))
+}
+
+// Parses the rendition of the EPUB whose package document is located at [path], regardless of the selection policy.
+// The other renditions are linked from the manifest as alternates, e.g. to be parsed with this function.
+func (p Parser) ParseRendition(asset asset.PublicationAsset, f fetcher.Fetcher, path string) (*pub.Builder, error) {
 	if !asset.MediaType().Equal(&mediatype.EPUB) {
 		return nil, nil
 	}
 
-	opfPath, err := GetRootFilePath(f)
+	container, err := ParseContainer(f)
 	if err != nil {
 		return nil, err
 	}
-	if opfPath[0] != '/' {
-		opfPath = "/" + opfPath
+
+	rendition, ok := container.Rendition(path)
+	if !ok || !rendition.IsPackageDocument() {
+		return nil, errors.Errorf("no rendition with the package document %s", path)
 	}
 
-	opfXmlDocument, errx := f.Get(manifest.Link{Href: opfPath}).ReadAsXML(map[string]string{
-		NamespaceOPF:                         "opf",
-		NamespaceDC:                          "dc",
-		VocabularyDCTerms:                    "dcterms",
-		"http://www.idpf.org/2013/rendition": "rendition",
-	})
-	if errx != nil {
-		return nil, errx
+	return p.parseRendition(asset.Name(), f, *container, rendition)
+}
+
+func (p Parser) parseRendition(fallbackTitle string, f fetcher.Fetcher, container Container, rendition Rendition) (*pub.Builder, error) {
+	if container.Metadata != nil && container.Metadata.Title != "" {
+		fallbackTitle = container.Metadata.Title
 	}
 
-	packageDocument, err := ParsePackageDocument(opfXmlDocument, opfPath)
+	opfPath := rendition.Href()
+	packageDocument, err := readPackageDocument(f, opfPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "invalid OPF file")
+		return nil, err
 	}
 
 	manifest := PublicationFactory{
@@ -61,23 +101,100 @@ func (p Parser) Parse(asset asset.PublicationAsset, f fetcher.Fetcher) (*pub.Bui
 		NavigationData:  parseNavigationData(*packageDocument, f),
 		EncryptionData:  parseEncryptionData(f),
 		DisplayOptions:  parseDisplayOptions(f),
+
+		NonLinearInReadingOrder: p.config.NonLinearInReadingOrder,
 	}.Create()
 
+	if manifest.Metadata.Identifier == "" && container.Metadata != nil {
+		manifest.Metadata.Identifier = container.Metadata.Identifier
+	}
+	if len(manifest.Metadata.Languages) == 0 && container.Metadata != nil {
+		manifest.Metadata.Languages = container.Metadata.Languages
+	}
+	for _, r := range container.Renditions {
+		if r.Path != rendition.Path && r.IsPackageDocument() {
+			manifest.Links = append(manifest.Links, r.Link())
+		}
+	}
+
+	// Fonts are obfuscated with the unique identifier of the default rendition.
+	obfuscationKey := manifest.Metadata.Identifier
+	if def := container.DefaultRendition(); def.Path != rendition.Path {
+		if doc, err := readPackageDocument(f, def.Href()); err == nil {
+			obfuscationKey = PublicationFactory{PackageDocument: *doc}.Create().Metadata.Identifier
+		}
+	}
+
 	ffetcher := f
-	if manifest.Metadata.Identifier != "" {
-		ffetcher = fetcher.NewTransformingFetcher(f, NewDeobfuscator(manifest.Metadata.Identifier).Transform)
+	if obfuscationKey != "" {
+		ffetcher = fetcher.NewTransformingFetcher(f, NewDeobfuscator(obfuscationKey).Transform)
+	}
+
+	if p.config.ReadViewportDimensions {
+		setFixedLayoutDimensions(&manifest, ffetcher)
+	}
+	if p.config.ReadMediaOverlayDurations {
+		setMediaOverlayDurations(&manifest, ffetcher)
+	}
+
+	if p.config.ScanContentFeatures {
+		ro := manifest.ReadingOrder
+		report := ScanContentFeatures(ffetcher, append(ro[:len(ro):len(ro)], manifest.Resources...))
+		report.Apply(manifest.ReadingOrder)
+		report.Apply(manifest.Resources)
+		if p.config.ContentFeaturesReporter != nil {
+			p.config.ContentFeaturesReporter(report)
+		}
+	}
+
+	if p.config.ProbeImageDimensions {
+		imagesize.ProbeLinks(ffetcher, manifest.ReadingOrder)
+		imagesize.ProbeLinks(ffetcher, manifest.Resources)
+	}
+
+	if p.config.InferLanguage && len(manifest.Metadata.Languages) == 0 {
+		if language := InferLanguage(ffetcher, manifest.ReadingOrder, p.config.LanguageThreshold); language != "" {
+			manifest.Metadata.Languages = []string{language}
+		}
+	}
+
+	htmlFactory := iterator.HTMLFactoryWithOptions(iterator.HTMLOptions{
+		SkipNotes:         p.config.ContentSkipNotes,
+		DetectLanguage:    p.config.ContentDetectLanguage,
+		LanguageThreshold: p.config.LanguageThreshold,
+	})
+	if p.config.ContentTokenizer != nil {
+		htmlFactory = iterator.TokenizingFactory(htmlFactory, p.config.ContentTokenizer)
 	}
 
 	builder := pub.NewServicesBuilder(map[string]pub.ServiceFactory{
-		pub.PositionsService_Name: PositionsServiceFactory(p.reflowablePositionsStrategy),
-		pub.ContentService_Name: pub.DefaultContentServiceFactory([]iterator.ResourceContentIteratorFactory{
-			iterator.HTMLFactory(),
-		}),
+		pub.PositionsService_Name: PositionsServiceFactoryWithPolicy(p.config.ReflowablePositionsStrategy, p.config.NonLinearPolicy),
+		pub.ContentService_Name: pub.DefaultContentServiceFactoryWithPolicy([]iterator.ResourceContentIteratorFactory{
+			htmlFactory,
+		}, p.config.NonLinearPolicy),
 		pub.GuidedNavigationService_Name: MediaOverlayFactory(),
 	})
 	return pub.NewBuilder(manifest, ffetcher, builder), nil
 }
 
+func readPackageDocument(f fetcher.Fetcher, opfPath string) (*PackageDocument, error) {
+	opfXmlDocument, errx := f.Get(manifest.Link{Href: opfPath}).ReadAsXML(map[string]string{
+		NamespaceOPF:       "opf",
+		NamespaceDC:        "dc",
+		VocabularyDCTerms:  "dcterms",
+		NamespaceRendition: "rendition",
+	})
+	if errx != nil {
+		return nil, errx
+	}
+
+	packageDocument, err := ParsePackageDocument(opfXmlDocument, opfPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid OPF file")
+	}
+	return packageDocument, nil
+}
+
 func parseEncryptionData(fetcher fetcher.Fetcher) (ret map[string]manifest.Encryption) {
 	n, err := fetcher.Get(manifest.Link{Href: "/META-INF/encryption.xml"}).ReadAsXML(map[string]string{
 		NamespaceENC:  "enc",
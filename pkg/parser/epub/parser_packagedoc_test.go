@@ -9,6 +9,10 @@ import (
 )
 
 func loadPackageDoc(name string) (*manifest.Manifest, error) {
+	return loadPackageDocWith(name, func(f *PublicationFactory) {})
+}
+
+func loadPackageDocWith(name string, configure func(f *PublicationFactory)) (*manifest.Manifest, error) {
 	n, rerr := fetcher.NewFileResource(manifest.Link{}, "./testdata/package/"+name+".opf").ReadAsXML(map[string]string{
 		NamespaceOPF:                         "opf",
 		NamespaceDC:                          "dc",
@@ -24,10 +28,12 @@ func loadPackageDoc(name string) (*manifest.Manifest, error) {
 		return nil, err
 	}
 
-	manifest := PublicationFactory{
+	factory := PublicationFactory{
 		FallbackTitle:   "fallback title",
 		PackageDocument: *d,
-	}.Create()
+	}
+	configure(&factory)
+	manifest := factory.Create()
 
 	return &manifest, nil
 }
@@ -124,6 +130,35 @@ func TestPackageDocLinkReadingOrder(t *testing.T) {
 	}, p.ReadingOrder)
 }
 
+func TestPackageDocLinkReadingOrderWithNonLinear(t *testing.T) {
+	p, err := loadPackageDocWith("links", func(f *PublicationFactory) {
+		f.NonLinearInReadingOrder = true
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, manifest.LinkList{
+		{
+			Href: "/titlepage.xhtml",
+			Type: "application/xhtml+xml",
+		},
+		{
+			Href: "/OEBPS/chapter01.xhtml",
+			Type: "application/xhtml+xml",
+		},
+		{
+			Href:       "/OEBPS/chapter02.xhtml",
+			Type:       "application/xhtml+xml",
+			Properties: manifest.Properties{"linear": false},
+		},
+	}, p.ReadingOrder)
+	assert.False(t, p.ReadingOrder[2].Properties.Linear())
+	assert.True(t, p.ReadingOrder[1].Properties.Linear())
+
+	for _, l := range p.Resources {
+		assert.NotEqual(t, "/OEBPS/chapter02.xhtml", l.Href)
+	}
+}
+
 func TestPackageDocLinkResources(t *testing.T) {
 	p, err := loadPackageDoc("links")
 	assert.NoError(t, err)
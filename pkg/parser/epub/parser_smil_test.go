@@ -1,11 +1,16 @@
 package epub
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/readium/go-toolkit/pkg/asset"
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/xmlquery"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func loadSmil(name string) (*manifest.GuidedNavigationDocument, error) {
@@ -53,3 +58,130 @@ func TestSMILClipBoundaries(t *testing.T) {
 	assert.Equal(t, "OEBPS/audio/page1.m4a#t=0.84", doc.Guided[1].AudioRef)
 	assert.Equal(t, "OEBPS/audio/page1.m4a", doc.Guided[2].AudioRef)
 }
+
+func TestSMILAudioDuration(t *testing.T) {
+	doc, err := loadSmil("audio1")
+	if assert.NoError(t, err) {
+		assert.InDelta(t, 14.5, doc.AudioDuration(), 0.0001)
+	}
+
+	doc, err = loadSmil("audio-clip")
+	if assert.NoError(t, err) {
+		assert.InDelta(t, 0.84, doc.AudioDuration(), 0.0001, "clips without an end are ignored")
+	}
+}
+
+func TestMediaOverlayDurations(t *testing.T) {
+	m := manifest.Manifest{
+		ReadingOrder: manifest.LinkList{
+			{
+				Href:       "/page1.xhtml",
+				Type:       "application/xhtml+xml",
+				Alternates: manifest.LinkList{{Href: "/audio1.smil", Type: "application/smil+xml"}},
+			},
+			{
+				Href:       "/page2.xhtml",
+				Type:       "application/xhtml+xml",
+				Alternates: manifest.LinkList{{Href: "/missing.smil", Type: "application/smil+xml", Duration: 20}},
+			},
+			{
+				Href: "/page3.xhtml",
+				Type: "application/xhtml+xml",
+			},
+		},
+	}
+	setMediaOverlayDurations(&m, fetcher.NewFileFetcher("/", "./testdata/smil"))
+
+	assert.InDelta(t, 14.5, m.ReadingOrder[0].Duration, 0.0001)
+	assert.InDelta(t, 14.5, m.ReadingOrder[0].Alternates[0].Duration, 0.0001)
+	assert.Equal(t, 20.0, m.ReadingOrder[1].Duration, "declared durations are used")
+	assert.Zero(t, m.ReadingOrder[2].Duration)
+	if assert.NotNil(t, m.Metadata.Duration) {
+		assert.InDelta(t, 34.5, *m.Metadata.Duration, 0.0001)
+	}
+
+	declared := 40.0
+	m.Metadata.Duration = &declared
+	setMediaOverlayDurations(&m, fetcher.NewFileFetcher("/", "./testdata/smil"))
+	assert.Equal(t, 40.0, *m.Metadata.Duration, "the declared total duration is kept")
+}
+
+func TestParserReadsMediaOverlayDurationsWhenEnabled(t *testing.T) {
+	a := asset.FileWithMediaType("mediaoverlay.epub", &mediatype.EPUB)
+	f := fetcher.NewFileFetcher("/", "./testdata/mediaoverlay")
+
+	b, err := NewParserWithConfig(ParserConfig{}).Parse(a, f)
+	if assert.NoError(t, err) {
+		m := b.Build().Manifest
+		assert.Zero(t, m.ReadingOrder[0].Duration, "the media overlays are not read by default")
+		assert.Nil(t, m.Metadata.Duration)
+	}
+
+	b, err = NewParserWithConfig(ParserConfig{ReadMediaOverlayDurations: true}).Parse(a, f)
+	if assert.NoError(t, err) {
+		m := b.Build().Manifest
+		assert.InDelta(t, 14.5, m.ReadingOrder[0].Duration, 0.0001)
+		if assert.NotNil(t, m.Metadata.Duration) {
+			assert.InDelta(t, 14.5, *m.Metadata.Duration, 0.0001)
+		}
+	}
+}
+
+func TestSMILWriterRoundTrip(t *testing.T) {
+	for _, v := range []string{"audio1", "audio-clip", "w3-2", "w3-3", "w3-4", "w3-8", "w3-10"} {
+		doc, err := loadSmil(v)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var sb strings.Builder
+		if !assert.NoError(t, WriteSMILDocument(&sb, *doc, "OEBPS/page1.smil")) {
+			continue
+		}
+		n, err := xmlquery.ParseWithOptions(strings.NewReader(sb.String()), xmlquery.ParserOptions{
+			Prefixes: map[string]string{
+				NamespaceOPS:   "epub",
+				NamespaceSMIL:  "smil",
+				NamespaceSMIL2: "smil2",
+			},
+		})
+		if !assert.NoError(t, err) {
+			continue
+		}
+		written, err := ParseSMILDocument(n, "OEBPS/page1.smil")
+		if assert.NoError(t, err, v) {
+			assert.Equal(t, doc, written, v)
+		}
+	}
+}
+
+func TestSMILWriterRelativeRefs(t *testing.T) {
+	assert.Equal(t, "page1.xhtml#word0", relativeRef("OEBPS/page1.xhtml#word0", "OEBPS/page1.smil"))
+	assert.Equal(t, "../text/page%201.xhtml#p1", relativeRef("OEBPS/text/page 1.xhtml#p1", "OEBPS/smil/page1.smil"))
+	assert.Equal(t, "OEBPS/audio.mp3", relativeRef("/OEBPS/audio.mp3", "page1.smil"))
+	assert.Equal(t, "../audio.mp3", relativeRef("audio.mp3", "OEBPS/page1.smil"))
+}
+
+func TestSMILWriterRequiresTextRef(t *testing.T) {
+	var sb strings.Builder
+	err := WriteSMILDocument(&sb, manifest.GuidedNavigationDocument{
+		Guided: []manifest.GuidedNavigationObject{{AudioRef: "audio.mp3#t=0,1"}},
+	}, "page1.smil")
+	assert.Error(t, err)
+}
+
+func TestSMILWriterClipBoundaries(t *testing.T) {
+	var sb strings.Builder
+	err := WriteSMILDocument(&sb, manifest.GuidedNavigationDocument{
+		Guided: []manifest.GuidedNavigationObject{
+			{TextRef: "page1.xhtml#w1", AudioRef: "audio.mp3#t=,1.5"},
+			{TextRef: "page1.xhtml#w2", AudioRef: "audio.mp3#t=npt:,2"},
+			{TextRef: "page1.xhtml#w3", AudioRef: "audio.mp3#t=0,2.5"},
+			{TextRef: "page1.xhtml#w4", AudioRef: "audio.mp3#t=2.5"},
+		},
+	}, "page1.smil")
+	require.NoError(t, err)
+	assert.Contains(t, sb.String(), `<audio src="audio.mp3" clipEnd="1.5s"/>`)
+	assert.Contains(t, sb.String(), `<audio src="audio.mp3" clipEnd="2s"/>`)
+	assert.Contains(t, sb.String(), `<audio src="audio.mp3" clipBegin="0s" clipEnd="2.5s"/>`)
+	assert.Contains(t, sb.String(), `<audio src="audio.mp3" clipBegin="2.5s"/>`)
+}
@@ -21,6 +21,7 @@ type PositionsService struct {
 	presentation       *manifest.Presentation
 	fetcher            fetcher.Fetcher
 	reflowableStrategy ReflowableStrategy
+	nonLinearPolicy    manifest.NonLinearPolicy
 	positions          [][]manifest.Locator
 }
 
@@ -56,6 +57,9 @@ func (s *PositionsService) computePositions() [][]manifest.Locator {
 	var lastPositionOfPreviousResource uint
 	positions := make([][]manifest.Locator, len(s.readingOrder))
 	for i, link := range s.readingOrder {
+		if s.nonLinearPolicy.Skips(link) {
+			continue
+		}
 		var lpositions []manifest.Locator
 		if s.presentation.LayoutOf(link) == manifest.EPUBLayoutFixed {
 			lpositions = s.createFixed(link, lastPositionOfPreviousResource)
@@ -125,6 +129,12 @@ func (s *PositionsService) createLocator(link manifest.Link, progression float64
 }
 
 func PositionsServiceFactory(reflowableStrategy ReflowableStrategy) pub.ServiceFactory {
+	return PositionsServiceFactoryWithPolicy(reflowableStrategy, manifest.NonLinearInclude)
+}
+
+// Same as [PositionsServiceFactory], with the given [policy] for the non-linear resources of the reading order.
+// Skipped resources have no positions.
+func PositionsServiceFactoryWithPolicy(reflowableStrategy ReflowableStrategy, policy manifest.NonLinearPolicy) pub.ServiceFactory {
 	if reflowableStrategy == nil {
 		reflowableStrategy = RecommendedReflowableStrategy
 	}
@@ -135,6 +145,7 @@ func PositionsServiceFactory(reflowableStrategy ReflowableStrategy) pub.ServiceF
 			presentation:       context.Manifest.Metadata.Presentation,
 			fetcher:            context.Fetcher,
 			reflowableStrategy: reflowableStrategy,
+			nonLinearPolicy:    policy,
 		}
 	}
 }
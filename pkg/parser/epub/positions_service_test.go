@@ -3,6 +3,7 @@ package epub
 import (
 	"testing"
 
+	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +30,39 @@ func TestEPUBPositionsServiceEmptyReadingOrder(t *testing.T) {
 	}}, service.Positions())
 }
 */
+
+func TestEPUBPositionsServiceNonLinearPolicy(t *testing.T) {
+	layout := manifest.EPUBLayoutFixed
+	readingOrder := manifest.LinkList{
+		{Href: "/page1.xhtml", Type: "application/xhtml+xml"},
+		{Href: "/notes.xhtml", Type: "application/xhtml+xml", Properties: manifest.Properties{"linear": false}},
+		{Href: "/page2.xhtml", Type: "application/xhtml+xml"},
+	}
+
+	service := PositionsService{
+		readingOrder:    readingOrder,
+		presentation:    &manifest.Presentation{Layout: &layout},
+		nonLinearPolicy: manifest.NonLinearInclude,
+	}
+	positions := service.Positions()
+	if assert.Len(t, positions, 3) {
+		assert.Equal(t, "/notes.xhtml", positions[1].Href)
+		assert.Equal(t, uint(3), *positions[2].Locations.Position)
+	}
+
+	service = PositionsService{
+		readingOrder:    readingOrder,
+		presentation:    &manifest.Presentation{Layout: &layout},
+		nonLinearPolicy: manifest.NonLinearSkip,
+	}
+	byReadingOrder := service.PositionsByReadingOrder()
+	if assert.Len(t, byReadingOrder, 3) {
+		assert.Empty(t, byReadingOrder[1])
+	}
+	positions = service.Positions()
+	if assert.Len(t, positions, 2) {
+		assert.Equal(t, "/page2.xhtml", positions[1].Href)
+		assert.Equal(t, uint(2), *positions[1].Locations.Position)
+		assert.Equal(t, 0.5, *positions[1].Locations.TotalProgression)
+	}
+}
@@ -0,0 +1,110 @@
+package epub
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// Writes a guided navigation document as an EPUB 3 Media Overlay document located at [filePath].
+// The references of the objects, relative to the root of the publication, are made relative to [filePath].
+// Objects with children are written as <seq> elements and the others as <par> elements, which both require a text reference.
+// This is the inverse of [ParseSMILDocument].
+func WriteSMILDocument(w io.Writer, doc manifest.GuidedNavigationDocument, filePath string) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<smil xmlns="` + NamespaceSMIL + `" xmlns:epub="` + NamespaceOPS + `" version="3.0">` + "\n")
+	bw.WriteString("  <body>\n")
+	if err := writeSMILObjects(bw, doc.Guided, filePath, 2); err != nil {
+		return err
+	}
+	bw.WriteString("  </body>\n")
+	bw.WriteString("</smil>\n")
+	return bw.Flush()
+}
+
+func writeSMILObjects(w *bufio.Writer, objects []manifest.GuidedNavigationObject, filePath string, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for _, o := range objects {
+		if o.TextRef == "" {
+			return errors.New("guided navigation object has no text reference, which is required in SMIL")
+		}
+		textRef := relativeRef(o.TextRef, filePath)
+		role := ""
+		if len(o.Role) > 0 {
+			role = ` epub:type="` + escapeXMLAttr(strings.Join(o.Role, " ")) + `"`
+		}
+
+		if len(o.Children) > 0 {
+			w.WriteString(indent + `<seq epub:textref="` + escapeXMLAttr(textRef) + `"` + role + ">\n")
+			if err := writeSMILObjects(w, o.Children, filePath, depth+1); err != nil {
+				return err
+			}
+			w.WriteString(indent + "</seq>\n")
+			continue
+		}
+
+		w.WriteString(indent + "<par" + role + ">\n")
+		w.WriteString(indent + `  <text src="` + escapeXMLAttr(textRef) + `"/>` + "\n")
+		if o.AudioRef != "" {
+			w.WriteString(indent + `  <audio src="` + escapeXMLAttr(relativeRef(o.AudioFile(), filePath)) + `"`)
+			if clip, ok := o.AudioTime(); ok {
+				// A clip without explicit beginning starts at the beginning of the audio file
+				if clip.HasBegin {
+					w.WriteString(` clipBegin="` + formatClockValue(clip.Begin) + `"`)
+				}
+				if clip.End != nil {
+					w.WriteString(` clipEnd="` + formatClockValue(*clip.End) + `"`)
+				}
+			}
+			w.WriteString("/>\n")
+		}
+		w.WriteString(indent + "</par>\n")
+	}
+	return nil
+}
+
+func formatClockValue(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', -1, 64) + "s"
+}
+
+func escapeXMLAttr(value string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(value))
+	return sb.String()
+}
+
+// Makes the [ref], relative to the root of the publication, relative to the directory of [filePath].
+// The fragment of the reference is kept as is.
+func relativeRef(ref string, filePath string) string {
+	p, fragment, hasFragment := strings.Cut(strings.TrimPrefix(ref, "/"), "#")
+
+	base := strings.Split(path.Dir(strings.TrimPrefix(filePath, "/")), "/")
+	if len(base) == 1 && base[0] == "." {
+		base = nil
+	}
+	target := strings.Split(p, "/")
+
+	common := 0
+	for common < len(base) && common < len(target)-1 && base[common] == target[common] {
+		common++
+	}
+	segments := make([]string, 0, len(base)-common+len(target)-common)
+	for i := common; i < len(base); i++ {
+		segments = append(segments, "..")
+	}
+	segments = append(segments, target[common:]...)
+
+	rel := (&url.URL{Path: strings.Join(segments, "/")}).EscapedPath()
+	if hasFragment {
+		rel += "#" + fragment
+	}
+	return rel
+}
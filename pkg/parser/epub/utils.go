@@ -4,29 +4,16 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/readium/go-toolkit/pkg/fetcher"
-	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/xmlquery"
 )
 
 func GetRootFilePath(fetcher fetcher.Fetcher) (string, error) {
-	res := fetcher.Get(manifest.Link{Href: "/META-INF/container.xml"})
-	xml, err := res.ReadAsXML(map[string]string{
-		"urn:oasis:names:tc:opendocument:xmlns:container": "cn",
-	})
+	c, err := ParseContainer(fetcher)
 	if err != nil {
-		return "", errors.Wrap(err, "failed loading container.xml")
+		return "", err
 	}
-	n := xml.SelectElement("/container/rootfiles/rootfile")
-	if n == nil {
-		return "", errors.New("rootfile not found in container")
-	}
-	p := n.SelectAttr("full-path")
-	if p == "" {
-		return "", errors.New("no full-path in rootfile")
-	}
-	return p, nil
+	return c.DefaultRendition().Path, nil
 }
 
 // TODO: Use updated xpath/xmlquery functions
@@ -0,0 +1,127 @@
+package epub
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"golang.org/x/net/html"
+)
+
+// Parses the width and height of a viewport declaration, such as the content of a <meta name="viewport">
+// or the value of the rendition:viewport property, e.g. "width=1200, height=1600".
+// Dimensions which are missing or not absolute, such as "device-width", are returned as zero.
+func ParseViewport(content string) (width uint, height uint) {
+	fields := strings.FieldsFunc(content, func(r rune) bool {
+		return r == ',' || r == ';'
+	})
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "width":
+			width = parseLength(value)
+		case "height":
+			height = parseLength(value)
+		}
+	}
+	return
+}
+
+// Parses an absolute length in CSS pixels, such as "1200" or "1200px".
+func parseLength(value string) uint {
+	value = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "px")
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || f <= 0 || math.IsInf(f, 0) {
+		return 0
+	}
+	return uint(math.Round(f))
+}
+
+// Parses the width and height of a SVG viewBox attribute, e.g. "0 0 1200 1600".
+func parseViewBox(viewBox string) (width uint, height uint) {
+	fields := strings.FieldsFunc(viewBox, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) != 4 {
+		return 0, 0
+	}
+	w := parseLength(fields[2])
+	h := parseLength(fields[3])
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	return w, h
+}
+
+// Reads the dimensions of a fixed-layout document: the <meta name="viewport"> of a XHTML document,
+// or the viewBox (falling back on the width and height) of the outermost <svg> element of a SVG document.
+func parseDocumentViewport(r io.Reader) (width uint, height uint) {
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return 0, 0
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := make(map[string]string)
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+			switch string(name) {
+			case "meta":
+				if strings.EqualFold(attrs["name"], "viewport") {
+					if w, h := ParseViewport(attrs["content"]); w > 0 && h > 0 {
+						return w, h
+					}
+				}
+			case "svg":
+				// Attribute names are lowercased by the tokenizer
+				if w, h := parseViewBox(attrs["viewbox"]); w > 0 && h > 0 {
+					return w, h
+				}
+				return parseLength(attrs["width"]), parseLength(attrs["height"])
+			case "body":
+				return 0, 0
+			}
+		}
+	}
+}
+
+// Sets the dimensions of the fixed-layout resources of the reading order, using their viewport.
+// The dimensions already set, e.g. from the rendition:viewport metadata, are used as a fallback.
+func setFixedLayoutDimensions(m *manifest.Manifest, f fetcher.Fetcher) {
+	presentation := manifest.Presentation{}
+	if m.Metadata.Presentation != nil {
+		presentation = *m.Metadata.Presentation
+	}
+	for i := range m.ReadingOrder {
+		link := &m.ReadingOrder[i]
+		if presentation.LayoutOf(*link) != manifest.EPUBLayoutFixed {
+			continue
+		}
+		mt := link.MediaType()
+		if !mt.IsHTML() && !mt.Matches(&mediatype.SVG) {
+			continue
+		}
+		res := f.Get(*link)
+		data, err := res.Read(0, 0)
+		res.Close()
+		if err != nil {
+			continue
+		}
+		if w, h := parseDocumentViewport(bytes.NewReader(data)); w > 0 && h > 0 {
+			link.Width = w
+			link.Height = h
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewportParsing(t *testing.T) {
+	w, h := ParseViewport("width=1200, height=1600")
+	assert.Equal(t, []uint{1200, 1600}, []uint{w, h})
+
+	w, h = ParseViewport(" width = 1200px ; height=1600.4 ")
+	assert.Equal(t, []uint{1200, 1600}, []uint{w, h})
+
+	w, h = ParseViewport("width=device-width, initial-scale=1")
+	assert.Equal(t, []uint{0, 0}, []uint{w, h})
+
+	w, h = ParseViewport("")
+	assert.Equal(t, []uint{0, 0}, []uint{w, h})
+}
+
+func TestViewportDocumentXHTML(t *testing.T) {
+	w, h := parseDocumentViewport(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+	<title>Page 1</title>
+	<meta charset="utf-8"/>
+	<meta name="viewport" content="width=1024, height=768"/>
+</head>
+<body><svg viewBox="0 0 10 10"></svg></body>
+</html>`))
+	assert.Equal(t, []uint{1024, 768}, []uint{w, h})
+
+	w, h = parseDocumentViewport(strings.NewReader(`<html><head><title>Page</title></head><body><svg viewBox="0 0 10 10"></svg></body></html>`))
+	assert.Equal(t, []uint{0, 0}, []uint{w, h}, "the body of the document is not used")
+}
+
+func TestViewportDocumentSVG(t *testing.T) {
+	w, h := parseDocumentViewport(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg" width="100%" height="100%" viewBox="0 0 1200 1600"><rect/></svg>`))
+	assert.Equal(t, []uint{1200, 1600}, []uint{w, h})
+
+	w, h = parseDocumentViewport(strings.NewReader(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="600px" height="800"/>`))
+	assert.Equal(t, []uint{600, 800}, []uint{w, h})
+}
+
+func TestViewportRenditionMetadata(t *testing.T) {
+	p, err := loadPackageDoc("viewport")
+	assert.NoError(t, err)
+
+	ro := p.ReadingOrder
+	assert.Equal(t, []uint{1200, 1600}, []uint{ro[0].Width, ro[0].Height})
+	assert.Equal(t, []uint{800, 600}, []uint{ro[1].Width, ro[1].Height}, "refined viewport should be used")
+	assert.Equal(t, []uint{0, 0}, []uint{ro[2].Width, ro[2].Height}, "reflowable resources have no dimensions")
+	assert.NotContains(t, p.Metadata.OtherMetadata, VocabularyRendition+"viewport")
+}
+
+func TestViewportFixedLayoutDocuments(t *testing.T) {
+	p, err := loadPackageDoc("viewport")
+	assert.NoError(t, err)
+
+	f := fetcher.NewFileFetcher("/", "./testdata/viewport")
+	setFixedLayoutDimensions(p, f)
+
+	ro := p.ReadingOrder
+	assert.Equal(t, []uint{1000, 1500}, []uint{ro[0].Width, ro[0].Height})
+	assert.Equal(t, []uint{800, 600}, []uint{ro[1].Width, ro[1].Height}, "rendition:viewport should be used as a fallback")
+	assert.Equal(t, []uint{0, 0}, []uint{ro[2].Width, ro[2].Height})
+}
+
+func TestParserReadsViewportDimensionsWhenEnabled(t *testing.T) {
+	a := asset.FileWithMediaType("viewport.epub", &mediatype.EPUB)
+	f := fetcher.NewFileFetcher("/", "./testdata/viewport")
+
+	b, err := NewParserWithConfig(ParserConfig{}).Parse(a, f)
+	if assert.NoError(t, err) {
+		ro := b.Build().Manifest.ReadingOrder
+		assert.Equal(t, []uint{1200, 1600}, []uint{ro[0].Width, ro[0].Height}, "only the rendition:viewport metadata is used by default")
+	}
+
+	b, err = NewParserWithConfig(ParserConfig{ReadViewportDimensions: true}).Parse(a, f)
+	if assert.NoError(t, err) {
+		ro := b.Build().Manifest.ReadingOrder
+		assert.Equal(t, []uint{1000, 1500}, []uint{ro[0].Width, ro[0].Height})
+		assert.Equal(t, []uint{800, 600}, []uint{ro[1].Width, ro[1].Height})
+	}
+}
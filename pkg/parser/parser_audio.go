@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/content/iterator"
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/internal/extensions"
 	"github.com/readium/go-toolkit/pkg/manifest"
@@ -66,7 +67,13 @@ func (p AudioParser) Parse(asset asset.PublicationAsset, fetcher fetcher.Fetcher
 		ReadingOrder: readingOrder,
 	}
 
-	return pub.NewBuilder(manifest, fetcher, nil), nil // TODO services!
+	builder := pub.NewServicesBuilder(map[string]pub.ServiceFactory{
+		pub.ContentService_Name: pub.DefaultContentServiceFactory([]iterator.ResourceContentIteratorFactory{
+			iterator.AudioFactory(),
+			iterator.VideoFactory(), // e.g. WebM tracks
+		}),
+	})
+	return pub.NewBuilder(manifest, fetcher, builder), nil // TODO other services!
 }
 
 var allowed_extensions_audio_extra = map[string]struct{}{
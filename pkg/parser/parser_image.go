@@ -7,16 +7,45 @@ import (
 	"strings"
 
 	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/content/iterator"
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/internal/extensions"
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/go-toolkit/pkg/parser/acbf"
 	"github.com/readium/go-toolkit/pkg/pub"
+	"github.com/readium/go-toolkit/pkg/util"
+	"github.com/readium/go-toolkit/pkg/util/imagesize"
 )
 
+// Options of the [ImageParser].
+type ImageParserOptions struct {
+	DetectSpreads  bool    // Detects the double-page spreads among the pages using their dimensions, and sets the page and spread hints of the reading order.
+	SpreadMinRatio float64 // Minimum width/height ratio of a page to be considered a double-page spread. Defaults to [DefaultSpreadMinRatio].
+}
+
+const DefaultSpreadMinRatio = 1.2
+
+// Options used by the default [ImageParser] of the streamer.
+var DefaultImageParserOptions = ImageParserOptions{
+	DetectSpreads:  true,
+	SpreadMinRatio: DefaultSpreadMinRatio,
+}
+
 // Parses an image–based Publication from an unstructured archive format containing bitmap files, such as CBZ or a simple ZIP.
 // It can also work for a standalone bitmap file.
-type ImageParser struct{}
+type ImageParser struct {
+	options ImageParserOptions
+}
+
+func NewImageParser(options ImageParserOptions) ImageParser {
+	if options.SpreadMinRatio <= 0 {
+		options.SpreadMinRatio = DefaultSpreadMinRatio
+	}
+	return ImageParser{
+		options: options,
+	}
+}
 
 // Parse implements PublicationParser
 func (p ImageParser) Parse(asset asset.PublicationAsset, fetcher fetcher.Fetcher) (*pub.Builder, error) {
@@ -46,30 +75,171 @@ func (p ImageParser) Parse(asset asset.PublicationAsset, fetcher fetcher.Fetcher
 		return readingOrder[i].Href < readingOrder[j].Href
 	})
 
+	// Read the dimensions of the pages from their headers
+	imagesize.ProbeLinks(fetcher, readingOrder)
+
 	// Try to figure out the publication's title
 	title := guessPublicationTitleFromFileStructure(fetcher)
 	if title == "" {
 		title = asset.Name()
 	}
 
+	metadata := manifest.Metadata{
+		LocalizedTitle: manifest.NewLocalizedStringFromString(title),
+	}
+	services := map[string]pub.ServiceFactory{
+		pub.PositionsService_Name: pub.PerResourcePositionsServiceFactory("image/*"),
+		pub.ContentService_Name: pub.DefaultContentServiceFactory([]iterator.ResourceContentIteratorFactory{
+			iterator.ImageFactory(),
+		}),
+	}
+
+	// Enhance the publication with the ACBF document, if any
+	if acbfLink := findACBFLink(links); acbfLink != nil {
+		if doc := readACBF(fetcher, *acbfLink); doc != nil {
+			metadata = doc.Metadata(title)
+			readingOrder = applyACBFPages(readingOrder, doc, acbfLink.Href)
+			services[pub.GuidedNavigationService_Name] = acbf.GuidedNavigationFactory(doc, acbfLink.Href)
+		}
+	}
+	metadata.ConformsTo = manifest.Profiles{manifest.ProfileDivina}
+
 	// First valid resource is the cover.
 	readingOrder[0].Rels = []string{"cover"}
 
+	if p.options.DetectSpreads {
+		applySpreadHints(readingOrder, metadata.EffectiveReadingProgression(), p.options.SpreadMinRatio)
+	}
+
 	manifest := manifest.Manifest{
-		Context: manifest.Strings{manifest.WebpubManifestContext},
-		Metadata: manifest.Metadata{
-			LocalizedTitle: manifest.NewLocalizedStringFromString(title),
-			ConformsTo:     manifest.Profiles{manifest.ProfileDivina},
-		},
+		Context:      manifest.Strings{manifest.WebpubManifestContext},
+		Metadata:     metadata,
 		ReadingOrder: readingOrder,
 	}
 
-	builder := pub.NewServicesBuilder(map[string]pub.ServiceFactory{
-		pub.PositionsService_Name: pub.PerResourcePositionsServiceFactory("image/*"),
-	})
+	builder := pub.NewServicesBuilder(services)
 	return pub.NewBuilder(manifest, fetcher, builder), nil
 }
 
+func findACBFLink(links manifest.LinkList) *manifest.Link {
+	for i := range links {
+		if extensions.IsHiddenOrThumbs(links[i].Href) {
+			continue
+		}
+		if strings.ToLower(filepath.Ext(links[i].Href)) == ".acbf" {
+			return &links[i]
+		}
+	}
+	return nil
+}
+
+// Reads the ACBF document of the publication. Invalid documents are ignored.
+func readACBF(f fetcher.Fetcher, link manifest.Link) *acbf.Document {
+	res := f.Get(link)
+	defer res.Close()
+	data, rerr := res.Read(0, 0)
+	if rerr != nil {
+		return nil
+	}
+	doc, err := acbf.Parse(data)
+	if err != nil {
+		return nil
+	}
+	return doc
+}
+
+// Orders the reading order according to the pages of the ACBF document, and sets the page titles.
+// Bitmaps not referenced by the document are kept at the end, in their original order.
+func applyACBFPages(readingOrder manifest.LinkList, doc *acbf.Document, acbfHref string) manifest.LinkList {
+	language := doc.Language()
+	guides, _ := acbf.Guides(doc, acbfHref)
+	ordered := make(manifest.LinkList, 0, len(readingOrder))
+	used := make([]bool, len(readingOrder))
+	for _, page := range doc.AllPages() {
+		href, err := util.NewHREF(page.Image.Href, acbfHref).String()
+		if err != nil {
+			continue
+		}
+		i := readingOrder.IndexOfFirstWithHref(href)
+		if i < 0 || used[i] {
+			continue
+		}
+		used[i] = true
+		link := readingOrder[i]
+		if title := page.Title(language); title != "" {
+			link.Title = title
+		}
+		ref := strings.TrimPrefix(href, "/")
+		if _, ok := guides[ref]; ok {
+			link.Alternates = append(link.Alternates, pub.GuidedNavigationLink.ExpandTemplate(map[string]string{
+				"ref": ref,
+			}))
+		}
+		ordered = append(ordered, link)
+	}
+	for i, link := range readingOrder {
+		if !used[i] {
+			ordered = append(ordered, link)
+		}
+	}
+	return ordered
+}
+
+// Sets the page and spread hints of the pages, when double-page spreads are found among portrait pages.
+// Spreads are centered and displayed alone, while the other pages alternate between the left and right
+// sides according to the reading progression, starting with the cover on its own.
+func applySpreadHints(readingOrder manifest.LinkList, progression manifest.ReadingProgression, minRatio float64) {
+	if progression != manifest.LTR && progression != manifest.RTL {
+		// Spreads are not used for vertical publications
+		return
+	}
+	if minRatio <= 0 {
+		minRatio = DefaultSpreadMinRatio
+	}
+
+	spreads := make([]bool, len(readingOrder))
+	hasSpread, hasPortrait := false, false
+	for i, link := range readingOrder {
+		if link.Width > 0 && link.Height > 0 && float64(link.Width)/float64(link.Height) >= minRatio {
+			spreads[i] = true
+			hasSpread = true
+		} else {
+			hasPortrait = true
+		}
+	}
+	if !hasSpread || !hasPortrait {
+		return
+	}
+
+	// Side of the first page of a spread, and of the second one
+	first, second := manifest.PageLeft, manifest.PageRight
+	if progression == manifest.RTL {
+		first, second = second, first
+	}
+
+	// The cover is displayed alone, on the side of the second page
+	next := second
+	for i := range readingOrder {
+		properties := make(manifest.Properties, len(readingOrder[i].Properties)+2)
+		for k, v := range readingOrder[i].Properties {
+			properties[k] = v
+		}
+		if spreads[i] {
+			properties["page"] = string(manifest.PageCenter)
+			properties["spread"] = string(manifest.SpreadNone)
+			next = first
+		} else {
+			properties["page"] = string(next)
+			if next == first {
+				next = second
+			} else {
+				next = first
+			}
+		}
+		readingOrder[i].Properties = properties
+	}
+}
+
 var allowed_extensions_image = map[string]struct{}{"acbf": {}, "xml": {}, "txt": {}, "json": {}}
 
 func (p ImageParser) accepts(asset asset.PublicationAsset, fetcher fetcher.Fetcher) (bool, error) {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/readium/go-toolkit/pkg/archive"
 	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/content/element"
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/go-toolkit/pkg/pub"
 	"github.com/stretchr/testify/assert"
@@ -87,3 +88,127 @@ func TestImageTitleBasedOnRoot(t *testing.T) {
 		)
 	})
 }
+
+func TestImageACBF(t *testing.T) {
+	withImageParser(t, "./testdata/image/acbf_sample.cbz", func(p *pub.Builder) {
+		assert.NotNil(t, p)
+		pub := p.Build()
+		assert.NotNil(t, pub)
+
+		assert.Equal(t, "The Sample Comic", pub.Manifest.Metadata.LocalizedTitle.Translations["en"])
+		assert.Equal(t, manifest.RTL, pub.Manifest.Metadata.ReadingProgression)
+		assert.Equal(t, manifest.Profiles{manifest.ProfileDivina}, pub.Manifest.Metadata.ConformsTo)
+
+		ro := pub.Manifest.ReadingOrder
+		hrefs := make([]string, 0, len(ro))
+		for _, roi := range ro {
+			hrefs = append(hrefs, roi.Href)
+		}
+		assert.Exactly(t, []string{"/cover.png", "/01.png", "/02.png", "/03.png"}, hrefs, "readingOrder should follow the ACBF pages")
+		assert.Equal(t, manifest.Strings{"cover"}, ro[0].Rels)
+		assert.Equal(t, "First page", ro[1].Title)
+
+		if assert.Len(t, ro[1].Alternates, 1) {
+			assert.Equal(t, "/~readium/guided-navigation.json?ref=01.png", ro[1].Alternates[0].Href)
+		}
+		assert.Empty(t, ro[3].Alternates)
+
+		res := pub.Get(ro[1].Alternates[0])
+		doc, rerr := res.ReadAsJSON()
+		assert.Nil(t, rerr)
+		assert.Len(t, doc["guided"], 2)
+	})
+}
+
+func TestImageDimensions(t *testing.T) {
+	withImageParser(t, "./testdata/image/acbf_sample.cbz", func(p *pub.Builder) {
+		pub := p.Build()
+		sizes := make([][2]uint, 0, len(pub.Manifest.ReadingOrder))
+		for _, roi := range pub.Manifest.ReadingOrder {
+			sizes = append(sizes, [2]uint{roi.Width, roi.Height})
+		}
+		assert.Equal(t, [][2]uint{{100, 150}, {100, 150}, {200, 150}, {100, 150}}, sizes)
+	})
+}
+
+func TestImageSpreadsNotDetectedByDefault(t *testing.T) {
+	withImageParser(t, "./testdata/image/acbf_sample.cbz", func(p *pub.Builder) {
+		for _, roi := range p.Build().Manifest.ReadingOrder {
+			assert.Empty(t, roi.Properties.Page())
+		}
+	})
+}
+
+func TestImageSpreadsDetected(t *testing.T) {
+	a := asset.File("./testdata/image/acbf_sample.cbz")
+	fet, err := a.CreateFetcher(asset.Dependencies{
+		ArchiveFactory: archive.NewArchiveFactory(),
+	}, "")
+	assert.NoError(t, err)
+	p, err := NewImageParser(DefaultImageParserOptions).Parse(a, fet)
+	assert.NoError(t, err)
+
+	// The ACBF document sets a right-to-left reading progression
+	ro := p.Build().Manifest.ReadingOrder
+	assert.Equal(t, manifest.PageLeft, ro[0].Properties.Page())
+	assert.Equal(t, manifest.PageRight, ro[1].Properties.Page())
+	assert.Equal(t, manifest.PageCenter, ro[2].Properties.Page())
+	assert.Equal(t, manifest.SpreadNone, ro[2].Properties.Spread())
+	assert.Equal(t, manifest.PageRight, ro[3].Properties.Page())
+}
+
+func TestImageSpreadHintsLTR(t *testing.T) {
+	ro := manifest.LinkList{
+		{Href: "/1.jpg", Width: 600, Height: 900},
+		{Href: "/2.jpg", Width: 600, Height: 900},
+		{Href: "/3.jpg", Width: 600, Height: 900},
+		{Href: "/4.jpg", Width: 1200, Height: 900},
+		{Href: "/5.jpg"},
+		{Href: "/6.jpg", Width: 600, Height: 900, Properties: manifest.Properties{"orientation": "portrait"}},
+	}
+	applySpreadHints(ro, manifest.LTR, DefaultSpreadMinRatio)
+
+	pages := make([]manifest.Page, 0, len(ro))
+	for _, roi := range ro {
+		pages = append(pages, roi.Properties.Page())
+	}
+	assert.Equal(t, []manifest.Page{
+		manifest.PageRight, manifest.PageLeft, manifest.PageRight, manifest.PageCenter, manifest.PageLeft, manifest.PageRight,
+	}, pages)
+	assert.Equal(t, manifest.OrientationPortrait, ro[5].Properties.Orientation())
+}
+
+func TestImageSpreadHintsWithoutSpreads(t *testing.T) {
+	ro := manifest.LinkList{
+		{Href: "/1.jpg", Width: 600, Height: 900},
+		{Href: "/2.jpg", Width: 600, Height: 900},
+	}
+	applySpreadHints(ro, manifest.LTR, DefaultSpreadMinRatio)
+	assert.Nil(t, ro[0].Properties)
+
+	ro = manifest.LinkList{
+		{Href: "/1.jpg", Width: 600, Height: 900},
+		{Href: "/2.jpg", Width: 1200, Height: 900},
+	}
+	applySpreadHints(ro, manifest.TTB, DefaultSpreadMinRatio)
+	assert.Nil(t, ro[1].Properties)
+}
+
+func TestImageContentElements(t *testing.T) {
+	withImageParser(t, "./testdata/image/futuristic_tales.cbz", func(p *pub.Builder) {
+		publication := p.Build()
+		service, ok := publication.FindService(pub.ContentService_Name).(pub.ContentService)
+		if !assert.True(t, ok) {
+			return
+		}
+		elements, err := service.Content(nil).Elements()
+		assert.NoError(t, err)
+		if assert.Len(t, elements, 4) {
+			image, ok := elements[1].(element.ImageElement)
+			assert.True(t, ok)
+			assert.Equal(t, publication.Manifest.ReadingOrder[1].Href, image.Locator().Href)
+			assert.Equal(t, "image/jpeg", image.Locator().Type)
+			assert.Equal(t, strings.TrimPrefix(publication.Manifest.ReadingOrder[1].Href, "/"), image.EmbeddedLink().Href)
+		}
+	})
+}
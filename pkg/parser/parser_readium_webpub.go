@@ -5,6 +5,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/content/iterator"
 	"github.com/readium/go-toolkit/pkg/fetcher"
 	"github.com/readium/go-toolkit/pkg/manifest"
 	"github.com/readium/go-toolkit/pkg/mediatype"
@@ -82,5 +83,11 @@ func (p WebPubParser) Parse(asset asset.PublicationAsset, fetcher fetcher.Fetche
 		return nil, errors.New("invalid LCP protected PDF")
 	}
 
-	return pub.NewBuilder(*manifest, lFetcher, nil), nil // TODO services!
+	builder := pub.NewServicesBuilder(map[string]pub.ServiceFactory{
+		pub.ContentService_Name: pub.DefaultContentServiceFactory(append(
+			[]iterator.ResourceContentIteratorFactory{iterator.HTMLFactory()},
+			iterator.EmbeddedFactories()...,
+		)),
+	})
+	return pub.NewBuilder(*manifest, lFetcher, builder), nil // TODO other services!
 }
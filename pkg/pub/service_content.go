@@ -1,7 +1,14 @@
 package pub
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/readium/go-toolkit/pkg/content"
 	"github.com/readium/go-toolkit/pkg/content/element"
@@ -11,15 +18,17 @@ import (
 	"github.com/readium/go-toolkit/pkg/mediatype"
 )
 
-// TODO content iterator special ~readium link
-
 var ContentLink = manifest.Link{
-	Href: "/~readium/content.json",
-	Type: mediatype.ReadiumContentDocument.String(),
+	Href:      "/~readium/content.json{?href,progression,cssSelector,limit}",
+	Type:      mediatype.ReadiumContentDocument.String(),
+	Templated: true,
 }
 
-// TODO uri template or something so we're not just dumping entire content
-// progression, href, cssselector, text context
+// Number of elements in a page of content, when no limit is requested.
+const DefaultContentPageLimit = 100
+
+// Maximum number of elements in a page of content.
+const MaxContentPageLimit = 1000
 
 // PositionsService implements Service
 // Provides a way to extract the raw [Content] of a [Publication].
@@ -32,22 +41,217 @@ type ContentService interface {
 type DefaultContentService struct {
 	context                          Context
 	resourceContentIteratorFactories []iterator.ResourceContentIteratorFactory
+	nonLinearPolicy                  manifest.NonLinearPolicy
+}
+
+// A request for a page of the [Content] of a publication.
+type ContentPageQuery struct {
+	Start *manifest.Locator // Location of the first element of the page, or nil to start from the beginning of the publication.
+	Limit int               // Maximum number of elements in the page.
+}
+
+// Parses the query parameters of an expanded [ContentLink].
+// The start location is made of the `href` of a resource of the reading order, and of either a `cssSelector`
+// or a `progression` in this resource.
+func ContentPageQueryFromParameters(m manifest.Manifest, params url.Values) (ContentPageQuery, error) {
+	query := ContentPageQuery{Limit: DefaultContentPageLimit}
+	if l := params.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			return query, errors.Errorf("invalid limit \"%s\"", l)
+		}
+		query.Limit = min(limit, MaxContentPageLimit)
+	}
+
+	href := params.Get("href")
+	if href == "" {
+		if params.Get("progression") != "" || params.Get("cssSelector") != "" {
+			return query, errors.New("the start location requires an href")
+		}
+		return query, nil
+	}
+	link := m.ReadingOrder.FirstWithHref(href)
+	if link == nil {
+		// The hrefs of the reading order might be relative to the manifest
+		if strings.HasPrefix(href, "/") {
+			link = m.ReadingOrder.FirstWithHref(strings.TrimPrefix(href, "/"))
+		} else {
+			link = m.ReadingOrder.FirstWithHref("/" + href)
+		}
+		if link == nil {
+			return query, errors.Errorf("no resource with href \"%s\" in the reading order", href)
+		}
+	}
+
+	start := &manifest.Locator{
+		Href:  link.Href,
+		Type:  link.Type,
+		Title: link.Title,
+	}
+	if p := params.Get("progression"); p != "" {
+		progression, err := strconv.ParseFloat(p, 64)
+		if err != nil || progression < 0 || progression > 1 {
+			return query, errors.Errorf("invalid progression \"%s\"", p)
+		}
+		start.Locations.Progression = &progression
+	}
+	if sel := params.Get("cssSelector"); sel != "" {
+		start.Locations.OtherLocations = map[string]interface{}{"cssSelector": sel}
+	}
+	query.Start = start
+	return query, nil
+}
+
+// Parameters used to expand the [ContentLink] for this query.
+func (q ContentPageQuery) Parameters() map[string]string {
+	params := map[string]string{
+		"limit": strconv.Itoa(q.Limit),
+	}
+	if q.Start != nil {
+		params["href"] = q.Start.Href
+		if sel := q.Start.Locations.CSSSelector(); sel != "" {
+			params["cssSelector"] = sel
+		} else if p := q.Start.Locations.Progression; p != nil {
+			params["progression"] = strconv.FormatFloat(*p, 'f', -1, 64)
+		}
+	}
+	return params
+}
+
+// Writes the page of [c] requested by the [query] as a JSON object, encoding each element as soon as it is
+// iterated instead of buffering the whole page. The page might exceed the limit when several elements share
+// the same location, such as lines separated by <br> in a paragraph. The object contains:
+//   - `elements`, the elements of the page,
+//   - `next`, the locator of the first element of the next page, used as a cursor, if there's one,
+//   - `links`, with the `next` link to the following page, relative to the manifest.
+//
+// An error is returned without writing anything if the content can't be iterated.
+func WriteContentPage(w io.Writer, c content.Content, query ContentPageQuery, indent string) error {
+	it := c.Iterator()
+	hasNext, err := it.HasNext()
+	if err != nil {
+		return err
+	}
+
+	marshal := func(v interface{}, prefix string) ([]byte, error) {
+		if indent == "" {
+			return json.Marshal(v)
+		}
+		return json.MarshalIndent(v, prefix, indent)
+	}
+	newline, colon, itemPrefix := "", ":", ""
+	if indent != "" {
+		newline, colon, itemPrefix = "\n", ": ", indent+indent
+	}
+
+	if _, err := io.WriteString(w, "{"+newline+indent+`"elements"`+colon+"["); err != nil {
+		return err
+	}
+	var next element.Element
+	if hasNext {
+		next = it.Next()
+	}
+	var count int
+	var last *manifest.Locator
+	for ; next != nil && (count < query.Limit || sameContentLocation(last, next.Locator())); count++ {
+		bin, err := marshal(next, itemPrefix)
+		if err != nil {
+			return err
+		}
+		sep := ","
+		if count == 0 {
+			sep = ""
+		}
+		if _, err := io.WriteString(w, sep+newline+itemPrefix); err != nil {
+			return err
+		}
+		if _, err := w.Write(bin); err != nil {
+			return err
+		}
+
+		locator := next.Locator()
+		last = &locator
+		if next, err = iterator.ItNextOrNil(it); err != nil {
+			return err
+		}
+	}
+	closing := "]"
+	if count > 0 {
+		closing = newline + indent + "]"
+	}
+	if _, err := io.WriteString(w, closing); err != nil {
+		return err
+	}
+
+	if next != nil {
+		cursor := next.Locator()
+		nextQuery := ContentPageQuery{Start: &cursor, Limit: query.Limit}
+		nextLink := ContentLink.ExpandTemplate(nextQuery.Parameters())
+		nextLink.Href = strings.TrimPrefix(nextLink.Href, "/")
+		nextLink.Rels = manifest.Strings{"next"}
+
+		for _, field := range []struct {
+			key   string
+			value interface{}
+		}{
+			{"next", cursor},
+			{"links", manifest.LinkList{nextLink}},
+		} {
+			bin, err := marshal(field.value, indent)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, ","+newline+indent+`"`+field.key+`"`+colon); err != nil {
+				return err
+			}
+			if _, err := w.Write(bin); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, newline+"}")
+	return err
+}
+
+// Whether the two locators target the same location, in which case a page can't end between them because
+// the following page would start from the first one.
+func sameContentLocation(a *manifest.Locator, b manifest.Locator) bool {
+	if a == nil || a.Href != b.Href {
+		return false
+	}
+	sel := a.Locations.CSSSelector()
+	return sel != "" && sel == b.Locations.CSSSelector()
 }
 
-func GetForContentService(service ContentService, link manifest.Link) (fetcher.Resource, bool) {
-	if link.Href != ContentLink.Href {
+// The [m]anifest of the publication is used to resolve the start location of the requested page.
+func GetForContentService(service ContentService, m manifest.Manifest, link manifest.Link) (fetcher.Resource, bool) {
+	// See [GetForGuidedNavigationService] about this shortcut.
+	link.Href = strings.TrimPrefix(link.Href, "/")
+	if !strings.HasPrefix(link.Href, "~readium/content.json") {
 		return nil, false
 	}
+	if link.Templated {
+		link = link.ExpandTemplate(nil)
+	}
 
-	elements, err := content.ContentElements(service.Content(nil))
+	// The query is parsed as is, because [util.HREF] would decode the escaped `#` of a CSS selector.
+	u, err := url.Parse(link.Href)
+	if err != nil {
+		return nil, false
+	}
+	query, err := ContentPageQueryFromParameters(m, u.Query())
 	if err != nil {
-		return fetcher.NewFailureResource(ContentLink, fetcher.Other(err)), false
+		return fetcher.NewFailureResource(link, fetcher.BadRequest(err)), true
 	}
 
-	return fetcher.NewBytesResource(ContentLink, func() []byte {
-		// Warning: this can be a massive payload since it's the entire content of the publication right now
-		bin, _ := json.Marshal(elements)
-		return bin
+	var buf bytes.Buffer
+	if err := WriteContentPage(&buf, service.Content(query.Start), query, ""); err != nil {
+		return fetcher.NewFailureResource(link, fetcher.Other(err)), true
+	}
+	link.Type = ContentLink.Type
+	return fetcher.NewBytesResource(link, func() []byte {
+		return buf.Bytes()
 	}), true
 }
 
@@ -58,7 +262,7 @@ func (s DefaultContentService) Links() manifest.LinkList {
 }
 
 func (s DefaultContentService) Get(link manifest.Link) (fetcher.Resource, bool) {
-	return GetForContentService(s, link)
+	return GetForContentService(s, s.context.Manifest, link)
 }
 
 func (s DefaultContentService) Content(start *manifest.Locator) content.Content {
@@ -66,6 +270,7 @@ func (s DefaultContentService) Content(start *manifest.Locator) content.Content
 		context:                          s.context,
 		start:                            start,
 		resourceContentIteratorFactories: s.resourceContentIteratorFactories,
+		nonLinearPolicy:                  s.nonLinearPolicy,
 	}
 }
 
@@ -73,14 +278,16 @@ type contentImplementation struct {
 	context                          Context
 	start                            *manifest.Locator
 	resourceContentIteratorFactories []iterator.ResourceContentIteratorFactory
+	nonLinearPolicy                  manifest.NonLinearPolicy
 }
 
 func (c contentImplementation) Iterator() iterator.Iterator {
-	return iterator.NewPublicationContent(
+	return iterator.NewPublicationContentWithPolicy(
 		c.context.Manifest,
 		c.context.Fetcher,
 		c.start,
 		c.resourceContentIteratorFactories,
+		c.nonLinearPolicy,
 	)
 }
 
@@ -93,10 +300,16 @@ func (c contentImplementation) Text(separator *string) (string, error) {
 }
 
 func DefaultContentServiceFactory(resourceContentIteratorFactories []iterator.ResourceContentIteratorFactory) ServiceFactory {
+	return DefaultContentServiceFactoryWithPolicy(resourceContentIteratorFactories, manifest.NonLinearInclude)
+}
+
+// Same as [DefaultContentServiceFactory], with the given [policy] for the non-linear resources of the reading order.
+func DefaultContentServiceFactoryWithPolicy(resourceContentIteratorFactories []iterator.ResourceContentIteratorFactory, policy manifest.NonLinearPolicy) ServiceFactory {
 	return func(context Context) Service {
 		return DefaultContentService{
 			context:                          context,
 			resourceContentIteratorFactories: resourceContentIteratorFactories,
+			nonLinearPolicy:                  policy,
 		}
 	}
 }
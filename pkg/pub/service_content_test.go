@@ -0,0 +1,171 @@
+package pub
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/content/element"
+	"github.com/readium/go-toolkit/pkg/content/iterator"
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves in-memory resources by href.
+type contentTestFetcher map[string]string
+
+func (f contentTestFetcher) Links() (manifest.LinkList, error) {
+	return nil, nil
+}
+
+func (f contentTestFetcher) Get(link manifest.Link) fetcher.Resource {
+	data, ok := f[link.Href]
+	if !ok {
+		return fetcher.NewFailureResource(link, fetcher.NotFound(nil))
+	}
+	return fetcher.NewBytesResource(link, func() []byte {
+		return []byte(data)
+	})
+}
+
+func (f contentTestFetcher) Close() {}
+
+func newContentTestPublication() *Publication {
+	m := manifest.Manifest{
+		ReadingOrder: manifest.LinkList{
+			{Href: "/chapter1.xhtml", Type: "application/xhtml+xml"},
+			{Href: "/chapter2.xhtml", Type: "application/xhtml+xml"},
+		},
+	}
+	f := contentTestFetcher{
+		"/chapter1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+			<h1 id="title">Chapter 1</h1>
+			<p>First paragraph.</p>
+			<div><p>Second paragraph.</p><p id="third">Third paragraph.</p></div>
+			<p>Fourth paragraph.</p>
+		</body></html>`,
+		"/chapter2.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+			<h1>Chapter 2</h1>
+			<p>Fifth paragraph.</p>
+		</body></html>`,
+	}
+	return New(m, f, NewServicesBuilder(map[string]ServiceFactory{
+		ContentService_Name: DefaultContentServiceFactory([]iterator.ResourceContentIteratorFactory{
+			iterator.HTMLFactory(),
+		}),
+	}))
+}
+
+// Text elements are serialized as a list of segments.
+type contentTestPage struct {
+	Elements []struct {
+		Segments []struct {
+			Text string `json:"text"`
+		} `json:"text"`
+	} `json:"elements"`
+	Next  *manifest.Locator `json:"next"`
+	Links []struct {
+		Href string `json:"href"`
+		Rel  string `json:"rel"`
+	} `json:"links"`
+}
+
+func (p contentTestPage) texts() []string {
+	texts := make([]string, len(p.Elements))
+	for i, el := range p.Elements {
+		for _, s := range el.Segments {
+			texts[i] += s.Text
+		}
+	}
+	return texts
+}
+
+func readContentTestPage(t *testing.T, p *Publication, href string) contentTestPage {
+	res := p.Get(manifest.Link{Href: href})
+	bin, rerr := res.Read(0, 0)
+	if !assert.Nil(t, rerr) {
+		return contentTestPage{}
+	}
+	var page contentTestPage
+	assert.NoError(t, json.Unmarshal(bin, &page))
+	return page
+}
+
+func TestContentServiceLinkIsTemplated(t *testing.T) {
+	p := newContentTestPublication()
+	link := p.Manifest.Links.FirstWithMediaType(&mediatype.ReadiumContentDocument)
+	if !assert.NotNil(t, link) {
+		return
+	}
+	assert.True(t, link.Templated)
+	assert.Equal(t, "/~readium/content.json{?href,progression,cssSelector,limit}", link.Href)
+}
+
+func TestContentServiceFollowsPages(t *testing.T) {
+	p := newContentTestPublication()
+
+	service := p.FindService(ContentService_Name).(ContentService)
+	all, err := service.Content(nil).Elements()
+	assert.NoError(t, err)
+	var expected []string
+	for _, el := range all {
+		expected = append(expected, el.(element.TextualElement).Text())
+	}
+	assert.Len(t, expected, 7)
+
+	var texts []string
+	href := ContentLink.ExpandTemplate(map[string]string{"limit": "2"}).Href
+	for i := 0; i < 10 && href != ""; i++ {
+		page := readContentTestPage(t, p, href)
+		assert.LessOrEqual(t, len(page.Elements), 2)
+		texts = append(texts, page.texts()...)
+		href = ""
+		if page.Next != nil && assert.Len(t, page.Links, 1) {
+			assert.Equal(t, "next", page.Links[0].Rel)
+			href = page.Links[0].Href
+		}
+	}
+	assert.Equal(t, expected, texts)
+}
+
+func TestContentServiceStartLocation(t *testing.T) {
+	p := newContentTestPublication()
+
+	page := readContentTestPage(t, p, ContentLink.ExpandTemplate(map[string]string{
+		"href":        "chapter1.xhtml",
+		"cssSelector": "#third",
+		"limit":       "1",
+	}).Href)
+	assert.Equal(t, []string{"Third paragraph."}, page.texts())
+
+	page = readContentTestPage(t, p, ContentLink.ExpandTemplate(map[string]string{
+		"href":        "/chapter1.xhtml",
+		"progression": "1",
+	}).Href)
+	assert.Equal(t, []string{"Chapter 2", "Fifth paragraph."}, page.texts())
+	assert.Nil(t, page.Next)
+}
+
+func TestContentPageQueryFromParameters(t *testing.T) {
+	m := newContentTestPublication().Manifest
+
+	q, err := ContentPageQueryFromParameters(m, url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, ContentPageQuery{Limit: DefaultContentPageLimit}, q)
+
+	q, err = ContentPageQueryFromParameters(m, url.Values{"limit": {"100000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, MaxContentPageLimit, q.Limit)
+
+	for _, params := range []url.Values{
+		{"limit": {"0"}},
+		{"cssSelector": {"#third"}},
+		{"href": {"unknown.xhtml"}},
+		{"href": {"chapter1.xhtml"}, "progression": {"2"}},
+	} {
+		_, err = ContentPageQueryFromParameters(m, params)
+		assert.Error(t, err, params)
+	}
+}
@@ -35,6 +35,7 @@ type Config struct {
 	IgnoreDefaultParsers bool                       // When true, only parsers provided in parsers will be used.
 	InferA11yMetadata    InferA11yMetadata          // When not empty, additional accessibility metadata will be infered from the manifest.
 	InferPageCount       bool                       // When true, will infer `Metadata.NumberOfPages` from the generated position list.
+	EPUBParserConfig     epub.ParserConfig          // Configuration of the default EPUB parser, e.g. to read the viewport dimensions or the durations of the media overlays.
 	ArchiveFactory       archive.ArchiveFactory     // Opens an archive (e.g. ZIP, RAR), optionally protected by credentials.
 	HttpClient           *http.Client               // Service performing HTTP requests.
 }
@@ -61,10 +62,10 @@ func New(config Config) Streamer { // TODO contentProtections
 	}
 
 	defaultParsers := []parser.PublicationParser{
-		epub.NewParser(nil), // TODO pass strategy
+		epub.NewParserWithConfig(config.EPUBParserConfig),
 		pdf.NewParser(),
 		parser.NewWebPubParser(config.HttpClient),
-		parser.ImageParser{},
+		parser.NewImageParser(parser.DefaultImageParserOptions),
 		parser.AudioParser{},
 	}
 
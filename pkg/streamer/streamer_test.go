@@ -0,0 +1,27 @@
+package streamer
+
+import (
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/asset"
+	"github.com/readium/go-toolkit/pkg/mediatype"
+	"github.com/readium/go-toolkit/pkg/parser/epub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamerPassesTheEPUBParserConfig(t *testing.T) {
+	a := asset.FileWithMediaType("../parser/epub/testdata/mediaoverlay", &mediatype.EPUB)
+
+	p, err := New(Config{}).Open(a, "")
+	require.NoError(t, err)
+	assert.Zero(t, p.Manifest.ReadingOrder[0].Duration)
+	p.Close()
+
+	p, err = New(Config{
+		EPUBParserConfig: epub.ParserConfig{ReadMediaOverlayDurations: true},
+	}).Open(a, "")
+	require.NoError(t, err)
+	assert.InDelta(t, 14.5, p.Manifest.ReadingOrder[0].Duration, 0.0001)
+	p.Close()
+}
@@ -0,0 +1,601 @@
+// Package imagesize reads the dimensions of bitmap images from their headers, without decoding them.
+//
+// Supported formats are JPEG, PNG, GIF, WebP, AVIF (and other HEIF-based formats), JPEG XL, BMP and TIFF.
+//
+// The dimensions are the ones of the image as displayed, after applying its orientation: the EXIF orientation of
+// JPEG and TIFF images, the irot property of HEIF images and the orientation of the JPEG XL headers. The EXIF
+// metadata of PNG and WebP images are ignored.
+package imagesize
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// Dimensions of an image, in pixels.
+type Size struct {
+	Width  uint
+	Height uint
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Reads the dimensions of the image provided by [r], once oriented for display.
+// Only the parts of the image needed to find its dimensions are read.
+func Probe(r io.ReaderAt) (Size, error) {
+	s := source{r}
+	header, err := s.read(0, 16)
+	if err != nil && len(header) < 2 {
+		return Size{}, errors.Wrap(err, "failed reading image header")
+	}
+
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8}):
+		return probeJPEG(s)
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return probePNG(s)
+	case bytes.HasPrefix(header, []byte("GIF87a")), bytes.HasPrefix(header, []byte("GIF89a")):
+		return probeGIF(s)
+	case bytes.HasPrefix(header, []byte("RIFF")) && len(header) >= 12 && string(header[8:12]) == "WEBP":
+		return probeWebP(s)
+	case bytes.HasPrefix(header, []byte("BM")):
+		return probeBMP(s)
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")),
+		bytes.HasPrefix(header, []byte("II+\x00")), bytes.HasPrefix(header, []byte("MM\x00+")):
+		return probeTIFF(s)
+	case bytes.HasPrefix(header, []byte{0xFF, 0x0A}), bytes.HasPrefix(header, jxlSignature):
+		return probeJXL(s)
+	case len(header) >= 8 && string(header[4:8]) == "ftyp":
+		return probeHEIF(s)
+	}
+	return Size{}, ErrUnsupportedFormat
+}
+
+// Reads the EXIF orientation of the JPEG or TIFF image provided by [r], from 1 to 8, which decoders such as the
+// standard [image] package don't apply. The orientation of the other formats is 1.
+func ProbeOrientation(r io.ReaderAt) (uint, error) {
+	s := source{r}
+	header, err := s.read(0, 4)
+	if err != nil && len(header) < 2 {
+		return 0, errors.Wrap(err, "failed reading image header")
+	}
+
+	var orientation uint64
+	switch {
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8}):
+		_, orientation, err = readJPEG(s)
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")),
+		bytes.HasPrefix(header, []byte("II+\x00")), bytes.HasPrefix(header, []byte("MM\x00+")):
+		var tags map[uint16]uint64
+		tags, err = readTIFFTags(s, tiffTagOrientation)
+		orientation = tags[tiffTagOrientation]
+	default:
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if orientation < 1 || orientation > 8 {
+		orientation = 1
+	}
+	return uint(orientation), nil
+}
+
+// Helper to read exact amounts of data at an offset of an [io.ReaderAt].
+type source struct {
+	r io.ReaderAt
+}
+
+// Reads [n] bytes at [offset]. If less bytes are available, the partial data is returned with an error.
+func (s source) read(offset int64, n int) ([]byte, error) {
+	if offset < 0 || n < 0 {
+		return nil, errors.New("invalid offset")
+	}
+	buf := make([]byte, n)
+	m, err := s.r.ReadAt(buf, offset)
+	if m < n {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return buf[:m], err
+	}
+	return buf, nil
+}
+
+func (s source) u16(offset int64, order binary.ByteOrder) (uint16, error) {
+	b, err := s.read(offset, 2)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint16(b), nil
+}
+
+func (s source) u32(offset int64, order binary.ByteOrder) (uint32, error) {
+	b, err := s.read(offset, 4)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(b), nil
+}
+
+func (s source) u64(offset int64, order binary.ByteOrder) (uint64, error) {
+	b, err := s.read(offset, 8)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint64(b), nil
+}
+
+func checkSize(width, height uint64) (Size, error) {
+	if width == 0 || height == 0 {
+		return Size{}, errors.New("image has an empty dimension")
+	}
+	return Size{Width: uint(width), Height: uint(height)}, nil
+}
+
+// Swaps the width and height if the EXIF [orientation] (1 to 8) transposes the image.
+func (s Size) orient(orientation uint64) Size {
+	if orientation >= 5 && orientation <= 8 {
+		return Size{Width: s.Height, Height: s.Width}
+	}
+	return s
+}
+
+// https://www.w3.org/TR/png/#11IHDR
+func probePNG(s source) (Size, error) {
+	b, err := s.read(12, 12)
+	if err != nil {
+		return Size{}, err
+	}
+	if string(b[0:4]) != "IHDR" {
+		return Size{}, errors.New("PNG IHDR chunk not found")
+	}
+	return checkSize(uint64(binary.BigEndian.Uint32(b[4:8])), uint64(binary.BigEndian.Uint32(b[8:12])))
+}
+
+// https://www.w3.org/Graphics/GIF/spec-gif89a.txt (logical screen descriptor)
+func probeGIF(s source) (Size, error) {
+	b, err := s.read(6, 4)
+	if err != nil {
+		return Size{}, err
+	}
+	return checkSize(uint64(binary.LittleEndian.Uint16(b[0:2])), uint64(binary.LittleEndian.Uint16(b[2:4])))
+}
+
+func probeBMP(s source) (Size, error) {
+	headerSize, err := s.u32(14, binary.LittleEndian)
+	if err != nil {
+		return Size{}, err
+	}
+	if headerSize == 12 {
+		// OS/2 BITMAPCOREHEADER
+		b, err := s.read(18, 4)
+		if err != nil {
+			return Size{}, err
+		}
+		return checkSize(uint64(binary.LittleEndian.Uint16(b[0:2])), uint64(binary.LittleEndian.Uint16(b[2:4])))
+	}
+	b, err := s.read(18, 8)
+	if err != nil {
+		return Size{}, err
+	}
+	width := int64(int32(binary.LittleEndian.Uint32(b[0:4])))
+	height := int64(int32(binary.LittleEndian.Uint32(b[4:8])))
+	if height < 0 {
+		height = -height // Top-down bitmap
+	}
+	if width < 0 {
+		return Size{}, errors.New("BMP has a negative width")
+	}
+	return checkSize(uint64(width), uint64(height))
+}
+
+func probeJPEG(s source) (Size, error) {
+	size, orientation, err := readJPEG(s)
+	if err != nil {
+		return Size{}, err
+	}
+	return size.orient(orientation), nil
+}
+
+// Reads the dimensions of the JPEG image as stored, and its EXIF orientation.
+// https://www.w3.org/Graphics/JPEG/itu-t81.pdf (B.2)
+func readJPEG(s source) (Size, uint64, error) {
+	offset := int64(2)
+	orientation := uint64(1)
+	exifFound := false
+	for {
+		b, err := s.read(offset, 2)
+		if err != nil {
+			return Size{}, 0, err
+		}
+		if b[0] != 0xFF {
+			return Size{}, 0, errors.New("invalid JPEG marker")
+		}
+		marker := b[1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte
+			offset++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without payload
+			offset += 2
+			continue
+		case marker == 0xD9 || marker == 0xDA:
+			return Size{}, 0, errors.New("JPEG SOF marker not found")
+		}
+
+		length, err := s.u16(offset+2, binary.BigEndian)
+		if err != nil {
+			return Size{}, 0, err
+		}
+		if length < 2 {
+			return Size{}, 0, errors.New("invalid JPEG segment length")
+		}
+
+		// SOFn markers, excluding DHT (C4), JPG (C8) and DAC (CC)
+		if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+			b, err := s.read(offset+5, 4)
+			if err != nil {
+				return Size{}, 0, err
+			}
+			size, err := checkSize(uint64(binary.BigEndian.Uint16(b[2:4])), uint64(binary.BigEndian.Uint16(b[0:2])))
+			return size, orientation, err
+		}
+
+		// APP1 segment with the EXIF metadata, a TIFF structure following the "Exif\0\0" identifier
+		// https://www.cipa.jp/std/documents/e/DC-X008-Translation-2019-E.pdf (4.5.4)
+		if marker == 0xE1 && !exifFound && length >= 8 {
+			if id, err := s.read(offset+4, 6); err == nil && string(id) == "Exif\x00\x00" {
+				exifFound = true
+				exif := source{io.NewSectionReader(s.r, offset+10, int64(length)-8)}
+				// Invalid metadata don't prevent reading the dimensions
+				if tags, err := readTIFFTags(exif, tiffTagOrientation); err == nil && tags[tiffTagOrientation] != 0 {
+					orientation = tags[tiffTagOrientation]
+				}
+			}
+		}
+		offset += 2 + int64(length)
+	}
+}
+
+// https://developers.google.com/speed/webp/docs/riff_container
+func probeWebP(s source) (Size, error) {
+	chunk, err := s.read(12, 4)
+	if err != nil {
+		return Size{}, err
+	}
+	switch string(chunk) {
+	case "VP8 ":
+		// Lossy, https://datatracker.ietf.org/doc/html/rfc6386#section-9.1
+		b, err := s.read(20, 10)
+		if err != nil {
+			return Size{}, err
+		}
+		if b[3] != 0x9D || b[4] != 0x01 || b[5] != 0x2A {
+			return Size{}, errors.New("invalid VP8 start code")
+		}
+		w := binary.LittleEndian.Uint16(b[6:8]) & 0x3FFF
+		h := binary.LittleEndian.Uint16(b[8:10]) & 0x3FFF
+		return checkSize(uint64(w), uint64(h))
+	case "VP8L":
+		// Lossless, https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification#3_riff_header
+		b, err := s.read(20, 5)
+		if err != nil {
+			return Size{}, err
+		}
+		if b[0] != 0x2F {
+			return Size{}, errors.New("invalid VP8L signature")
+		}
+		bits := binary.LittleEndian.Uint32(b[1:5])
+		return checkSize(uint64(bits&0x3FFF)+1, uint64((bits>>14)&0x3FFF)+1)
+	case "VP8X":
+		// Extended
+		b, err := s.read(24, 6)
+		if err != nil {
+			return Size{}, err
+		}
+		w := uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16
+		h := uint64(b[3]) | uint64(b[4])<<8 | uint64(b[5])<<16
+		return checkSize(w+1, h+1)
+	}
+	return Size{}, errors.New("unknown WebP chunk")
+}
+
+const (
+	tiffTagImageWidth  = 256
+	tiffTagImageLength = 257
+	tiffTagOrientation = 274
+)
+
+func probeTIFF(s source) (Size, error) {
+	tags, err := readTIFFTags(s, tiffTagImageWidth, tiffTagImageLength, tiffTagOrientation)
+	if err != nil {
+		return Size{}, err
+	}
+	size, err := checkSize(tags[tiffTagImageWidth], tags[tiffTagImageLength])
+	return size.orient(tags[tiffTagOrientation]), err
+}
+
+// Reads the numeric values of the given [tags] in the first image file directory of a TIFF structure, used by
+// TIFF images and EXIF metadata. Missing tags are absent from the result.
+// https://www.itu.int/itudoc/itu-t/com16/tiff-fx/docs/tiff6.pdf
+// https://www.awaresystems.be/imaging/tiff/bigtiff.html
+func readTIFFTags(s source, tags ...uint16) (map[uint16]uint64, error) {
+	b, err := s.read(0, 4)
+	if err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch string(b[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid TIFF byte order")
+	}
+	big := order.Uint16(b[2:4]) == 43
+
+	var ifdOffset, count uint64
+	var entrySize, countSize int64 = 12, 2
+	if big {
+		entrySize, countSize = 20, 8
+		if ifdOffset, err = s.u64(8, order); err != nil {
+			return nil, err
+		}
+		if count, err = s.u64(int64(ifdOffset), order); err != nil {
+			return nil, err
+		}
+	} else {
+		o, err := s.u32(4, order)
+		if err != nil {
+			return nil, err
+		}
+		ifdOffset = uint64(o)
+		c, err := s.u16(int64(ifdOffset), order)
+		if err != nil {
+			return nil, err
+		}
+		count = uint64(c)
+	}
+
+	if count > 4096 {
+		return nil, errors.New("too many TIFF IFD entries")
+	}
+	entries, err := s.read(int64(ifdOffset)+countSize, int(count)*int(entrySize))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[uint16]uint64, len(tags))
+	for i := int64(0); i < int64(count); i++ {
+		e := entries[i*entrySize : (i+1)*entrySize]
+		tag := order.Uint16(e[0:2])
+		if !slices.Contains(tags, tag) {
+			continue
+		}
+		valueOffset := 8
+		if big {
+			valueOffset = 12
+		}
+		switch order.Uint16(e[2:4]) {
+		case 3: // SHORT
+			values[tag] = uint64(order.Uint16(e[valueOffset : valueOffset+2]))
+		case 4: // LONG
+			values[tag] = uint64(order.Uint32(e[valueOffset : valueOffset+4]))
+		case 16: // LONG8
+			if big {
+				values[tag] = order.Uint64(e[valueOffset : valueOffset+8])
+			}
+		}
+	}
+	return values, nil
+}
+
+var jxlSignature = []byte{0x00, 0x00, 0x00, 0x0C, 'J', 'X', 'L', ' ', 0x0D, 0x0A, 0x87, 0x0A}
+
+// https://github.com/libjxl/libjxl/blob/main/doc/format_overview.md
+func probeJXL(s source) (Size, error) {
+	codestream := int64(0)
+	b, err := s.read(0, 2)
+	if err != nil {
+		return Size{}, err
+	}
+	if b[0] != 0xFF {
+		// ISOBMFF-based container, find the codestream box
+		found := false
+		err := walkBoxes(s, int64(len(jxlSignature)), -1, func(boxType string, offset, size int64) (bool, error) {
+			switch boxType {
+			case "jxlc":
+				codestream = offset
+			case "jxlp":
+				codestream = offset + 4 // Skip the index of the partial codestream
+			default:
+				return true, nil
+			}
+			found = true
+			return false, nil
+		})
+		if err != nil {
+			return Size{}, err
+		}
+		if !found {
+			return Size{}, errors.New("JPEG XL codestream not found")
+		}
+	}
+
+	// Signature (2 bytes), SizeHeader (at most 9 bytes) and the start of ImageMetadata
+	b, err = s.read(codestream, 12)
+	if err != nil && len(b) < 3 {
+		return Size{}, err
+	}
+	if b[0] != 0xFF || b[1] != 0x0A {
+		return Size{}, errors.New("invalid JPEG XL codestream signature")
+	}
+	br := &bitReader{data: b[2:]}
+	u32 := func() uint64 {
+		switch br.bits(2) {
+		case 0:
+			return br.bits(9) + 1
+		case 1:
+			return br.bits(13) + 1
+		case 2:
+			return br.bits(18) + 1
+		default:
+			return br.bits(30) + 1
+		}
+	}
+
+	var width, height uint64
+	small := br.bits(1) == 1
+	if small {
+		height = (br.bits(5) + 1) * 8
+	} else {
+		height = u32()
+	}
+	ratio := br.bits(3)
+	switch {
+	case ratio == 0 && small:
+		width = (br.bits(5) + 1) * 8
+	case ratio == 0:
+		width = u32()
+	default:
+		r := jxlRatios[ratio-1]
+		width = height * r[0] / r[1]
+	}
+	if br.overflow {
+		return Size{}, io.ErrUnexpectedEOF
+	}
+
+	// The orientation is declared at the start of ImageMetadata, when its fields don't have their default values
+	orientation := uint64(1)
+	if allDefault := br.bits(1); allDefault == 0 {
+		if extraFields := br.bits(1); extraFields == 1 {
+			orientation = br.bits(3) + 1
+		}
+	}
+	if br.overflow {
+		orientation = 1
+	}
+	size, err := checkSize(width, height)
+	return size.orient(orientation), err
+}
+
+var jxlRatios = [7][2]uint64{{1, 1}, {12, 10}, {4, 3}, {3, 2}, {16, 9}, {5, 4}, {2, 1}}
+
+// Reads bits starting from the least significant bit of each byte.
+type bitReader struct {
+	data     []byte
+	pos      uint
+	overflow bool
+}
+
+func (r *bitReader) bits(n uint) uint64 {
+	var v uint64
+	for i := uint(0); i < n; i++ {
+		idx := r.pos / 8
+		if int(idx) >= len(r.data) {
+			r.overflow = true
+			return 0
+		}
+		v |= uint64((r.data[idx]>>(r.pos%8))&1) << i
+		r.pos++
+	}
+	return v
+}
+
+// Reads the image spatial extents properties (ispe) of a HEIF-based image, such as AVIF.
+// The largest extents are used, as the image can contain smaller thumbnails. They are swapped if an image rotation
+// property (irot) turns the image by 90 or 270 degrees.
+// https://aomediacodec.github.io/av1-avif/
+func probeHEIF(s source) (Size, error) {
+	var size Size
+	transposed := false
+	var visit func(start, end int64, path string) error
+	visit = func(start, end int64, path string) error {
+		return walkBoxes(s, start, end, func(boxType string, offset, boxSize int64) (bool, error) {
+			boxEnd := int64(-1)
+			if boxSize >= 0 {
+				boxEnd = offset + boxSize
+			}
+			switch {
+			case path == "" && boxType == "meta":
+				// meta is a full box, skip version and flags
+				return false, visit(offset+4, boxEnd, "meta")
+			case path == "meta" && boxType == "iprp":
+				return true, visit(offset, boxEnd, "iprp")
+			case path == "iprp" && boxType == "ipco":
+				return true, visit(offset, boxEnd, "ipco")
+			case path == "ipco" && boxType == "ispe":
+				b, err := s.read(offset+4, 8)
+				if err != nil {
+					return false, err
+				}
+				w, h := uint(binary.BigEndian.Uint32(b[0:4])), uint(binary.BigEndian.Uint32(b[4:8]))
+				if w*h > size.Width*size.Height {
+					size = Size{Width: w, Height: h}
+				}
+			case path == "ipco" && boxType == "irot":
+				b, err := s.read(offset, 1)
+				if err != nil {
+					return false, err
+				}
+				// Anti-clockwise rotation in units of 90 degrees
+				transposed = b[0]&1 == 1
+			}
+			return true, nil
+		})
+	}
+	if err := visit(0, -1, ""); err != nil {
+		return Size{}, err
+	}
+	if transposed {
+		size = Size{Width: size.Height, Height: size.Width}
+	}
+	return checkSize(uint64(size.Width), uint64(size.Height))
+}
+
+// Iterates over the ISOBMFF boxes located between [start] and [end] (-1 for the end of the file).
+// The callback receives the type, the offset of the payload and the payload size (-1 if unknown) of each box,
+// and returns whether the iteration should continue.
+func walkBoxes(s source, start, end int64, f func(boxType string, offset, size int64) (bool, error)) error {
+	offset := start
+	for end < 0 || offset+8 <= end {
+		b, err := s.read(offset, 8)
+		if err != nil {
+			if end < 0 && len(b) == 0 {
+				return nil // End of the file
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(b[0:4]))
+		boxType := string(b[4:8])
+		header := int64(8)
+		if size == 1 {
+			large, err := s.u64(offset+8, binary.BigEndian)
+			if err != nil {
+				return err
+			}
+			size = int64(large)
+			header = 16
+		}
+		payload := int64(-1)
+		if size != 0 {
+			if size < header {
+				return errors.New("invalid box size")
+			}
+			payload = size - header
+		}
+		cont, err := f(boxType, offset+header, payload)
+		if err != nil || !cont || size == 0 {
+			return err
+		}
+		offset += size
+	}
+	return nil
+}
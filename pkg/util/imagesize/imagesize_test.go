@@ -0,0 +1,274 @@
+package imagesize
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+func probeBytes(t *testing.T, data []byte) Size {
+	size, err := Probe(bytes.NewReader(data))
+	assert.NoError(t, err)
+	return size
+}
+
+func TestProbeStandardFormats(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 120, 45))
+	encoders := map[string]func(*bytes.Buffer) error{
+		"png":  func(b *bytes.Buffer) error { return png.Encode(b, img) },
+		"gif":  func(b *bytes.Buffer) error { return gif.Encode(b, img, nil) },
+		"jpeg": func(b *bytes.Buffer) error { return jpeg.Encode(b, img, nil) },
+		"bmp":  func(b *bytes.Buffer) error { return bmp.Encode(b, img) },
+		"tiff": func(b *bytes.Buffer) error { return tiff.Encode(b, img, nil) },
+	}
+	for name, encode := range encoders {
+		var b bytes.Buffer
+		assert.NoError(t, encode(&b))
+		assert.Equal(t, Size{Width: 120, Height: 45}, probeBytes(t, b.Bytes()), name)
+	}
+}
+
+func TestProbeJPEGWithSegments(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, 31, 17)), nil))
+	data := b.Bytes()
+
+	// Insert a large APP1 segment and fill bytes before the frame header
+	app := append([]byte{0xFF, 0xFF, 0xE1, 0x20, 0x02}, make([]byte, 0x2000)...)
+	data = append(append(append([]byte{}, data[:2]...), app...), data[2:]...)
+	assert.Equal(t, Size{Width: 31, Height: 17}, probeBytes(t, data))
+}
+
+// Minimal TIFF structure with a single image file directory of SHORT values.
+func tiffStructure(order binary.AppendByteOrder, tags map[uint16]uint16) []byte {
+	var data []byte
+	if order == binary.LittleEndian {
+		data = []byte("II*\x00")
+	} else {
+		data = []byte("MM\x00*")
+	}
+	data = order.AppendUint32(data, 8)
+	data = order.AppendUint16(data, uint16(len(tags)))
+	for _, tag := range []uint16{256, 257, 274} {
+		if value, ok := tags[tag]; ok {
+			data = order.AppendUint16(data, tag)
+			data = order.AppendUint16(data, 3) // SHORT
+			data = order.AppendUint32(data, 1) // Count
+			data = order.AppendUint16(data, value)
+			data = append(data, 0, 0)
+		}
+	}
+	return order.AppendUint32(data, 0) // No next IFD
+}
+
+func TestProbeOrientedTIFF(t *testing.T) {
+	for orientation, expected := range map[uint16]Size{
+		1: {Width: 120, Height: 45},
+		3: {Width: 120, Height: 45},
+		6: {Width: 45, Height: 120},
+		8: {Width: 45, Height: 120},
+	} {
+		for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+			data := tiffStructure(order, map[uint16]uint16{256: 120, 257: 45, 274: orientation})
+			assert.Equal(t, expected, probeBytes(t, data), "orientation %d", orientation)
+		}
+	}
+}
+
+func TestProbeOrientedJPEG(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, 31, 17)), nil))
+	withExif := func(exif []byte) []byte {
+		payload := append([]byte("Exif\x00\x00"), exif...)
+		app := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+		return bytes.Join([][]byte{b.Bytes()[:2], app, payload, b.Bytes()[2:]}, nil)
+	}
+
+	rotated := withExif(tiffStructure(binary.BigEndian, map[uint16]uint16{274: 6}))
+	assert.Equal(t, Size{Width: 17, Height: 31}, probeBytes(t, rotated))
+
+	upsideDown := withExif(tiffStructure(binary.LittleEndian, map[uint16]uint16{274: 3}))
+	assert.Equal(t, Size{Width: 31, Height: 17}, probeBytes(t, upsideDown))
+
+	// Invalid EXIF metadata are ignored
+	assert.Equal(t, Size{Width: 31, Height: 17}, probeBytes(t, withExif([]byte("XX\x00*"))))
+}
+
+func TestProbeOrientation(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, 31, 17)), nil))
+	payload := append([]byte("Exif\x00\x00"), tiffStructure(binary.BigEndian, map[uint16]uint16{274: 6})...)
+	app := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+	rotated := bytes.Join([][]byte{b.Bytes()[:2], app, payload, b.Bytes()[2:]}, nil)
+
+	tests := []struct {
+		data        []byte
+		orientation uint
+		name        string
+	}{
+		{rotated, 6, "JPEG with EXIF"},
+		{b.Bytes(), 1, "JPEG without EXIF"},
+		{tiffStructure(binary.LittleEndian, map[uint16]uint16{256: 120, 257: 45, 274: 8}), 8, "TIFF"},
+		{tiffStructure(binary.LittleEndian, map[uint16]uint16{256: 120, 257: 45, 274: 9}), 1, "invalid orientation"},
+		{[]byte("\x89PNG\r\n\x1a\n"), 1, "other format"},
+	}
+	for _, tt := range tests {
+		orientation, err := ProbeOrientation(bytes.NewReader(tt.data))
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.orientation, orientation, tt.name)
+	}
+
+	_, err := ProbeOrientation(bytes.NewReader(rotated[:4]))
+	assert.Error(t, err)
+}
+
+func riff(chunk string, payload []byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+	return append(data, payload...)
+}
+
+func TestProbeWebP(t *testing.T) {
+	lossy := riff("VP8 ", []byte{0x00, 0x00, 0x00, 0x9D, 0x01, 0x2A, 0x90, 0x01, 0x2C, 0x01})
+	assert.Equal(t, Size{Width: 400, Height: 300}, probeBytes(t, lossy))
+
+	bits := uint32(399) | uint32(299)<<14
+	lossless := riff("VP8L", binary.LittleEndian.AppendUint32([]byte{0x2F}, bits))
+	assert.Equal(t, Size{Width: 400, Height: 300}, probeBytes(t, lossless))
+
+	extended := riff("VP8X", []byte{0x00, 0x00, 0x00, 0x00, 0x8F, 0x01, 0x00, 0x2B, 0x01, 0x00})
+	assert.Equal(t, Size{Width: 400, Height: 300}, probeBytes(t, extended))
+}
+
+func box(boxType string, payload ...[]byte) []byte {
+	content := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	return append(append(b, boxType...), content...)
+}
+
+func TestProbeAVIF(t *testing.T) {
+	ispe := func(w, h uint32) []byte {
+		return box("ispe", []byte{0, 0, 0, 0}, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, w), h))
+	}
+	data := bytes.Join([][]byte{
+		box("ftyp", []byte("avif\x00\x00\x00\x00mif1avif")),
+		box("meta",
+			[]byte{0, 0, 0, 0},
+			box("hdlr", make([]byte, 24)),
+			box("iprp", box("ipco", ispe(160, 90), ispe(1920, 1080))),
+		),
+		box("mdat", make([]byte, 32)),
+	}, nil)
+	assert.Equal(t, Size{Width: 1920, Height: 1080}, probeBytes(t, data))
+
+	for angle, expected := range map[byte]Size{
+		0: {Width: 1920, Height: 1080},
+		1: {Width: 1080, Height: 1920},
+		2: {Width: 1920, Height: 1080},
+		3: {Width: 1080, Height: 1920},
+	} {
+		data := bytes.Join([][]byte{
+			box("ftyp", []byte("avif\x00\x00\x00\x00mif1avif")),
+			box("meta",
+				[]byte{0, 0, 0, 0},
+				box("iprp", box("ipco", ispe(1920, 1080), box("irot", []byte{angle}))),
+			),
+		}, nil)
+		assert.Equal(t, expected, probeBytes(t, data), "angle %d", angle)
+	}
+}
+
+type bitWriter struct {
+	data []byte
+	pos  uint
+}
+
+func (w *bitWriter) write(n uint, v uint64) {
+	for i := uint(0); i < n; i++ {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[w.pos/8] |= byte((v>>i)&1) << (w.pos % 8)
+		w.pos++
+	}
+}
+
+func TestProbeJXL(t *testing.T) {
+	// Small header, 64x64 with a 1:1 ratio
+	assert.Equal(t, Size{Width: 64, Height: 64}, probeBytes(t, []byte{0xFF, 0x0A, 0x4F, 0x00}))
+
+	// Explicit dimensions
+	w := &bitWriter{}
+	w.write(1, 0)    // Not small
+	w.write(2, 1)    // 13 bits height
+	w.write(13, 999) // Height - 1
+	w.write(3, 0)    // No ratio
+	w.write(2, 0)    // 9 bits width
+	w.write(9, 499)  // Width - 1
+	codestream := append([]byte{0xFF, 0x0A}, w.data...)
+	assert.Equal(t, Size{Width: 500, Height: 1000}, probeBytes(t, codestream))
+
+	// Ratio of 16:9
+	w = &bitWriter{}
+	w.write(1, 0)
+	w.write(2, 1)
+	w.write(13, 1079)
+	w.write(3, 5)
+	assert.Equal(t, Size{Width: 1920, Height: 1080}, probeBytes(t, append([]byte{0xFF, 0x0A}, w.data...)))
+
+	// Orientation declared in the image metadata
+	w = &bitWriter{}
+	w.write(1, 0)
+	w.write(2, 1)
+	w.write(13, 999)
+	w.write(3, 0)
+	w.write(2, 0)
+	w.write(9, 499)
+	w.write(1, 0) // Not all default
+	w.write(1, 1) // Extra fields
+	w.write(3, 5) // Orientation - 1
+	assert.Equal(t, Size{Width: 1000, Height: 500}, probeBytes(t, append([]byte{0xFF, 0x0A}, w.data...)))
+
+	// Container
+	container := bytes.Join([][]byte{
+		jxlSignature,
+		box("ftyp", []byte("jxl \x00\x00\x00\x00jxl ")),
+		box("jxlc", codestream),
+	}, nil)
+	assert.Equal(t, Size{Width: 500, Height: 1000}, probeBytes(t, container))
+}
+
+func TestProbeErrors(t *testing.T) {
+	_, err := Probe(bytes.NewReader([]byte("not an image at all")))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = Probe(bytes.NewReader([]byte("\x89PNG\r\n\x1a\n\x00\x00")))
+	assert.Error(t, err)
+
+	_, err = Probe(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}))
+	assert.Error(t, err)
+}
+
+func TestProbeResource(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, 31, 17)), nil))
+
+	// Frame header located after the initial prefix
+	data := b.Bytes()
+	app := append([]byte{0xFF, 0xE2, 0xFF, 0xFF}, make([]byte, 0xFFFD)...)
+	data = append(append(append([]byte{}, data[:2]...), app...), data[2:]...)
+
+	res := fetcher.NewBytesResource(manifest.Link{Href: "/image.jpg"}, func() []byte { return data })
+	size, err := ProbeResource(res)
+	assert.NoError(t, err)
+	assert.Equal(t, Size{Width: 31, Height: 17}, size)
+}
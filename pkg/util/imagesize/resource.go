@@ -0,0 +1,95 @@
+package imagesize
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/readium/go-toolkit/pkg/fetcher"
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+const (
+	initialPrefixLength = 4096
+	maxPrefixLength     = 1 << 20
+)
+
+// Reads the dimensions of the image provided by a [fetcher.Resource].
+// The resource is read by growing chunks from its start, so that only its header is decompressed when it is
+// stored in an archive.
+func ProbeResource(resource fetcher.Resource) (Size, error) {
+	return Probe(&resourceReaderAt{resource: resource})
+}
+
+// Implements [io.ReaderAt] by keeping a growing prefix of the resource in memory.
+// Reads far from the start of the resource, e.g. for TIFF directories, are not buffered.
+type resourceReaderAt struct {
+	resource fetcher.Resource
+	prefix   []byte
+	eof      bool
+}
+
+func (r *resourceReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	if end > int64(len(r.prefix)) && !r.eof {
+		if end > maxPrefixLength {
+			return r.readDirectly(p, off)
+		}
+		length := max(int64(initialPrefixLength), 2*int64(len(r.prefix)), end)
+		if length > maxPrefixLength {
+			length = maxPrefixLength
+		}
+		var buf bytes.Buffer
+		n, rerr := r.resource.Stream(&buf, 0, length-1)
+		if rerr != nil {
+			return 0, rerr
+		}
+		r.prefix = buf.Bytes()
+		r.eof = n < length
+	}
+	if off >= int64(len(r.prefix)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.prefix[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *resourceReaderAt) readDirectly(p []byte, off int64) (int, error) {
+	length, rerr := r.resource.Length()
+	if rerr != nil {
+		return 0, rerr
+	}
+	if off >= length {
+		return 0, io.EOF
+	}
+	var buf bytes.Buffer
+	if _, rerr := r.resource.Stream(&buf, off, min(off+int64(len(p)), length)-1); rerr != nil {
+		return 0, rerr
+	}
+	n := copy(p, buf.Bytes())
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Sets the width and height of the bitmap links which don't have them yet, by probing their resources.
+// Links which can't be probed are left untouched.
+func ProbeLinks(f fetcher.Fetcher, links manifest.LinkList) {
+	for i := range links {
+		link := &links[i]
+		if (link.Width > 0 && link.Height > 0) || !link.MediaType().IsBitmap() {
+			continue
+		}
+		res := f.Get(*link)
+		size, err := ProbeResource(res)
+		res.Close()
+		if err != nil {
+			continue
+		}
+		link.Width = size.Width
+		link.Height = size.Height
+	}
+}
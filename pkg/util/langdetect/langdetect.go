@@ -0,0 +1,118 @@
+// Package langdetect identifies the language of a text, without any external service.
+//
+// The script of the text is enough to identify languages such as Greek or Korean. Languages sharing a script,
+// e.g. the ones written in Latin or Cyrillic alphabets, are told apart by comparing the frequency of the
+// n-grams of the text with profiles built from sample texts.
+package langdetect
+
+import (
+	"math"
+	"sort"
+)
+
+// Confidence above which a detected language is reliable enough to be used, e.g. to select a TTS voice.
+const DefaultThreshold = 0.5
+
+// Minimum number of letters needed to compare the text with the language profiles.
+const minLetters = 12
+
+// Difference between the log-likelihoods of the two most likely languages, summed over the n-grams of the text,
+// giving a confidence of tanh(1) ≈ 0.76. It was calibrated on sample texts, so that a single sentence is enough to
+// tell apart close languages such as Danish and Norwegian, while a short title stays unreliable.
+const evidenceScale = 15
+
+// Language detected in a text.
+type Result struct {
+	Language   string  // BCP 47 language tag, or an empty string if the language is unknown.
+	Confidence float64 // Between 0 (unknown) and 1 (certain).
+}
+
+// Returns whether the language is known with a confidence of at least [threshold].
+func (r Result) IsReliable(threshold float64) bool {
+	return r.Language != "" && r.Confidence >= threshold
+}
+
+// Detects the most likely language of the text.
+func Detect(text string) Result {
+	return DetectAmong(text, nil)
+}
+
+// Detects the most likely language of the text, among the given [languages] (BCP 47 tags).
+// All the supported languages are candidates if [languages] is empty.
+func DetectAmong(text string, languages []string) Result {
+	counts := countScripts(text)
+	script, share := counts.dominant()
+	if script == scriptOther {
+		return Result{}
+	}
+	if language, ok := scriptLanguages[script]; ok {
+		if !isCandidate(language, languages) {
+			return Result{}
+		}
+		return Result{Language: language, Confidence: share}
+	}
+
+	if counts[script] < minLetters {
+		return Result{}
+	}
+
+	grams := ngrams(text)
+	best, second := math.Inf(-1), math.Inf(-1)
+	var language string
+	for _, p := range loadProfiles() {
+		if p.script != script || !isCandidate(p.language, languages) {
+			continue
+		}
+		l := p.logLikelihood(grams)
+		if l > best {
+			best, second = l, best
+			language = p.language
+		} else if l > second {
+			second = l
+		}
+	}
+	if language == "" {
+		return Result{}
+	}
+	if math.IsInf(second, -1) {
+		return Result{Language: language, Confidence: share}
+	}
+
+	// The closer the runner-up, the more ambiguous the result. The longer the text, the more evidence there is.
+	confidence := share * math.Tanh((best-second)*float64(len(grams))/evidenceScale)
+	return Result{Language: language, Confidence: confidence}
+}
+
+func isCandidate(language string, candidates []string) bool {
+	if len(candidates) == 0 {
+		return true
+	}
+	for _, c := range candidates {
+		if primarySubtag(c) == language {
+			return true
+		}
+	}
+	return false
+}
+
+func primarySubtag(tag string) string {
+	for i, c := range tag {
+		if c == '-' || c == '_' {
+			return tag[:i]
+		}
+	}
+	return tag
+}
+
+// Languages which can be detected, as BCP 47 tags.
+func Languages() []string {
+	var languages []string
+	for _, l := range scriptLanguages {
+		languages = append(languages, l)
+	}
+	for _, p := range loadProfiles() {
+		languages = append(languages, p.language)
+	}
+	sort.Strings(languages)
+	return languages
+}
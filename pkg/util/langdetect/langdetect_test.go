@@ -0,0 +1,87 @@
+package langdetect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFromProfiles(t *testing.T) {
+	for language, text := range map[string]string{
+		"en": "It is a truth universally acknowledged, that a single man in possession of a good fortune, must be in want of a wife.",
+		"fr": "Longtemps, je me suis couché de bonne heure.",
+		"de": "Der schnelle braune Fuchs springt über den faulen Hund.",
+		"es": "Cuando llegó a la ciudad, no conocía a nadie y tuvo que buscar un lugar donde dormir.",
+		"nl": "Toen hij in de stad aankwam, kende hij niemand en moest hij een plek zoeken om te slapen.",
+		"pt": "Quando chegou à cidade, não conhecia ninguém e teve de procurar um lugar para dormir.",
+		"sv": "När han kom till staden kände han ingen och var tvungen att leta efter ett ställe att sova.",
+		"hu": "Amikor megérkezett a városba, senkit sem ismert, és kellett keresnie egy helyet, ahol aludhat.",
+		"pl": "Szybki brązowy lis przeskakuje nad leniwym psem.",
+		"ru": "Когда он приехал в город, он никого не знал и должен был искать место для ночлега.",
+		"uk": "Коли він приїхав до міста, він нікого не знав і мусив шукати місце для ночівлі.",
+		"ar": "الثعلب البني السريع يقفز فوق الكلب الكسول",
+	} {
+		res := Detect(text)
+		assert.Equal(t, language, res.Language, text)
+		assert.True(t, res.IsReliable(DefaultThreshold), text)
+	}
+}
+
+// The paragraphs in testdata are not part of the sample texts of the profiles.
+func TestDetectParagraphs(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.txt")
+	require.NoError(t, err)
+	require.Len(t, files, len(loadProfiles()), "every profile has a test paragraph")
+
+	for _, file := range files {
+		language := strings.TrimSuffix(filepath.Base(file), ".txt")
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		paragraph := string(data)
+
+		res := Detect(paragraph)
+		assert.Equal(t, language, res.Language, file)
+		assert.True(t, res.IsReliable(DefaultThreshold), "%s: %v", file, res.Confidence)
+
+		// A single sentence is enough, even for close languages such as Danish and Norwegian
+		sentence, _, _ := strings.Cut(paragraph, ". ")
+		res = Detect(sentence)
+		assert.Equal(t, language, res.Language, sentence)
+		assert.True(t, res.IsReliable(DefaultThreshold), "%s: %v", sentence, res.Confidence)
+	}
+}
+
+func TestDetectFromScript(t *testing.T) {
+	for language, text := range map[string]string{
+		"el": "Η γρήγορη καφέ αλεπού πηδάει πάνω από τον τεμπέλη σκύλο.",
+		"ja": "素早い茶色の狐はのろまな犬を飛び越える",
+		"zh": "敏捷的棕色狐狸跳过了懒狗",
+		"ko": "빠른 갈색 여우가 게으른 개를 뛰어넘는다",
+	} {
+		assert.Equal(t, Result{Language: language, Confidence: 1}, Detect(text))
+	}
+}
+
+func TestDetectUnknown(t *testing.T) {
+	assert.Equal(t, Result{}, Detect(""))
+	assert.Equal(t, Result{}, Detect("1984 - 2024"))
+	assert.Equal(t, Result{}, Detect("Chapter 1"))
+	assert.False(t, Detect("Madame Bovary").IsReliable(DefaultThreshold))
+}
+
+func TestDetectAmong(t *testing.T) {
+	text := "Da han kom til byen, kendte han ingen og måtte lede efter et sted at sove."
+	assert.Equal(t, "da", DetectAmong(text, []string{"da-DK", "en"}).Language)
+	assert.Equal(t, Result{}, DetectAmong("敏捷的棕色狐狸跳过了懒狗", []string{"ja"}))
+}
+
+func TestLanguages(t *testing.T) {
+	languages := Languages()
+	assert.Contains(t, languages, "en")
+	assert.Contains(t, languages, "ru")
+	assert.Contains(t, languages, "ko")
+}
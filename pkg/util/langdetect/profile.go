@@ -0,0 +1,95 @@
+package langdetect
+
+import (
+	"embed"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Sample texts used to build the n-gram profiles, named after the BCP 47 tag of their language.
+//
+//go:embed profiles/*.txt
+var samples embed.FS
+
+// N-grams of a language with their frequency in the sample text.
+type profile struct {
+	language string
+	script   script
+	counts   map[string]int
+	total    int
+}
+
+var (
+	profilesOnce sync.Once
+	profiles     []profile
+)
+
+func loadProfiles() []profile {
+	profilesOnce.Do(func() {
+		entries, err := samples.ReadDir("profiles")
+		if err != nil {
+			panic(err)
+		}
+		for _, entry := range entries {
+			data, err := samples.ReadFile(path.Join("profiles", entry.Name()))
+			if err != nil {
+				panic(err)
+			}
+			text := string(data)
+			script, _ := countScripts(text).dominant()
+			p := profile{
+				language: strings.TrimSuffix(entry.Name(), ".txt"),
+				script:   script,
+				counts:   map[string]int{},
+			}
+			for _, ngram := range ngrams(text) {
+				p.counts[ngram]++
+				p.total++
+			}
+			profiles = append(profiles, p)
+		}
+	})
+	return profiles
+}
+
+// Splits the text into lowercase words, ignoring the digits and punctuation.
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsMark(c)
+	})
+}
+
+// Returns the 1 to 3-grams of the words of [text].
+// Words are padded with spaces, to take into account the beginning and end of words.
+func ngrams(text string) []string {
+	var ngrams []string
+	for _, word := range normalizedWords(text) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= 3; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if n == 1 && runes[i] == ' ' {
+					continue
+				}
+				ngrams = append(ngrams, string(runes[i:i+n]))
+			}
+		}
+	}
+	return ngrams
+}
+
+// Average log-probability of the n-grams in the language, with an additive smoothing for the unseen ones.
+func (p profile) logLikelihood(ngrams []string) float64 {
+	var sum float64
+	for _, ngram := range ngrams {
+		sum += math.Log((float64(p.counts[ngram]) + smoothing) / (float64(p.total) + smoothing*vocabularySize))
+	}
+	return sum / float64(len(ngrams))
+}
+
+const (
+	smoothing      = 0.5
+	vocabularySize = 20000
+)
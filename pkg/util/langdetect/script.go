@@ -0,0 +1,111 @@
+package langdetect
+
+import "unicode"
+
+// Writing system of a letter.
+type script uint8
+
+const (
+	scriptOther script = iota
+	scriptLatin
+	scriptCyrillic
+	scriptArabic
+	scriptGreek
+	scriptHebrew
+	scriptHan
+	scriptKana
+	scriptHangul
+	scriptThai
+	scriptDevanagari
+	scriptBengali
+	scriptTamil
+	scriptGeorgian
+	scriptArmenian
+)
+
+var scriptTables = []struct {
+	script script
+	table  *unicode.RangeTable
+}{
+	{scriptLatin, unicode.Latin},
+	{scriptCyrillic, unicode.Cyrillic},
+	{scriptArabic, unicode.Arabic},
+	{scriptGreek, unicode.Greek},
+	{scriptHebrew, unicode.Hebrew},
+	{scriptHan, unicode.Han},
+	{scriptKana, unicode.Hiragana},
+	{scriptKana, unicode.Katakana},
+	{scriptHangul, unicode.Hangul},
+	{scriptThai, unicode.Thai},
+	{scriptDevanagari, unicode.Devanagari},
+	{scriptBengali, unicode.Bengali},
+	{scriptTamil, unicode.Tamil},
+	{scriptGeorgian, unicode.Georgian},
+	{scriptArmenian, unicode.Armenian},
+}
+
+// Languages which can be identified from their script alone, at least as the most likely candidate.
+var scriptLanguages = map[script]string{
+	scriptGreek:      "el",
+	scriptHebrew:     "he",
+	scriptHan:        "zh",
+	scriptKana:       "ja",
+	scriptHangul:     "ko",
+	scriptThai:       "th",
+	scriptDevanagari: "hi",
+	scriptBengali:    "bn",
+	scriptTamil:      "ta",
+	scriptGeorgian:   "ka",
+	scriptArmenian:   "hy",
+}
+
+func scriptOf(c rune) script {
+	if c < 0x80 {
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+			return scriptLatin
+		}
+		return scriptOther
+	}
+	for _, t := range scriptTables {
+		if unicode.Is(t.table, c) {
+			return t.script
+		}
+	}
+	return scriptOther
+}
+
+// Counts the letters of the text by script.
+type scriptCounts map[script]int
+
+func countScripts(text string) scriptCounts {
+	counts := scriptCounts{}
+	for _, c := range text {
+		if !unicode.IsLetter(c) {
+			continue
+		}
+		counts[scriptOf(c)]++
+	}
+	return counts
+}
+
+// Returns the script used by most of the letters, and its share of all the letters.
+func (counts scriptCounts) dominant() (script, float64) {
+	var best script
+	var bestCount, total int
+	for s, count := range counts {
+		total += count
+		if count > bestCount || (count == bestCount && s < best) {
+			best, bestCount = s, count
+		}
+	}
+	if total == 0 {
+		return scriptOther, 0
+	}
+
+	// Japanese mixes kanji with kana, while Chinese has no kana at all.
+	if (best == scriptHan || best == scriptKana) && counts[scriptKana] > 0 {
+		cjk := counts[scriptHan] + counts[scriptKana]
+		return scriptKana, float64(cjk) / float64(total)
+	}
+	return best, float64(bestCount) / float64(total)
+}
@@ -88,8 +88,9 @@ func (u URITemplate) Expand(parameters map[string]string) string {
 	// This is an issue for ISO 8601 date for example.
 	// As a workaround, we encode manually this character. We don't do it in the full URI,
 	// because it could contain some legitimate +-as-space characters.
+	// The same goes for `#` and `&`, which would end a value in the query, e.g. for a CSS selector with an ID.
 	for k, v := range parameters {
-		parameters[k] = strings.Replace(v, "+", "~~+~~", -1)
+		parameters[k] = strings.NewReplacer("+", "~~+~~", "#", "~~23~~", "&", "~~26~~").Replace(v)
 	}
 
 	href, _ := NewHREF(expandRegex.ReplaceAllStringSubmatchFunc(u.uri, func(s []string) string {
@@ -103,7 +104,7 @@ func (u URITemplate) Expand(parameters map[string]string) string {
 		}
 	}), "").PercentEncodedString()
 
-	return strings.ReplaceAll(strings.ReplaceAll(href, "~~%20~~", "%2B"), "~~+~~", "%2B")
+	return strings.NewReplacer("~~%20~~", "%2B", "~~+~~", "%2B", "~~23~~", "%23", "~~26~~", "%26").Replace(href)
 
 }
 
@@ -53,3 +53,15 @@ func TestUTExpandComplicatedTemplated(t *testing.T) { // form-style ampersand-se
 		}),
 	)
 }
+
+func TestUTExpandEscapesQueryDelimiters(t *testing.T) {
+	assert.Equal(
+		t,
+		"/content?href=chapter.xhtml&cssSelector=%23intro%20%3E%20p&q=a%26b",
+		NewURITemplate("/content{?href,cssSelector,q}").Expand(map[string]string{
+			"href":        "chapter.xhtml",
+			"cssSelector": "#intro > p",
+			"q":           "a&b",
+		}),
+	)
+}
@@ -0,0 +1,107 @@
+// Package webvtt writes Web Video Text Tracks, e.g. to caption the audio narration of a publication.
+// https://www.w3.org/TR/webvtt1/
+package webvtt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+)
+
+// A caption displayed between two times of the media, in seconds.
+type Cue struct {
+	Identifier string
+	Start      float64
+	End        float64
+	Text       string
+}
+
+// Audio resources referenced by the objects of the guided navigation document, in order of appearance.
+func AudioFiles(doc manifest.GuidedNavigationDocument) []string {
+	var files []string
+	walk(doc.Guided, func(o manifest.GuidedNavigationObject) {
+		if file := o.AudioFile(); file != "" && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	})
+	return files
+}
+
+// Builds the cues of the clips of [audioFile] having a text, from the guided navigation document.
+// A clip without an end lasts until the beginning of the next clip of the same file; it is dropped when it is the last one.
+func Cues(doc manifest.GuidedNavigationDocument, audioFile string) []Cue {
+	type clip struct {
+		start float64
+		end   *float64
+		text  string
+	}
+	var clips []clip
+	walk(doc.Guided, func(o manifest.GuidedNavigationObject) {
+		if o.AudioFile() != audioFile {
+			return
+		}
+		t, ok := o.AudioTime()
+		if !ok {
+			return
+		}
+		clips = append(clips, clip{t.Begin, t.End, strings.Join(strings.Fields(o.Text), " ")})
+	})
+
+	cues := make([]Cue, 0, len(clips))
+	for i, c := range clips {
+		end := c.end
+		if end == nil && i+1 < len(clips) {
+			end = &clips[i+1].start
+		}
+		if end == nil || *end <= c.start || c.text == "" {
+			continue
+		}
+		cues = append(cues, Cue{
+			Start: c.start,
+			End:   *end,
+			Text:  c.text,
+		})
+	}
+	return cues
+}
+
+func walk(objects []manifest.GuidedNavigationObject, fn func(manifest.GuidedNavigationObject)) {
+	for _, o := range objects {
+		fn(o)
+		walk(o.Children, fn)
+	}
+}
+
+// Formats a time in seconds as a WebVTT timestamp, e.g. 01:02:03.456
+func FormatTimestamp(seconds float64) string {
+	ms := int64(math.Round(math.Max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Writes a WebVTT file containing the [cues].
+func Write(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n")
+	for _, cue := range cues {
+		bw.WriteString("\n")
+		if id := strings.Join(strings.Fields(cue.Identifier), " "); id != "" && !strings.Contains(id, "-->") {
+			bw.WriteString(id + "\n")
+		}
+		bw.WriteString(FormatTimestamp(cue.Start) + " --> " + FormatTimestamp(cue.End) + "\n")
+
+		// Blank lines would end the cue
+		for _, line := range strings.Split(cue.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				bw.WriteString(textEscaper.Replace(line) + "\n")
+			}
+		}
+	}
+	return bw.Flush()
+}
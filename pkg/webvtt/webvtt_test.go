@@ -0,0 +1,60 @@
+package webvtt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/readium/go-toolkit/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+var doc = manifest.GuidedNavigationDocument{
+	Guided: []manifest.GuidedNavigationObject{
+		{AudioRef: "audio/1.mp3#t=0,1.5", Text: "Call me   Ishmael."},
+		{Children: []manifest.GuidedNavigationObject{
+			{AudioRef: "audio/1.mp3#t=1.5", Text: "Some years ago <never mind how long>"},
+			{AudioRef: "audio/2.mp3#t=0,2", Text: "Other file"},
+			{AudioRef: "audio/1.mp3#t=3723.25,3724", Text: "precisely"},
+			{AudioRef: "audio/1.mp3#t=3724", Text: "Dropped, no end"},
+		}},
+	},
+}
+
+func TestAudioFiles(t *testing.T) {
+	assert.Equal(t, []string{"audio/1.mp3", "audio/2.mp3"}, AudioFiles(doc))
+}
+
+func TestCues(t *testing.T) {
+	assert.Equal(t, []Cue{
+		{Start: 0, End: 1.5, Text: "Call me Ishmael."},
+		{Start: 1.5, End: 3723.25, Text: "Some years ago <never mind how long>"},
+		{Start: 3723.25, End: 3724, Text: "precisely"},
+	}, Cues(doc, "audio/1.mp3"))
+	assert.Equal(t, []Cue{{Start: 0, End: 2, Text: "Other file"}}, Cues(doc, "audio/2.mp3"))
+	assert.Empty(t, Cues(doc, "audio/3.mp3"))
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	assert.Equal(t, "00:00:00.000", FormatTimestamp(0))
+	assert.Equal(t, "00:00:01.500", FormatTimestamp(1.5))
+	assert.Equal(t, "01:02:03.250", FormatTimestamp(3723.25))
+	assert.Equal(t, "00:00:00.000", FormatTimestamp(-2))
+}
+
+func TestWrite(t *testing.T) {
+	var sb strings.Builder
+	assert.NoError(t, Write(&sb, []Cue{
+		{Identifier: "1", Start: 0, End: 1.5, Text: "Call me Ishmael."},
+		{Start: 1.5, End: 3723.25, Text: "Some years ago\n\n<never mind how long>"},
+	}))
+	assert.Equal(t, `WEBVTT
+
+1
+00:00:00.000 --> 00:00:01.500
+Call me Ishmael.
+
+00:00:01.500 --> 01:02:03.250
+Some years ago
+&lt;never mind how long&gt;
+`, sb.String())
+}